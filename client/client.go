@@ -345,7 +345,7 @@ func testProductService(client pb.ProductServiceClient, categoryClient pb.Catego
 		IsActive:    true,
 		CategoryId:  categoryID, // Используем динамический ID категории
 		BrandId:     brandID,    // Используем динамический ID бренда
-		Images:      "[\"image1.jpg\", \"image2.jpg\"]",
+		Images:      "[\"https://example.com/image1.jpg\", \"https://example.com/image2.jpg\"]",
 		VideoUrl:    "https://example.com/video.mp4",
 	})
 	if err != nil {
@@ -383,7 +383,7 @@ func testProductService(client pb.ProductServiceClient, categoryClient pb.Catego
 		IsActive:    true,
 		CategoryId:  createResp.Product.CategoryId,
 		BrandId:     createResp.Product.BrandId,
-		Images:      "[\"updated_image1.jpg\", \"updated_image2.jpg\"]",
+		Images:      "[\"https://example.com/updated_image1.jpg\", \"https://example.com/updated_image2.jpg\"]",
 		VideoUrl:    "https://example.com/updated_video.mp4",
 	})
 	if err != nil {
@@ -637,7 +637,7 @@ func testProductVariantService(client pb.ProductVariantServiceClient, productCli
 		Sku:       "TEST-SKU",
 		Price:     9999,
 		Stock:     100,
-		Barcode:   "TEST-BARCODE",
+		Barcode:   "4006381333931",
 		IsActive:  true,
 		Reserved:  3,
 	})
@@ -656,7 +656,7 @@ func testProductVariantService(client pb.ProductVariantServiceClient, productCli
 		getBySKUResp.Variant.Model.Id, money.CentsToDecimal(int64(getBySKUResp.Variant.Price)))
 
 	// 📌 Get variant by Barcode
-	getByBarcodeResp, err := client.GetVariant(ctx, &pb.VariantRequest{Identifier: &pb.VariantRequest_Barcode{Barcode: "TEST-BARCODE"}, Unscoped: false})
+	getByBarcodeResp, err := client.GetVariant(ctx, &pb.VariantRequest{Identifier: &pb.VariantRequest_Barcode{Barcode: "4006381333931"}, Unscoped: false})
 	if err != nil {
 		log.Fatalf("❌ Error getting variant by barcode: %v", err)
 	}
@@ -670,7 +670,7 @@ func testProductVariantService(client pb.ProductVariantServiceClient, productCli
 		Sku:       "TEST-SKU-UPDATED",
 		Price:     14999,
 		Stock:     200,
-		Barcode:   "NEW-BARCODE",
+		Barcode:   "5901234123457",
 		IsActive:  false,
 	})
 	if err != nil {
@@ -1,21 +1,39 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
 	"net"
+	"time"
 
 	"admin/configs"
+	"admin/internal/attribute"
 	"admin/internal/brand"
+	"admin/internal/catalog"
 	"admin/internal/category"
+	"admin/internal/coupon"
+	"admin/internal/currency"
+	"admin/internal/deadletter"
 	"admin/internal/home"
+	"admin/internal/inventory"
 	"admin/internal/link"
+	"admin/internal/media"
+	"admin/internal/outbox"
 	"admin/internal/product"
 	"admin/internal/productVariant"
+	"admin/internal/promotion"
+	"admin/internal/rendition"
+	"admin/internal/reservation"
+	"admin/internal/review"
 	"admin/internal/stat"
 	"admin/internal/user"
+	"admin/internal/variantMatrix"
 	"admin/migrations"
 	"admin/pkg/db"
 	"admin/pkg/dlq"
+	"admin/pkg/event"
+	"admin/pkg/lifecycle"
+	"admin/pkg/storage"
 
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
 
@@ -24,14 +42,16 @@ import (
 	"google.golang.org/grpc"
 )
 
-func AdminApp() *grpc.Server {
-
-	conf := configs.LoadConfig()
-	consoleLvl := conf.LogLevel
-	fileLvl := conf.FileLogLevel
-	logger.InitLogger(consoleLvl, fileLvl)
-	logger.EnableFileLogging("TailorNado_admin-service")
+// AdminApp собирает приложение и регистрирует его компоненты в app в порядке запуска:
+// БД, миграции, Kafka, фоновые воркеры, gRPC. Останавливаются они в обратном порядке
+func AdminApp(conf *configs.Config, app *lifecycle.Manager) {
 	db := db.NewDB(conf)
+	app.Add("database", db.Ping, func(context.Context) error { return db.Close() })
+	app.Add("migrations", func(context.Context) error { return migrations.CheckForMigrations() }, nil)
+
+	// шина событий закрывается последней из прикладных компонентов: gRPC и воркеры ещё публикуют
+	bus := event.NewBus()
+	app.Add("event bus", nil, bus.Close)
 
 	// Создаем новый gRPC-сервер
 	grpcServer := grpc.NewServer()
@@ -44,19 +64,68 @@ func AdminApp() *grpc.Server {
 	productRepository := product.NewProductRepository(db)
 	categoryRepository := category.NewCategoryRepository(db)
 	productVariantRepository := productVariant.NewProductVariantRepository(db)
+	catalogRepository := catalog.NewCatalogRepository(db)
+	promotionRepository := promotion.NewPromotionRepository(db)
+	couponRepository := coupon.NewCouponRepository(db)
+	currencyRepository := currency.NewCurrencyRepository(db)
+	attributeRepository := attribute.NewAttributeRepository(db)
+	variantMatrixRepository := variantMatrix.NewVariantMatrixRepository(db)
+	mediaRepository := media.NewMediaRepository(db)
+	renditionRepository := rendition.NewRenditionRepository(db)
+	reviewRepository := review.NewReviewRepository(db)
+	deadLetterRepository := deadletter.NewDeadLetterRepository(db)
+	outboxRepository := outbox.NewOutboxRepository(db)
+	inventoryRepository := inventory.NewInventoryRepository(db)
+	reservationRepository := reservation.NewReservationRepository(db)
+
+	// storage
+	mediaStorage := storage.NewLocalStorage(conf.Media.Root, conf.Media.BaseURL)
 
 	//validators
-	validator := &productVariant.ProductVariantValidator{}
+	validator := productVariant.NewProductVariantValidator()
+	productValidator := product.NewProductValidator()
+	promotionValidator := &promotion.PromotionValidator{}
+	couponValidator := &coupon.CouponValidator{}
+	attributeValidator := &attribute.AttributeValidator{}
+	reviewValidator := review.NewReviewValidator()
 
 	// services
 	linkService := link.NewLinkService(linkRepository)
-	statService := stat.NewStatService(&stat.StatServiceDeps{StatRepository: statRepository})
-	homeService := home.NewHomeService(categoryRepository, productRepository, brandRepository)
+	statService := stat.NewStatService(&stat.StatServiceDeps{StatRepository: statRepository, Bus: bus})
+	statService.Subscribe(bus)
+	homeService := home.NewHomeService(categoryRepository, productRepository, brandRepository, promotionRepository)
 	userService := user.NewUserService(userRepository)
 	brandService := brand.NewBrandService(brandRepository)
-	productService := product.NewProductServiceServer(productRepository)
+	productService := product.NewProductServiceServer(productRepository, productValidator)
 	categoryService := category.NewCategoryService(categoryRepository)
-	productVariantService := productVariant.NewVariantService(productVariantRepository, validator)
+	barcodeService := productVariant.NewBarcodeService(productVariantRepository, conf.Barcode.CompanyPrefix)
+	catalogService := catalog.NewCatalogService(catalogRepository, brandRepository)
+	promotionService := promotion.NewPromotionService(promotionRepository, promotionValidator)
+	couponService := coupon.NewCouponService(couponRepository, couponValidator)
+	currencyService := currency.NewCurrencyService(currencyRepository, productVariantRepository, conf.Currency.Base)
+	attributeService := attribute.NewAttributeService(attributeRepository, attributeValidator)
+	productVariantService := productVariant.NewVariantService(productVariantRepository, validator, attributeService)
+	variantMatrixService := variantMatrix.NewVariantMatrixService(variantMatrixRepository, productVariantRepository, attributeRepository, validator, attributeValidator)
+	mediaService := media.NewMediaService(mediaRepository, mediaStorage)
+	renditionService := rendition.NewRenditionService(renditionRepository)
+	reviewService := review.NewReviewService(reviewRepository, reviewValidator)
+	// с неверной схемой проверка payload молча отключилась бы: запуск прерывается
+	dlqSchemas, schemasErr := dlq.LoadSchemas(conf.Dlq.SchemasDir)
+	app.Add("dlq schemas", func(context.Context) error { return schemasErr }, nil)
+	dlqRunner := dlq.NewRunner(conf.Dlq, deadLetterRepository, dlqSchemas)
+	// продюсеры создаются при первой переотправке, недоступность Kafka не мешает запуску
+	deadLetterProducers := dlq.NewTopicProducers([]string{conf.Dlq.Broker})
+	deadLetterService := deadletter.NewDeadLetterService(deadLetterRepository, dlqRunner.Router(), deadLetterProducers, dlqSchemas)
+	inventoryService := inventory.NewInventoryService(inventoryRepository)
+
+	// курсы пишутся в БД, поэтому загружаются после миграций.
+	// Без них цены пересчитывались бы по старым курсам: запуск прерывается
+	app.Add("exchange rates", func(context.Context) error {
+		if conf.Currency.RatesFile == "" {
+			return nil
+		}
+		return currencyService.LoadRatesFromFile(conf.Currency.RatesFile)
+	}, nil)
 
 	// registration
 	pb.RegisterUserServiceServer(grpcServer, userService)
@@ -68,24 +137,84 @@ func AdminApp() *grpc.Server {
 	pb.RegisterStatServiceServer(grpcServer, statService)
 	pb.RegisterProductVariantServiceServer(grpcServer, productVariantService)
 
-	log.Println("🚀 Запуск DLQ процессора...")
-	dlq.StartDLQProcessor(conf)
-
-	return grpcServer
+	// сервисы без описания в admin-proto: регистрируются после генерации их ServiceDesc
+	_ = catalogService
+	_ = promotionService
+	_ = couponService
+	_ = currencyService
+	_ = attributeService
+	_ = variantMatrixService
+	_ = barcodeService
+	_ = mediaService
+	_ = renditionService
+	_ = reviewService
+	_ = deadLetterService
+	_ = inventoryService
+	// HomeDataResponse в admin-proto без поля акций: главная с акциями ждёт его генерации
+	_ = homeService.GetHomeDataWithPromotions
+
+	// kafka
+	app.Add("dlq processor", dlqRunner.Start, dlqRunner.Stop)
+	inventoryConsumer := inventory.NewConsumer(inventoryRepository, []string{conf.Inventory.Broker}, conf.Inventory.Topic, conf.Dlq.ConsumerTopic)
+	app.Add("inventory consumer", inventoryConsumer.Start, inventoryConsumer.Stop)
+	orderStockConsumer := reservation.NewConsumer(reservationRepository, []string{conf.Orders.Broker}, conf.Orders.Topic, conf.Dlq.ConsumerTopic)
+	app.Add("order stock consumer", orderStockConsumer.Start, orderStockConsumer.Stop)
+	outboxPublisher := outbox.NewKafkaPublisher([]string{conf.Outbox.Broker}, conf.Outbox.Topic)
+	app.Add("kafka producers", nil, func(context.Context) error {
+		return errors.Join(deadLetterProducers.Close(), outboxPublisher.Close())
+	})
+
+	// workers
+	workers := &lifecycle.Workers{}
+	priceScheduler := productVariant.NewPriceScheduler(productVariantRepository)
+	workers.Go(func(ctx context.Context) { priceScheduler.Run(ctx, time.Minute) })
+	orphanCleaner := media.NewOrphanCleaner(mediaService, media.DefaultOrphanGrace)
+	workers.Go(func(ctx context.Context) { orphanCleaner.Run(ctx, time.Hour) })
+	renditionWorker := rendition.NewWorker(renditionRepository, mediaStorage, conf.Media.FetchHosts)
+	workers.Go(func(ctx context.Context) { renditionWorker.Run(ctx, 30*time.Second) })
+	outboxRelay := outbox.NewRelay(outboxRepository, outboxPublisher)
+	workers.Go(func(ctx context.Context) { outboxRelay.Run(ctx, time.Second) })
+	app.Add("workers", workers.Start, workers.Stop)
+
+	// grpc
+	app.Add("grpc server", func(context.Context) error {
+		listener, err := net.Listen("tcp", ":50051")
+		if err != nil {
+			return err
+		}
+		logger.Info("gRPC server is running on :50051")
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				app.Fail(err)
+			}
+		}()
+		return nil
+	}, func(ctx context.Context) error {
+		// дожидаемся текущих RPC; по дедлайну обрываем оставшиеся
+		done := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	})
 }
 
 func main() {
-	listener, err := net.Listen("tcp", ":50051")
-	if err != nil {
-		logger.Errorf("Error due conn to tcp: %v", err)
-		return
-	}
-	migrations.CheckForMigrations()
-	logger.Info("gRPC server is running on :50051")
-
-	grpcServer := AdminApp()
+	conf := configs.LoadConfig()
+	logger.InitLogger(conf.LogLevel, conf.FileLogLevel)
+	logger.EnableFileLogging("TailorNado_admin-service")
+	defer logger.CloseFileLogs()
 
-	if err := grpcServer.Serve(listener); err != nil {
-		logger.Errorf("Error due starting the gRPC server: %v", err)
+	app := lifecycle.NewManager(conf.ShutdownTimeout)
+	AdminApp(conf, app)
+	if err := app.Run(context.Background()); err != nil {
+		logger.Errorf("Error due running the admin service: %v", err)
 	}
 }
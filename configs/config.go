@@ -2,6 +2,9 @@ package configs
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
 
@@ -12,13 +15,54 @@ import (
 type Config struct {
 	Db           DbConfig
 	Dlq          DlqConfig
+	Currency     CurrencyConfig
+	Barcode      BarcodeConfig
+	Media        MediaConfig
+	Outbox       OutboxConfig
+	Inventory    InventoryConfig
+	Orders       OrdersConfig
 	LogLevel     logger.LogLevel
 	FileLogLevel logger.LogLevel
+	// сколько ждать остановки gRPC, консьюмеров, продюсеров и БД по SIGTERM
+	ShutdownTimeout time.Duration
 }
 type DlqConfig struct {
 	Broker        string
 	ConsumerTopic string
 	ProducerTopic string
+	ParkingTopic  string // сообщения, которые больше не повторяются; по умолчанию ConsumerTopic + ".parking"
+	// сообщения, не прошедшие проверку модели или схемы; по умолчанию ConsumerTopic + ".quarantine"
+	QuarantineTopic string
+	SchemasDir      string // каталог JSON-схем payload по категориям и подтипам
+	RulesFile       string // YAML/JSON с правилами маршрутизации, перечитывается при изменении
+	Workers         int    // сколько партиций обрабатывается параллельно, по умолчанию 4
+}
+type CurrencyConfig struct {
+	Base      string // валюта, в которой хранятся цены вариантов
+	RatesFile string // JSON-файл с курсами, загружается при старте
+}
+type BarcodeConfig struct {
+	CompanyPrefix string // префикс компании GS1 для внутренних EAN-13
+}
+type MediaConfig struct {
+	Root    string // каталог локального хранилища изображений
+	BaseURL string // абсолютный адрес, по которому раздаётся Root
+	// хосты, с которых можно скачивать внешние изображения для производных, через запятую
+	FetchHosts []string
+}
+type OutboxConfig struct {
+	Broker string
+	Topic  string // топик доменных событий каталога, по умолчанию catalog.events
+}
+type InventoryConfig struct {
+	Broker string
+	Topic  string // снимки и изменения остатков из WMS, по умолчанию wms.inventory
+}
+type OrdersConfig struct {
+	Broker string
+	// события жизненного цикла заказов, по умолчанию order.events;
+	// ответы stock.* публикуются через outbox в топик Outbox.Topic
+	Topic string
 }
 type DbConfig struct {
 	Dsn string
@@ -40,17 +84,52 @@ func LoadConfig() *Config {
 		fileLogLevelStr = "INFO"
 	}
 	FileLogLevel := configs.ParseLogLevel(fileLogLevelStr)
+	// пусто или некорректно — число воркеров DLQ по умолчанию
+	dlqWorkers, _ := strconv.Atoi(os.Getenv("DLQ_WORKERS"))
+	// пусто или некорректно — дедлайн остановки по умолчанию
+	shutdownTimeout, _ := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT"))
 
 	return &Config{
 		Db: DbConfig{
 			Dsn: os.Getenv("DSN"),
 		},
 		Dlq: DlqConfig{
-			Broker:        os.Getenv("KAFKA_BROKER"),
-			ConsumerTopic: os.Getenv("KAFKA_CONSUMER"),
-			ProducerTopic: os.Getenv("KAFKA_PRODUCER"),
+			Broker:          os.Getenv("KAFKA_BROKER"),
+			ConsumerTopic:   os.Getenv("KAFKA_CONSUMER"),
+			ProducerTopic:   os.Getenv("KAFKA_PRODUCER"),
+			ParkingTopic:    os.Getenv("KAFKA_DLQ_PARKING"),
+			RulesFile:       os.Getenv("DLQ_RULES_FILE"),
+			Workers:         dlqWorkers,
+			QuarantineTopic: os.Getenv("KAFKA_DLQ_QUARANTINE"),
+			SchemasDir:      os.Getenv("DLQ_SCHEMAS_DIR"),
+		},
+		Currency: CurrencyConfig{
+			Base:      os.Getenv("BASE_CURRENCY"),
+			RatesFile: os.Getenv("EXCHANGE_RATES_FILE"),
+		},
+		Barcode: BarcodeConfig{
+			CompanyPrefix: os.Getenv("GS1_COMPANY_PREFIX"),
+		},
+		Media: MediaConfig{
+			Root:       os.Getenv("MEDIA_ROOT"),
+			BaseURL:    os.Getenv("MEDIA_BASE_URL"),
+			FetchHosts: strings.FieldsFunc(os.Getenv("MEDIA_FETCH_HOSTS"), func(r rune) bool { return r == ',' || r == ' ' }),
+		},
+		Outbox: OutboxConfig{
+			Broker: os.Getenv("KAFKA_BROKER"),
+			Topic:  os.Getenv("KAFKA_OUTBOX_TOPIC"),
+		},
+		Inventory: InventoryConfig{
+			Broker: os.Getenv("KAFKA_BROKER"),
+			Topic:  os.Getenv("KAFKA_INVENTORY_TOPIC"),
+		},
+		Orders: OrdersConfig{
+			Broker: os.Getenv("KAFKA_BROKER"),
+			Topic:  os.Getenv("KAFKA_ORDER_EVENTS_TOPIC"),
 		},
 		LogLevel:     LogLevel,
 		FileLogLevel: FileLogLevel,
+
+		ShutdownTimeout: shutdownTimeout,
 	}
 }
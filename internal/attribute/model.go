@@ -0,0 +1,57 @@
+package attribute
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	TypeString    = "string"     // произвольная строка
+	TypeNumber    = "number"     // число, единица измерения в Unit
+	TypeBoolean   = "boolean"    // да/нет
+	TypeEnum      = "enum"       // одно значение из AllowedValues
+	TypeMultiEnum = "multi_enum" // несколько значений из AllowedValues
+)
+
+// Definition — атрибут вариантов категории. Действует и для всех её подкатегорий
+type Definition struct {
+	gorm.Model
+	CategoryID    uint     `gorm:"not null;uniqueIndex:idx_attribute_definitions_code,where:deleted_at IS NULL" json:"category_id"`
+	Code          string   `gorm:"type:varchar(64);not null;uniqueIndex:idx_attribute_definitions_code,where:deleted_at IS NULL" json:"code"` // машинное имя, например screen_size
+	Name          string   `gorm:"type:varchar(255);not null" json:"name"`
+	Type          string   `gorm:"type:varchar(20);not null" json:"type"`
+	AllowedValues []string `gorm:"type:json;serializer:json" json:"allowed_values"` // для enum и multi_enum
+	Unit          string   `gorm:"type:varchar(20)" json:"unit"`                    // например "см", "мАч"
+	Required      bool     `gorm:"not null" json:"required"`
+	Position      int      `gorm:"default:0" json:"position"` // порядок отображения
+}
+
+func (Definition) TableName() string {
+	return "attribute_definitions"
+}
+
+// Value — значение атрибута у варианта. У multi_enum по строке на каждое значение,
+// заполнена только колонка, соответствующая типу атрибута
+type Value struct {
+	ID           uint                `gorm:"primarykey"`
+	VariantID    uint                `gorm:"not null;uniqueIndex:idx_attribute_values_variant"`
+	DefinitionID uint                `gorm:"not null;uniqueIndex:idx_attribute_values_variant;index:idx_attribute_values_lookup"`
+	Definition   Definition          `gorm:"foreignKey:DefinitionID;constraint:OnDelete:CASCADE"`
+	StringValue  string              `gorm:"type:varchar(255);not null;default:'';uniqueIndex:idx_attribute_values_variant;index:idx_attribute_values_lookup"`
+	NumberValue  decimal.NullDecimal `gorm:"type:decimal(18,4)"`
+	BoolValue    *bool
+}
+
+func (Value) TableName() string {
+	return "variant_attribute_values"
+}
+
+// Filter — условие отбора вариантов по атрибуту.
+// Values — для строк и перечислений (любое из), Min/Max — для чисел, Bool — для boolean
+type Filter struct {
+	Definition Definition
+	Values     []string
+	Min        decimal.NullDecimal
+	Max        decimal.NullDecimal
+	Bool       *bool
+}
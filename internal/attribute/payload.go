@@ -0,0 +1,64 @@
+package attribute
+
+// Сообщения AttributeService. Когда RPC появятся в admin-proto,
+// эти типы заменяются сгенерированными.
+
+type DefinitionMessage struct {
+	Id            uint32   `json:"id"`
+	CategoryId    uint32   `json:"category_id"`
+	Code          string   `json:"code"`
+	Name          string   `json:"name"`
+	Type          string   `json:"type"` // string, number, boolean, enum, multi_enum
+	AllowedValues []string `json:"allowed_values"`
+	Unit          string   `json:"unit"`
+	Required      bool     `json:"required"`
+	Position      int32    `json:"position"`
+}
+
+type DeleteDefinitionRequest struct {
+	Id uint32 `json:"id"`
+}
+
+type GetCategorySchemaRequest struct {
+	CategoryId uint32 `json:"category_id"`
+}
+
+type GetCategorySchemaResponse struct {
+	Attributes []*DefinitionMessage `json:"attributes"` // включая унаследованные от родительских категорий
+}
+
+type AttributeValueMessage struct {
+	Code   string   `json:"code"`
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Unit   string   `json:"unit"`
+	Values []string `json:"values"` // несколько значений только у multi_enum
+}
+
+type SetVariantAttributesRequest struct {
+	VariantId  uint32                   `json:"variant_id"`
+	Attributes []*AttributeValueMessage `json:"attributes"` // заменяют все текущие значения
+}
+
+type GetVariantAttributesRequest struct {
+	VariantId uint32 `json:"variant_id"`
+}
+
+type VariantAttributesResponse struct {
+	VariantId  uint32                   `json:"variant_id"`
+	Attributes []*AttributeValueMessage `json:"attributes"`
+}
+
+type AttributeFilterMessage struct {
+	Code   string   `json:"code"`
+	Values []string `json:"values"` // строки и перечисления: любое из значений; boolean: "true"/"false"
+	Min    string   `json:"min"`    // для number, десятичная строка
+	Max    string   `json:"max"`
+}
+
+type FilterVariantsRequest struct {
+	CategoryId uint32                    `json:"category_id"`
+	Filters    []*AttributeFilterMessage `json:"filters"`
+	Limit      uint32                    `json:"limit"`
+	Offset     uint32                    `json:"offset"`
+}
@@ -0,0 +1,251 @@
+package attribute
+
+import (
+	"admin/internal/category"
+	"admin/internal/productVariant"
+	"admin/pkg/db"
+
+	"gorm.io/gorm"
+)
+
+type AttributeRepository struct {
+	Database *db.Db
+}
+
+func NewAttributeRepository(database *db.Db) *AttributeRepository {
+	return &AttributeRepository{
+		Database: database,
+	}
+}
+
+func (repo *AttributeRepository) CreateDefinition(definition *Definition) (*Definition, error) {
+	if err := repo.Database.DB.First(&category.Category{}, definition.CategoryID).Error; err != nil {
+		return nil, err
+	}
+	if err := repo.Database.DB.Create(definition).Error; err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+func (repo *AttributeRepository) GetDefinition(id uint) (*Definition, error) {
+	var definition Definition
+	result := repo.Database.DB.First(&definition, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &definition, nil
+}
+
+// UpdateDefinition меняет описание атрибута. Код, категория и тип не меняются,
+// чтобы не ломать сохранённые значения
+func (repo *AttributeRepository) UpdateDefinition(definition *Definition) (*Definition, error) {
+	result := repo.Database.DB.Model(&Definition{}).
+		Where("id = ?", definition.ID).
+		Select("name", "allowed_values", "unit", "required", "position").
+		Updates(definition)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return repo.GetDefinition(definition.ID)
+}
+
+// DeleteDefinition удаляет атрибут вместе со значениями у вариантов
+func (repo *AttributeRepository) DeleteDefinition(id uint) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("definition_id = ?", id).Delete(&Value{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&Definition{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// GetCategorySchema возвращает атрибуты категории вместе с унаследованными от родительских
+func (repo *AttributeRepository) GetCategorySchema(categoryID uint) ([]Definition, error) {
+	ids, err := repo.categoryAncestors(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	var definitions []Definition
+	result := repo.Database.DB.
+		Where("category_id IN ?", ids).
+		Order("position, id").
+		Find(&definitions)
+	return definitions, result.Error
+}
+
+// GetProductCategory возвращает категорию активного товара
+func (repo *AttributeRepository) GetProductCategory(productID uint) (uint, error) {
+	var row struct {
+		CategoryID uint
+	}
+	result := repo.Database.DB.Table("products").
+		Select("category_id").
+		Where("id = ? AND deleted_at IS NULL", productID).
+		Take(&row)
+	return row.CategoryID, result.Error
+}
+
+// GetVariantCategory возвращает категорию товара, к которому относится вариант
+func (repo *AttributeRepository) GetVariantCategory(variantID uint) (uint, error) {
+	var categoryID uint
+	result := repo.Database.DB.Model(&productVariant.ProductVariant{}).
+		Select("products.category_id").
+		Joins("JOIN products ON products.id = product_variants.product_id").
+		Where("product_variants.id = ?", variantID).
+		Limit(1).
+		Scan(&categoryID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return categoryID, nil
+}
+
+// ReplaceVariantValues заменяет все значения атрибутов варианта
+func (repo *AttributeRepository) ReplaceVariantValues(variantID uint, values []Value) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("variant_id = ?", variantID).Delete(&Value{}).Error; err != nil {
+			return err
+		}
+		return CreateValues(tx, variantID, values)
+	})
+}
+
+// CreateValues сохраняет значения атрибутов нового варианта. tx — транзакция создания варианта
+func CreateValues(tx *gorm.DB, variantID uint, values []Value) error {
+	if len(values) == 0 {
+		return nil
+	}
+	for i := range values {
+		values[i].VariantID = variantID
+	}
+	return tx.Omit("Definition").Create(&values).Error
+}
+
+func (repo *AttributeRepository) GetVariantValues(variantID uint) ([]Value, error) {
+	var values []Value
+	result := repo.Database.DB.
+		Preload("Definition").
+		Joins("JOIN attribute_definitions ON attribute_definitions.id = variant_attribute_values.definition_id").
+		Where("variant_attribute_values.variant_id = ?", variantID).
+		Order("attribute_definitions.position, variant_attribute_values.definition_id, variant_attribute_values.string_value").
+		Find(&values)
+	return values, result.Error
+}
+
+// FilterVariants возвращает активные варианты категории и её подкатегорий,
+// подходящие под все фильтры по атрибутам
+func (repo *AttributeRepository) FilterVariants(categoryID uint, filters []Filter, limit, offset int) ([]productVariant.ProductVariant, int64, error) {
+	categoryIDs, err := repo.categoryDescendants(categoryID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := repo.Database.DB.Model(&productVariant.ProductVariant{}).
+		Joins("JOIN products ON products.id = product_variants.product_id AND products.deleted_at IS NULL").
+		Where("products.category_id IN ? AND product_variants.is_active = true", categoryIDs)
+
+	for _, f := range filters {
+		sub := repo.Database.DB.Model(&Value{}).
+			Select("1").
+			Where("variant_attribute_values.variant_id = product_variants.id AND variant_attribute_values.definition_id = ?", f.Definition.ID)
+		switch f.Definition.Type {
+		case TypeNumber:
+			if f.Min.Valid {
+				sub = sub.Where("number_value >= ?", f.Min.Decimal)
+			}
+			if f.Max.Valid {
+				sub = sub.Where("number_value <= ?", f.Max.Decimal)
+			}
+		case TypeBoolean:
+			if f.Bool != nil {
+				sub = sub.Where("bool_value = ?", *f.Bool)
+			}
+		default:
+			if len(f.Values) > 0 {
+				sub = sub.Where("string_value IN ?", f.Values)
+			}
+		}
+		query = query.Where("EXISTS (?)", sub)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var variants []productVariant.ProductVariant
+	result := query.Order("product_variants.id").Limit(limit).Offset(offset).Find(&variants)
+	return variants, total, result.Error
+}
+
+// CodeInUse проверяет, занят ли код атрибута в родительских категориях или подкатегориях:
+// схема подкатегории включает атрибуты родителей, и коды в ней должны быть уникальны
+func (repo *AttributeRepository) CodeInUse(categoryID uint, code string) (bool, error) {
+	ancestors, err := repo.categoryAncestors(categoryID)
+	if err != nil {
+		return false, err
+	}
+	descendants, err := repo.categoryDescendants(categoryID)
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	err = repo.Database.DB.Model(&Definition{}).
+		Where("code = ? AND category_id IN ?", code, append(ancestors, descendants...)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (repo *AttributeRepository) categoryAncestors(categoryID uint) ([]uint, error) {
+	ids := []uint{}
+	visited := map[uint]bool{}
+	current := &categoryID
+	for current != nil && !visited[*current] { // защита от циклов в дереве
+		var c category.Category
+		if err := repo.Database.DB.Select("id", "parent_category_id").First(&c, *current).Error; err != nil {
+			return nil, err
+		}
+		visited[c.ID] = true
+		ids = append(ids, c.ID)
+		current = c.ParentCategoryID
+	}
+	return ids, nil
+}
+
+func (repo *AttributeRepository) categoryDescendants(categoryID uint) ([]uint, error) {
+	ids := []uint{categoryID}
+	visited := map[uint]bool{categoryID: true}
+	parents := []uint{categoryID}
+	for len(parents) > 0 {
+		var children []uint
+		if err := repo.Database.DB.Model(&category.Category{}).
+			Where("parent_category_id IN ?", parents).
+			Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		parents = parents[:0]
+		for _, child := range children {
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+			ids = append(ids, child)
+			parents = append(parents, child)
+		}
+	}
+	return ids, nil
+}
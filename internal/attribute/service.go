@@ -0,0 +1,339 @@
+package attribute
+
+import (
+	"admin/internal/productVariant"
+	"admin/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultFilterLimit = 20
+	maxFilterLimit     = 100
+)
+
+type AttributeService struct {
+	AttributeRepository *AttributeRepository
+	validator           *AttributeValidator
+}
+
+func NewAttributeService(attributeRepository *AttributeRepository, validator *AttributeValidator) *AttributeService {
+	return &AttributeService{
+		AttributeRepository: attributeRepository,
+		validator:           validator,
+	}
+}
+
+// DefineAttribute добавляет атрибут в схему категории
+func (s *AttributeService) DefineAttribute(ctx context.Context, req *DefinitionMessage) (*DefinitionMessage, error) {
+	definition := ConvertPayloadToDB(req)
+	definition.ID = 0
+	if err := s.validator.ValidateDefinition(definition); err != nil {
+		logger.Errorf("DefineAttribute validation error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	inUse, err := s.AttributeRepository.CodeInUse(definition.CategoryID, definition.Code)
+	if err != nil {
+		logger.Errorf("DefineAttribute error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	if inUse {
+		logger.Errorf("DefineAttribute error: code %s is already used in category tree %d", definition.Code, definition.CategoryID)
+		return nil, status.Errorf(codes.AlreadyExists, "attribute %s is already defined in this category tree", definition.Code)
+	}
+
+	created, err := s.AttributeRepository.CreateDefinition(definition)
+	if err != nil {
+		logger.Errorf("DefineAttribute error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	return ConvertDBToPayload(created), nil
+}
+
+// UpdateAttribute меняет название, допустимые значения, единицу, обязательность и порядок атрибута
+func (s *AttributeService) UpdateAttribute(ctx context.Context, req *DefinitionMessage) (*DefinitionMessage, error) {
+	current, err := s.AttributeRepository.GetDefinition(uint(req.Id))
+	if err != nil {
+		logger.Errorf("UpdateAttribute error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+
+	updated := ConvertPayloadToDB(req)
+	updated.CategoryID, updated.Code, updated.Type = current.CategoryID, current.Code, current.Type
+	if err := s.validator.ValidateDefinition(updated); err != nil {
+		logger.Errorf("UpdateAttribute validation error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := s.AttributeRepository.UpdateDefinition(updated)
+	if err != nil {
+		logger.Errorf("UpdateAttribute error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	return ConvertDBToPayload(result), nil
+}
+
+func (s *AttributeService) DeleteAttribute(ctx context.Context, req *DeleteDefinitionRequest) (*pb.Error, error) {
+	if err := s.AttributeRepository.DeleteDefinition(uint(req.Id)); err != nil {
+		logger.Errorf("DeleteAttribute error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	return &pb.Error{}, nil
+}
+
+func (s *AttributeService) GetCategorySchema(ctx context.Context, req *GetCategorySchemaRequest) (*GetCategorySchemaResponse, error) {
+	schema, err := s.AttributeRepository.GetCategorySchema(uint(req.CategoryId))
+	if err != nil {
+		logger.Errorf("GetCategorySchema error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	resp := &GetCategorySchemaResponse{Attributes: make([]*DefinitionMessage, 0, len(schema))}
+	for i := range schema {
+		resp.Attributes = append(resp.Attributes, ConvertDBToPayload(&schema[i]))
+	}
+	return resp, nil
+}
+
+// SetVariantAttributes проверяет значения по схеме категории варианта и заменяет ими текущие
+func (s *AttributeService) SetVariantAttributes(ctx context.Context, req *SetVariantAttributesRequest) (*VariantAttributesResponse, error) {
+	schema, err := s.variantSchema(uint(req.VariantId))
+	if err != nil {
+		logger.Errorf("SetVariantAttributes error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+
+	values, err := s.validator.ValidateValues(schema, ValuesInput(req.Attributes))
+	if err != nil {
+		logger.Errorf("SetVariantAttributes validation error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.AttributeRepository.ReplaceVariantValues(uint(req.VariantId), values); err != nil {
+		logger.Errorf("SetVariantAttributes error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return s.GetVariantAttributes(ctx, &GetVariantAttributesRequest{VariantId: req.VariantId})
+}
+
+// PrepareVariantValues проверяет атрибуты нового варианта по схеме категории товара,
+// включая обязательные. Реализует productVariant.VariantAttributes
+func (s *AttributeService) PrepareVariantValues(productID uint, input map[string][]string) (func(tx *gorm.DB, variantID uint) error, error) {
+	categoryID, err := s.AttributeRepository.GetProductCategory(productID)
+	if err != nil {
+		logger.Errorf("PrepareVariantValues error: product %d: %v", productID, err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "product not found")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	schema, err := s.AttributeRepository.GetCategorySchema(categoryID)
+	if err != nil {
+		logger.Errorf("PrepareVariantValues error: category schema %d: %v", categoryID, err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	values, err := s.validator.ValidateValues(schema, input)
+	if err != nil {
+		logger.Errorf("PrepareVariantValues validation error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return func(tx *gorm.DB, variantID uint) error {
+		return CreateValues(tx, variantID, values)
+	}, nil
+}
+
+func (s *AttributeService) GetVariantAttributes(ctx context.Context, req *GetVariantAttributesRequest) (*VariantAttributesResponse, error) {
+	values, err := s.AttributeRepository.GetVariantValues(uint(req.VariantId))
+	if err != nil {
+		logger.Errorf("GetVariantAttributes error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &VariantAttributesResponse{
+		VariantId:  req.VariantId,
+		Attributes: convertValuesToPayload(values),
+	}, nil
+}
+
+// FilterVariants ищет активные варианты категории по любым атрибутам её схемы
+func (s *AttributeService) FilterVariants(ctx context.Context, req *FilterVariantsRequest) (*pb.VariantListResponse, error) {
+	if req.CategoryId == 0 {
+		logger.Error("FilterVariants error: category ID is required")
+		return nil, status.Error(codes.InvalidArgument, "category ID is required")
+	}
+	schema, err := s.AttributeRepository.GetCategorySchema(uint(req.CategoryId))
+	if err != nil {
+		logger.Errorf("FilterVariants error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	filters, err := buildFilters(schema, req.Filters)
+	if err != nil {
+		logger.Errorf("FilterVariants error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultFilterLimit
+	}
+	if limit > maxFilterLimit {
+		limit = maxFilterLimit
+	}
+	variants, total, err := s.AttributeRepository.FilterVariants(uint(req.CategoryId), filters, limit, int(req.Offset))
+	if err != nil {
+		logger.Errorf("FilterVariants error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &pb.VariantListResponse{
+		Variants:   make([]*pb.ProductVariant, 0, len(variants)),
+		TotalCount: uint32(total),
+	}
+	for i := range variants {
+		resp.Variants = append(resp.Variants, productVariant.ConvertDBToProto(&variants[i]))
+	}
+	return resp, nil
+}
+
+func (s *AttributeService) variantSchema(variantID uint) ([]Definition, error) {
+	categoryID, err := s.AttributeRepository.GetVariantCategory(variantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.AttributeRepository.GetCategorySchema(categoryID)
+}
+
+func buildFilters(schema []Definition, messages []*AttributeFilterMessage) ([]Filter, error) {
+	byCode := make(map[string]Definition, len(schema))
+	for _, d := range schema {
+		byCode[d.Code] = d
+	}
+
+	filters := make([]Filter, 0, len(messages))
+	for _, m := range messages {
+		d, ok := byCode[m.Code]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAttribute, m.Code)
+		}
+		f := Filter{Definition: d}
+		switch d.Type {
+		case TypeNumber:
+			var err error
+			if f.Min, err = parseBound(m.Min); err != nil {
+				return nil, err
+			}
+			if f.Max, err = parseBound(m.Max); err != nil {
+				return nil, err
+			}
+		case TypeBoolean:
+			if len(m.Values) != 1 {
+				return nil, errors.New("boolean filter " + m.Code + " needs exactly one value")
+			}
+			b, err := strconv.ParseBool(m.Values[0])
+			if err != nil {
+				return nil, errors.New("boolean filter " + m.Code + " must be true or false")
+			}
+			f.Bool = &b
+		default:
+			f.Values = m.Values
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+func parseBound(value string) (decimal.NullDecimal, error) {
+	if value == "" {
+		return decimal.NullDecimal{}, nil
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.NullDecimal{}, errors.New("invalid number bound " + value)
+	}
+	return decimal.NewNullDecimal(d), nil
+}
+
+// ValuesInput собирает значения из сообщений по кодам атрибутов для ValidateValues
+func ValuesInput(messages []*AttributeValueMessage) map[string][]string {
+	input := make(map[string][]string, len(messages))
+	for _, m := range messages {
+		input[m.Code] = append(input[m.Code], m.Values...)
+	}
+	return input
+}
+
+func writeErrorToStatus(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func ConvertDBToPayload(d *Definition) *DefinitionMessage {
+	if d == nil {
+		return nil
+	}
+	return &DefinitionMessage{
+		Id:            uint32(d.ID),
+		CategoryId:    uint32(d.CategoryID),
+		Code:          d.Code,
+		Name:          d.Name,
+		Type:          d.Type,
+		AllowedValues: d.AllowedValues,
+		Unit:          d.Unit,
+		Required:      d.Required,
+		Position:      int32(d.Position),
+	}
+}
+
+func ConvertPayloadToDB(m *DefinitionMessage) *Definition {
+	definition := &Definition{
+		CategoryID:    uint(m.CategoryId),
+		Code:          m.Code,
+		Name:          m.Name,
+		Type:          m.Type,
+		AllowedValues: m.AllowedValues,
+		Unit:          m.Unit,
+		Required:      m.Required,
+		Position:      int(m.Position),
+	}
+	definition.ID = uint(m.Id)
+	return definition
+}
+
+// convertValuesToPayload собирает строки значений в атрибуты, сохраняя порядок схемы
+func convertValuesToPayload(values []Value) []*AttributeValueMessage {
+	var result []*AttributeValueMessage
+	byDefinition := make(map[uint]*AttributeValueMessage)
+	for _, v := range values {
+		msg, ok := byDefinition[v.DefinitionID]
+		if !ok {
+			msg = &AttributeValueMessage{
+				Code: v.Definition.Code,
+				Name: v.Definition.Name,
+				Type: v.Definition.Type,
+				Unit: v.Definition.Unit,
+			}
+			byDefinition[v.DefinitionID] = msg
+			result = append(result, msg)
+		}
+		switch v.Definition.Type {
+		case TypeNumber:
+			msg.Values = append(msg.Values, v.NumberValue.Decimal.String())
+		case TypeBoolean:
+			if v.BoolValue != nil {
+				msg.Values = append(msg.Values, strconv.FormatBool(*v.BoolValue))
+			}
+		default:
+			msg.Values = append(msg.Values, v.StringValue)
+		}
+	}
+	return result
+}
@@ -0,0 +1,143 @@
+package attribute
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var codePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+var (
+	ErrUnknownAttribute = errors.New("attribute is not defined for category")
+	ErrRequired         = errors.New("required attribute is missing")
+	ErrInvalidValue     = errors.New("invalid attribute value")
+)
+
+type AttributeValidator struct{}
+
+func (v *AttributeValidator) ValidateDefinition(d *Definition) error {
+	if d.CategoryID == 0 {
+		return errors.New("category ID is required")
+	}
+	if !codePattern.MatchString(d.Code) {
+		return errors.New("code must be lowercase latin letters, digits and underscores")
+	}
+	if strings.TrimSpace(d.Name) == "" {
+		return errors.New("name is required")
+	}
+
+	switch d.Type {
+	case TypeEnum, TypeMultiEnum:
+		if len(d.AllowedValues) == 0 {
+			return errors.New("allowed values are required for " + d.Type)
+		}
+		seen := make(map[string]bool, len(d.AllowedValues))
+		for _, value := range d.AllowedValues {
+			if value == "" || seen[value] {
+				return errors.New("allowed values must be non-empty and unique")
+			}
+			seen[value] = true
+		}
+	case TypeString, TypeNumber, TypeBoolean:
+		if len(d.AllowedValues) > 0 {
+			return errors.New("allowed values are only supported for enum types")
+		}
+	default:
+		return errors.New("unknown attribute type")
+	}
+	return nil
+}
+
+// ValidateValues проверяет значения атрибутов варианта по схеме категории и приводит их к типам.
+// input — код атрибута -> значения в виде строк
+func (v *AttributeValidator) ValidateValues(schema []Definition, input map[string][]string) ([]Value, error) {
+	byCode := make(map[string]Definition, len(schema))
+	for _, d := range schema {
+		byCode[d.Code] = d
+	}
+	for code := range input {
+		if _, ok := byCode[code]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAttribute, code)
+		}
+	}
+
+	var values []Value
+	for _, d := range schema {
+		raw := nonEmpty(input[d.Code])
+		if len(raw) == 0 {
+			if d.Required {
+				return nil, fmt.Errorf("%w: %s", ErrRequired, d.Code)
+			}
+			continue
+		}
+		if d.Type != TypeMultiEnum && len(raw) > 1 {
+			return nil, fmt.Errorf("%w: %s accepts a single value", ErrInvalidValue, d.Code)
+		}
+
+		parsed, err := parseValues(d, raw)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, parsed...)
+	}
+	return values, nil
+}
+
+func parseValues(d Definition, raw []string) ([]Value, error) {
+	values := make([]Value, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, r := range raw {
+		value := Value{DefinitionID: d.ID}
+		switch d.Type {
+		case TypeString:
+			value.StringValue = r
+		case TypeNumber:
+			n, err := decimal.NewFromString(r)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidValue, d.Code)
+			}
+			value.NumberValue = decimal.NewNullDecimal(n)
+		case TypeBoolean:
+			b, err := strconv.ParseBool(r)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be true or false", ErrInvalidValue, d.Code)
+			}
+			value.BoolValue = &b
+		case TypeEnum, TypeMultiEnum:
+			if !contains(d.AllowedValues, r) {
+				return nil, fmt.Errorf("%w: %s is not allowed for %s", ErrInvalidValue, r, d.Code)
+			}
+			if seen[r] {
+				continue
+			}
+			seen[r] = true
+			value.StringValue = r
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func nonEmpty(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -4,8 +4,10 @@ import "gorm.io/gorm"
 
 type Brand struct {
 	gorm.Model  `swaggerignore:"true"`
-	Name        string `gorm:"type:varchar(255);not null" json:"name"`
+	Name        string `gorm:"type:varchar(255);not null;uniqueIndex:idx_brands_name_active,where:deleted_at IS NULL" json:"name"`
+	Slug        string `gorm:"type:varchar(255);uniqueIndex:idx_brands_slug,where:slug <> ''" json:"slug"` // Адрес страницы бренда
 	Description string `gorm:"type:text" json:"description"`
 	VideoURL    string `gorm:"type:varchar(255)" json:"video_url"` // Ссылка на видео в облаке
-	Logo        string `gorm:"type:text" json:"logo"`              // JSON хранящий ссылку на статику(изображение)
+	Logo        string `gorm:"type:text" json:"logo"`              // Ссылка на основное изображение, заполняется из media_images
+	Version     uint   `gorm:"not null;default:1" json:"version"`  // Для оптимистичной блокировки
 }
@@ -0,0 +1,30 @@
+package brand
+
+import pb "github.com/ShopOnGO/admin-proto/pkg/service"
+
+// Сообщения ID-based RPC BrandService, которых пока нет в admin-proto
+
+type UpdateBrandByIDRequest struct {
+	Id          uint32 `json:"id"`
+	Version     uint32 `json:"version"` // версия, прочитанная клиентом
+	Name        string `json:"name"`
+	Slug        string `json:"slug"` // при смене старый слаг остаётся редиректом
+	Description string `json:"description"`
+	VideoUrl    string `json:"video_url"`
+}
+
+type GetBrandByIDRequest struct {
+	Id uint32 `json:"id"`
+}
+
+type DeleteBrandByIDRequest struct {
+	Id       uint32 `json:"id"`
+	Version  uint32 `json:"version"` // 0 — без проверки версии
+	Unscoped bool   `json:"unscoped"`
+}
+
+type BrandVersionResponse struct {
+	Brand   *pb.Brand `json:"brand"`
+	Slug    string    `json:"slug"`
+	Version uint32    `json:"version"`
+}
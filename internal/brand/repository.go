@@ -1,9 +1,25 @@
 package brand
 
 import (
+	"admin/internal/slug"
 	"admin/pkg/db"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
 )
 
+// ErrBrandHasProducts возвращается, когда у удаляемого бренда есть товары.
+// Каскадное удаление и переназначение выполняет catalog.CatalogService
+var ErrBrandHasProducts = errors.New("brand has products, use catalog delete policy")
+
+// ErrVersionConflict возвращается, когда бренд изменили после того, как клиент его прочитал
+var ErrVersionConflict = errors.New("brand was modified concurrently")
+
+// ErrNameTaken возвращается, когда имя занято другим неудалённым брендом
+var ErrNameTaken = errors.New("brand name is already taken")
+
 type BrandRepository struct {
 	Database *db.Db
 }
@@ -14,9 +30,15 @@ func NewBrandRepository(database *db.Db) *BrandRepository {
 	}
 }
 func (repo *BrandRepository) Create(brand *Brand) (*Brand, error) {
-	result := repo.Database.DB.Create(brand)
-	if result.Error != nil {
-		return nil, result.Error
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := slug.Create(tx, "brands", slug.EntityBrand, brand.Slug, brand.Name, func(tx *gorm.DB, s string) error {
+			brand.Slug = s
+			return tx.Create(brand).Error
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 	return brand, nil
 }
@@ -46,6 +68,30 @@ func (repo *BrandRepository) FindBrandByID(id uint) (*Brand, error) {
 	}
 	return &brand, nil
 }
+
+// FindBySlug ищет бренд по актуальному слагу, а затем по истории слагов.
+// redirected == true означает, что slug устарел и актуальный адрес — brand.Slug
+func (repo *BrandRepository) FindBySlug(s string) (brand *Brand, redirected bool, err error) {
+	var found Brand
+	result := repo.Database.DB.First(&found, "slug = ?", s)
+	if result.Error == nil {
+		return &found, false, nil
+	}
+	if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, false, result.Error
+	}
+
+	id, err := slug.Resolve(repo.Database.DB, slug.EntityBrand, s)
+	if err != nil {
+		return nil, false, err
+	}
+	brand, err = repo.FindBrandByID(id)
+	if err != nil {
+		return nil, false, err
+	}
+	return brand, true, nil
+}
+
 func (repo *BrandRepository) FindByName(name string) (*Brand, error) {
 	var brand Brand
 	result := repo.Database.DB.First(&brand, "name = ?", name)
@@ -54,15 +100,48 @@ func (repo *BrandRepository) FindByName(name string) (*Brand, error) {
 	}
 	return &brand, nil
 }
+
+// Update сохраняет бренд, если его версия в БД совпадает с brand.Version,
+// и увеличивает версию. Иначе возвращает ErrVersionConflict.
+// При смене слага старый сохраняется для редиректа
 func (repo *BrandRepository) Update(brand *Brand) (*Brand, error) {
-	result := repo.Database.DB.Model(&Brand{}).Where("id = ?", brand.ID).Updates(brand)
-	if result.Error != nil {
-		return nil, result.Error
+	newVersion := brand.Version + 1
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := slug.Change(tx, "brands", slug.EntityBrand, brand.ID, brand.Slug, brand.Name, func(tx *gorm.DB, s string) error {
+			brand.Slug = s
+			result := tx.Model(&Brand{}).
+				Where("id = ? AND version = ?", brand.ID, brand.Version).
+				Updates(map[string]interface{}{
+					"name":        brand.Name,
+					"slug":        brand.Slug,
+					"description": brand.Description,
+					"video_url":   brand.VideoURL,
+					"version":     newVersion,
+					"updated_at":  time.Now(),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return repo.missingOrConflict(brand.ID)
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	brand.Version = newVersion
 	return brand, nil
 }
 
 func (repo *BrandRepository) Delete(name string, unscoped bool) error {
+	ids := repo.Database.DB.Unscoped().Model(&Brand{}).Select("id").Where("name = ?", name)
+	if err := repo.ensureNoProducts(ids, unscoped); err != nil {
+		return err
+	}
+
 	result := repo.Database.DB
 	if unscoped {
 		result = result.Unscoped()
@@ -70,3 +149,61 @@ func (repo *BrandRepository) Delete(name string, unscoped bool) error {
 	result = result.Where("name = ?", name).Delete(&Brand{})
 	return result.Error
 }
+
+// DeleteByID удаляет бренд по ID. Если version > 0, удаление выполняется
+// только при совпадении версии
+func (repo *BrandRepository) DeleteByID(id, version uint, unscoped bool) error {
+	if err := repo.ensureNoProducts([]uint{id}, unscoped); err != nil {
+		return err
+	}
+
+	query := repo.Database.DB.Where("id = ?", id)
+	if unscoped {
+		query = query.Unscoped()
+	}
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	result := query.Delete(&Brand{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repo.missingOrConflict(id)
+	}
+	return nil
+}
+
+// ensureNoProducts проверяет, что у брендов нет товаров. ids — слайс ID или подзапрос
+func (repo *BrandRepository) ensureNoProducts(ids interface{}, unscoped bool) error {
+	var products int64
+	query := repo.Database.DB.Table("products").Where("brand_id IN (?)", ids)
+	if !unscoped {
+		query = query.Where("deleted_at IS NULL")
+	}
+	if err := query.Count(&products).Error; err != nil {
+		return err
+	}
+	if products > 0 {
+		return ErrBrandHasProducts
+	}
+	return nil
+}
+
+func (repo *BrandRepository) missingOrConflict(id uint) error {
+	var count int64
+	if err := repo.Database.DB.Model(&Brand{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return ErrVersionConflict
+}
+
+// IsNameConflict сообщает, что запись нарушила уникальный индекс имён неудалённых брендов
+func IsNameConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_brands_name_active"
+}
@@ -3,6 +3,7 @@ package brand
 import (
 	"admin/pkg/logger"
 	"context"
+	"errors"
 	"time"
 
 	pb "github.com/ShopOnGO/admin-proto/pkg/service"
@@ -34,6 +35,9 @@ func (s *BrandService) CreateBrand(ctx context.Context, req *pb.CreateBrandReque
 	createdBrand, err := s.BrandRepository.Create(brand)
 	if err != nil {
 		logger.Errorf("CreateBrand error: %v", err)
+		if IsNameConflict(err) {
+			return nil, status.Error(codes.AlreadyExists, ErrNameTaken.Error())
+		}
 		return nil, status.Errorf(codes.Internal, "failed to create brand: %v", err)
 	}
 
@@ -83,6 +87,27 @@ func (s *BrandService) FindBrandByID(ctx context.Context, req *pb.FindBrandByIDR
 	return &pb.BrandResponse{Brand: ConvertDBToProto(brand)}, nil
 }
 
+// GetBrandByID возвращает бренд вместе с версией, которую клиент передаёт в UpdateBrandByID
+// и DeleteBrandByID: в pb.Brand поля версии нет
+func (s *BrandService) GetBrandByID(ctx context.Context, req *GetBrandByIDRequest) (*BrandVersionResponse, error) {
+	if req.Id == 0 {
+		logger.Errorf("GetBrandByID error: brand ID is required")
+		return nil, status.Errorf(codes.InvalidArgument, "brand ID is required")
+	}
+
+	brand, err := s.BrandRepository.FindBrandByID(uint(req.Id))
+	if err != nil {
+		logger.Errorf("GetBrandByID error: %v", err)
+		return nil, brandWriteErrorToStatus(err)
+	}
+
+	return &BrandVersionResponse{
+		Brand:   ConvertDBToProto(brand),
+		Slug:    brand.Slug,
+		Version: uint32(brand.Version),
+	}, nil
+}
+
 func (s *BrandService) UpdateBrand(ctx context.Context, req *pb.UpdateBrandRequest) (*pb.BrandResponse, error) {
 
 	existingBrand, err := s.BrandRepository.FindBrandByID(uint(req.Id))
@@ -91,33 +116,108 @@ func (s *BrandService) UpdateBrand(ctx context.Context, req *pb.UpdateBrandReque
 		return nil, status.Errorf(codes.NotFound, "brand not found")
 	}
 
-	if req.Description != "" {
-		existingBrand.Description = req.Description
+	applyBrandChanges(existingBrand, req.Name, req.Description, req.VideoUrl)
+
+	updatedBrand, err := s.BrandRepository.Update(existingBrand)
+	if err != nil {
+		logger.Errorf("UpdateBrand error: failed to update brand: %v", err)
+		return nil, brandWriteErrorToStatus(err)
+	}
+
+	return &pb.BrandResponse{Brand: ConvertDBToProto(updatedBrand)}, nil
+}
+
+// UpdateBrandByID обновляет бренд с проверкой версии: если бренд изменили
+// после чтения клиентом, возвращается codes.Aborted
+func (s *BrandService) UpdateBrandByID(ctx context.Context, req *UpdateBrandByIDRequest) (*BrandVersionResponse, error) {
+	if req.Id == 0 || req.Version == 0 {
+		logger.Errorf("UpdateBrandByID error: brand ID and version are required")
+		return nil, status.Errorf(codes.InvalidArgument, "brand ID and version are required")
 	}
-	if req.VideoUrl != "" {
-		existingBrand.VideoURL = req.VideoUrl
+
+	existingBrand, err := s.BrandRepository.FindBrandByID(uint(req.Id))
+	if err != nil {
+		logger.Errorf("UpdateBrandByID error: brand not found, ID: %d", req.Id)
+		return nil, status.Errorf(codes.NotFound, "brand not found")
 	}
-	if req.Logo != "" {
-		existingBrand.Logo = req.Logo
+	if existingBrand.Version != uint(req.Version) {
+		logger.Errorf("UpdateBrandByID error: stale version %d for brand %d (current %d)", req.Version, req.Id, existingBrand.Version)
+		return nil, status.Error(codes.Aborted, ErrVersionConflict.Error())
+	}
+
+	applyBrandChanges(existingBrand, req.Name, req.Description, req.VideoUrl)
+	if req.Slug != "" {
+		existingBrand.Slug = req.Slug
 	}
 
 	updatedBrand, err := s.BrandRepository.Update(existingBrand)
 	if err != nil {
-		logger.Errorf("UpdateBrand error: failed to update brand: %v", err)
-		return nil, status.Errorf(codes.Internal, err.Error())
+		logger.Errorf("UpdateBrandByID error: failed to update brand: %v", err)
+		return nil, brandWriteErrorToStatus(err)
 	}
 
-	return &pb.BrandResponse{Brand: ConvertDBToProto(updatedBrand)}, nil
+	return &BrandVersionResponse{
+		Brand:   ConvertDBToProto(updatedBrand),
+		Slug:    updatedBrand.Slug,
+		Version: uint32(updatedBrand.Version),
+	}, nil
+}
+
+// DeleteBrandByID удаляет бренд по ID; если передана версия, удаление выполняется только при её совпадении
+func (s *BrandService) DeleteBrandByID(ctx context.Context, req *DeleteBrandByIDRequest) (*pb.DeleteBrandResponse, error) {
+	if req.Id == 0 {
+		logger.Errorf("DeleteBrandByID error: brand ID is required")
+		return nil, status.Errorf(codes.InvalidArgument, "brand ID is required")
+	}
+
+	if err := s.BrandRepository.DeleteByID(uint(req.Id), uint(req.Version), req.Unscoped); err != nil {
+		logger.Errorf("DeleteBrandByID error: failed to delete brand %d: %v", req.Id, err)
+		return nil, brandWriteErrorToStatus(err)
+	}
+	return &pb.DeleteBrandResponse{}, nil
 }
 
 func (s *BrandService) DeleteBrand(ctx context.Context, req *pb.DeleteBrandRequest) (*pb.DeleteBrandResponse, error) {
 	err := s.BrandRepository.Delete(req.Name, req.Unscoped)
+	if errors.Is(err, ErrBrandHasProducts) {
+		logger.Errorf("DeleteBrand error: brand '%s' still has products", req.Name)
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
 	if err != nil {
 		logger.Errorf("DeleteBrand error: failed to delete brand '%s': %v", req.Name, err)
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 	return &pb.DeleteBrandResponse{}, nil
 }
+
+// applyBrandChanges применяет непустые поля. Logo не меняется: им управляет media
+func applyBrandChanges(brand *Brand, name, description, videoURL string) {
+	if name != "" {
+		brand.Name = name
+	}
+	if description != "" {
+		brand.Description = description
+	}
+	if videoURL != "" {
+		brand.VideoURL = videoURL
+	}
+}
+
+func brandWriteErrorToStatus(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "brand not found")
+	case errors.Is(err, ErrVersionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, ErrBrandHasProducts):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case IsNameConflict(err):
+		return status.Error(codes.AlreadyExists, ErrNameTaken.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
 func ConvertDBToProto(brand *Brand) *pb.Brand {
 	if brand == nil {
 		return nil
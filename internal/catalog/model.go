@@ -0,0 +1,76 @@
+package catalog
+
+import (
+	"admin/internal/category"
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+// DeletePolicy определяет, что происходит с зависимыми сущностями
+// (товары, варианты, подкатегории) при удалении бренда или категории
+type DeletePolicy string
+
+const (
+	DeleteRestrict DeletePolicy = "restrict" // отказ, если есть зависимые сущности
+	DeleteCascade  DeletePolicy = "cascade"  // удалить зависимые сущности вместе с родителем
+	DeleteReassign DeletePolicy = "reassign" // перевесить зависимые сущности на другой бренд/категорию
+)
+
+const (
+	EntityBrand    = "brand"
+	EntityCategory = "category"
+)
+
+var (
+	ErrDeleteRestricted      = errors.New("entity has dependent records")
+	ErrUnknownDeletePolicy   = errors.New("unknown delete policy")
+	ErrUnknownEntity         = errors.New("unknown entity type")
+	ErrInvalidReassignTarget = errors.New("invalid reassign target")
+)
+
+func ParseDeletePolicy(policy string) (DeletePolicy, error) {
+	switch DeletePolicy(policy) {
+	case "", DeleteRestrict:
+		return DeleteRestrict, nil
+	case DeleteCascade:
+		return DeleteCascade, nil
+	case DeleteReassign:
+		return DeleteReassign, nil
+	default:
+		return "", ErrUnknownDeletePolicy
+	}
+}
+
+type DeleteOptions struct {
+	Policy     DeletePolicy
+	ReassignTo uint // ID бренда/категории, используется только с DeleteReassign
+	Unscoped   bool
+}
+
+// DeleteImpact описывает, какие записи затронет удаление
+type DeleteImpact struct {
+	ProductIDs     []uint
+	Products       int64
+	Variants       int64
+	SubCategoryIDs []uint
+	SubCategories  int64
+}
+
+func (i *DeleteImpact) IsEmpty() bool {
+	return i.Products == 0 && i.Variants == 0 && i.SubCategories == 0
+}
+
+// PriceRange — минимальная и максимальная цена активных вариантов.
+// Valid == false, если активных вариантов нет
+type PriceRange struct {
+	Min   decimal.Decimal
+	Max   decimal.Decimal
+	Valid bool
+}
+
+// CategoryProducts — категория и число товаров бренда в ней
+type CategoryProducts struct {
+	Category category.Category
+	Products int64
+}
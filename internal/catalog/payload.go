@@ -0,0 +1,59 @@
+package catalog
+
+import pb "github.com/ShopOnGO/admin-proto/pkg/service"
+
+// Сообщения CatalogService. Когда RPC появятся в admin-proto,
+// эти типы заменяются сгенерированными.
+
+type PreviewDeleteRequest struct {
+	Entity   string `json:"entity"` // EntityBrand или EntityCategory
+	Id       uint32 `json:"id"`
+	Unscoped bool   `json:"unscoped"`
+}
+
+type PreviewDeleteResponse struct {
+	ProductIds       []uint32 `json:"product_ids"`
+	Products         int64    `json:"products"`
+	Variants         int64    `json:"variants"`
+	SubCategoryIds   []uint32 `json:"sub_category_ids"`
+	SubCategories    int64    `json:"sub_categories"`
+	DeletionBlocking bool     `json:"deletion_blocking"` // true, если restrict откажет в удалении
+}
+
+type DeleteEntityRequest struct {
+	Entity     string `json:"entity"`
+	Id         uint32 `json:"id"`
+	Policy     string `json:"policy"` // restrict (по умолчанию), cascade, reassign
+	ReassignTo uint32 `json:"reassign_to"`
+	Unscoped   bool   `json:"unscoped"`
+}
+
+type DeleteEntityResponse struct {
+	Impact *PreviewDeleteResponse `json:"impact"`
+}
+
+type GetBrandPageRequest struct {
+	Slug     string `json:"slug"`
+	BrandId  uint32 `json:"brand_id"` // используется, если slug пуст
+	Page     uint32 `json:"page"`     // с 1
+	PageSize uint32 `json:"page_size"`
+}
+
+type BrandPageCategory struct {
+	Category *pb.Category `json:"category"`
+	Slug     string       `json:"slug"`
+	Products int64        `json:"products"`
+}
+
+type GetBrandPageResponse struct {
+	Brand         *pb.Brand            `json:"brand"`
+	Slug          string               `json:"slug"`
+	RedirectTo    string               `json:"redirect_to"` // непустой, если запрошен устаревший слаг
+	ProductCount  int64                `json:"product_count"`
+	MinPrice      int64                `json:"min_price"` // в копейках, 0 если нет активных вариантов
+	MaxPrice      int64                `json:"max_price"`
+	TopCategories []*BrandPageCategory `json:"top_categories"`
+	Products      []*pb.Product        `json:"products"`
+	Page          uint32               `json:"page"`
+	PageSize      uint32               `json:"page_size"`
+}
@@ -0,0 +1,332 @@
+package catalog
+
+import (
+	"admin/internal/brand"
+	"admin/internal/category"
+	"admin/internal/product"
+	"admin/internal/productVariant"
+	"admin/pkg/db"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type CatalogRepository struct {
+	Database *db.Db
+}
+
+func NewCatalogRepository(database *db.Db) *CatalogRepository {
+	return &CatalogRepository{
+		Database: database,
+	}
+}
+
+// PreviewBrandDelete возвращает товары и варианты, которые затронет удаление бренда
+func (repo *CatalogRepository) PreviewBrandDelete(id uint, unscoped bool) (*DeleteImpact, error) {
+	return repo.brandImpact(repo.Database.DB, id, unscoped)
+}
+
+// PreviewCategoryDelete возвращает подкатегории, товары и варианты, которые затронет удаление категории
+func (repo *CatalogRepository) PreviewCategoryDelete(id uint, unscoped bool) (*DeleteImpact, error) {
+	return repo.categoryImpact(repo.Database.DB, id, unscoped)
+}
+
+// DeleteBrand удаляет бренд, применяя политику к его товарам
+func (repo *CatalogRepository) DeleteBrand(id uint, opts DeleteOptions) (*DeleteImpact, error) {
+	var impact *DeleteImpact
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		impact, err = repo.brandImpact(tx, id, opts.Unscoped)
+		if err != nil {
+			return err
+		}
+
+		switch opts.Policy {
+		case DeleteRestrict:
+			if !impact.IsEmpty() {
+				return fmt.Errorf("%w: %d products, %d variants", ErrDeleteRestricted, impact.Products, impact.Variants)
+			}
+		case DeleteCascade:
+			if err := deleteProducts(tx, impact.ProductIDs, opts.Unscoped); err != nil {
+				return err
+			}
+		case DeleteReassign:
+			if opts.ReassignTo == 0 || opts.ReassignTo == id {
+				return ErrInvalidReassignTarget
+			}
+			if err := tx.First(&brand.Brand{}, opts.ReassignTo).Error; err != nil {
+				return fmt.Errorf("%w: brand %d: %v", ErrInvalidReassignTarget, opts.ReassignTo, err)
+			}
+			// переносим и мягко удалённые товары, иначе они ссылались бы на удалённый бренд
+			if err := tx.Unscoped().Model(&product.Product{}).
+				Where("brand_id = ?", id).
+				Update("brand_id", opts.ReassignTo).Error; err != nil {
+				return err
+			}
+			if err := product.AddUpdatedEvents(tx, impact.ProductIDs); err != nil {
+				return err
+			}
+		default:
+			return ErrUnknownDeletePolicy
+		}
+
+		return scoped(tx, opts.Unscoped).Delete(&brand.Brand{}, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return impact, nil
+}
+
+// DeleteCategory удаляет категорию, применяя политику к её подкатегориям и товарам
+func (repo *CatalogRepository) DeleteCategory(id uint, opts DeleteOptions) (*DeleteImpact, error) {
+	var impact *DeleteImpact
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		impact, err = repo.categoryImpact(tx, id, opts.Unscoped)
+		if err != nil {
+			return err
+		}
+
+		switch opts.Policy {
+		case DeleteRestrict:
+			if !impact.IsEmpty() {
+				return fmt.Errorf("%w: %d subcategories, %d products, %d variants",
+					ErrDeleteRestricted, impact.SubCategories, impact.Products, impact.Variants)
+			}
+		case DeleteCascade:
+			if err := deleteProducts(tx, impact.ProductIDs, opts.Unscoped); err != nil {
+				return err
+			}
+			// удаляем подкатегории начиная с листьев, чтобы не нарушать внешний ключ на родителя
+			for i := len(impact.SubCategoryIDs) - 1; i >= 0; i-- {
+				if err := scoped(tx, opts.Unscoped).Delete(&category.Category{}, impact.SubCategoryIDs[i]).Error; err != nil {
+					return err
+				}
+			}
+		case DeleteReassign:
+			if opts.ReassignTo == 0 || opts.ReassignTo == id || containsID(impact.SubCategoryIDs, opts.ReassignTo) {
+				return ErrInvalidReassignTarget
+			}
+			if err := tx.First(&category.Category{}, opts.ReassignTo).Error; err != nil {
+				return fmt.Errorf("%w: category %d: %v", ErrInvalidReassignTarget, opts.ReassignTo, err)
+			}
+			// товары и прямые подкатегории переезжают к новой категории, поддерево сохраняется
+			var productIDs, childIDs []uint
+			if err := tx.Unscoped().Model(&product.Product{}).Where("category_id = ?", id).Pluck("id", &productIDs).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&category.Category{}).Where("parent_category_id = ?", id).Pluck("id", &childIDs).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&product.Product{}).
+				Where("category_id = ?", id).
+				Update("category_id", opts.ReassignTo).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&category.Category{}).
+				Where("parent_category_id = ?", id).
+				Update("parent_category_id", opts.ReassignTo).Error; err != nil {
+				return err
+			}
+			if err := product.AddUpdatedEvents(tx, productIDs); err != nil {
+				return err
+			}
+			for _, childID := range childIDs {
+				if err := category.AddMovedEvent(tx, childID, &id, &opts.ReassignTo); err != nil {
+					return err
+				}
+			}
+		default:
+			return ErrUnknownDeletePolicy
+		}
+
+		return scoped(tx, opts.Unscoped).Delete(&category.Category{}, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return impact, nil
+}
+
+// CountBrandProducts считает активные товары бренда
+func (repo *CatalogRepository) CountBrandProducts(brandID uint) (int64, error) {
+	var count int64
+	result := repo.Database.DB.Model(&product.Product{}).
+		Where("brand_id = ? AND is_active = true", brandID).
+		Count(&count)
+	return count, result.Error
+}
+
+// BrandPriceRange возвращает диапазон итоговых цен по активным вариантам активных товаров бренда
+func (repo *CatalogRepository) BrandPriceRange(brandID uint) (*PriceRange, error) {
+	var row struct {
+		MinPrice decimal.NullDecimal
+		MaxPrice decimal.NullDecimal
+	}
+	result := repo.Database.DB.Model(&productVariant.ProductVariant{}).
+		Select("MIN(GREATEST(product_variants.price - product_variants.discount, 0)) AS min_price, "+
+			"MAX(GREATEST(product_variants.price - product_variants.discount, 0)) AS max_price").
+		Joins("JOIN products ON products.id = product_variants.product_id AND products.deleted_at IS NULL").
+		Where("products.brand_id = ? AND products.is_active = true AND product_variants.is_active = true", brandID).
+		Scan(&row)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &PriceRange{
+		Min:   row.MinPrice.Decimal,
+		Max:   row.MaxPrice.Decimal,
+		Valid: row.MinPrice.Valid && row.MaxPrice.Valid,
+	}, nil
+}
+
+// BrandTopCategories возвращает категории с наибольшим числом активных товаров бренда
+func (repo *CatalogRepository) BrandTopCategories(brandID uint, limit int) ([]CategoryProducts, error) {
+	var rows []struct {
+		CategoryID uint
+		Products   int64
+	}
+	result := repo.Database.DB.Model(&product.Product{}).
+		Select("category_id, COUNT(*) AS products").
+		Where("brand_id = ? AND is_active = true", brandID).
+		Group("category_id").
+		Order("products DESC, category_id").
+		Limit(limit).
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.CategoryID)
+	}
+	var categories []category.Category
+	if err := repo.Database.DB.Where("id IN ?", ids).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]category.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	top := make([]CategoryProducts, 0, len(rows))
+	for _, row := range rows {
+		c, ok := byID[row.CategoryID]
+		if !ok { // категория мягко удалена
+			continue
+		}
+		top = append(top, CategoryProducts{Category: c, Products: row.Products})
+	}
+	return top, nil
+}
+
+// BrandProducts возвращает страницу активных товаров бренда
+func (repo *CatalogRepository) BrandProducts(brandID uint, limit, offset int) ([]product.Product, error) {
+	var products []product.Product
+	result := repo.Database.DB.
+		Where("brand_id = ? AND is_active = true", brandID).
+		Order("id").
+		Limit(limit).
+		Offset(offset).
+		Find(&products)
+	return products, result.Error
+}
+
+func (repo *CatalogRepository) brandImpact(tx *gorm.DB, id uint, unscoped bool) (*DeleteImpact, error) {
+	if err := scoped(tx, unscoped).First(&brand.Brand{}, id).Error; err != nil {
+		return nil, err
+	}
+
+	impact := &DeleteImpact{}
+	if err := scoped(tx, unscoped).Model(&product.Product{}).
+		Where("brand_id = ?", id).
+		Pluck("id", &impact.ProductIDs).Error; err != nil {
+		return nil, err
+	}
+	impact.Products = int64(len(impact.ProductIDs))
+
+	return impact, countVariants(tx, impact, unscoped)
+}
+
+func (repo *CatalogRepository) categoryImpact(tx *gorm.DB, id uint, unscoped bool) (*DeleteImpact, error) {
+	if err := scoped(tx, unscoped).First(&category.Category{}, id).Error; err != nil {
+		return nil, err
+	}
+
+	impact := &DeleteImpact{}
+	visited := map[uint]bool{id: true}
+	parents := []uint{id}
+	for len(parents) > 0 {
+		var children []uint
+		if err := scoped(tx, unscoped).Model(&category.Category{}).
+			Where("parent_category_id IN ?", parents).
+			Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+
+		parents = parents[:0]
+		for _, child := range children {
+			if visited[child] { // защита от циклов в дереве
+				continue
+			}
+			visited[child] = true
+			impact.SubCategoryIDs = append(impact.SubCategoryIDs, child)
+			parents = append(parents, child)
+		}
+	}
+	impact.SubCategories = int64(len(impact.SubCategoryIDs))
+
+	categoryIDs := append([]uint{id}, impact.SubCategoryIDs...)
+	if err := scoped(tx, unscoped).Model(&product.Product{}).
+		Where("category_id IN ?", categoryIDs).
+		Pluck("id", &impact.ProductIDs).Error; err != nil {
+		return nil, err
+	}
+	impact.Products = int64(len(impact.ProductIDs))
+
+	return impact, countVariants(tx, impact, unscoped)
+}
+
+func countVariants(tx *gorm.DB, impact *DeleteImpact, unscoped bool) error {
+	if len(impact.ProductIDs) == 0 {
+		return nil
+	}
+	return scoped(tx, unscoped).Model(&productVariant.ProductVariant{}).
+		Where("product_id IN ?", impact.ProductIDs).
+		Count(&impact.Variants).Error
+}
+
+func deleteProducts(tx *gorm.DB, productIDs []uint, unscoped bool) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	if err := scoped(tx, unscoped).Where("product_id IN ?", productIDs).Delete(&productVariant.ProductVariant{}).Error; err != nil {
+		return err
+	}
+	if err := scoped(tx, unscoped).Where("id IN ?", productIDs).Delete(&product.Product{}).Error; err != nil {
+		return err
+	}
+	return product.AddDeletedEvents(tx, productIDs, unscoped)
+}
+
+func scoped(tx *gorm.DB, unscoped bool) *gorm.DB {
+	if unscoped {
+		// Session нужен, чтобы последующие цепочки не накапливали условия друг друга
+		return tx.Unscoped().Session(&gorm.Session{})
+	}
+	return tx
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
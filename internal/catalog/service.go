@@ -0,0 +1,221 @@
+package catalog
+
+import (
+	"admin/internal/brand"
+	"admin/internal/category"
+	"admin/internal/product"
+	"admin/pkg/logger"
+	"admin/pkg/money"
+	"context"
+	"errors"
+
+	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize   = 20
+	maxPageSize       = 100
+	topCategoriesSize = 5
+)
+
+type CatalogService struct {
+	CatalogRepository *CatalogRepository
+	BrandRepository   *brand.BrandRepository
+}
+
+func NewCatalogService(catalogRepository *CatalogRepository, brandRepository *brand.BrandRepository) *CatalogService {
+	return &CatalogService{
+		CatalogRepository: catalogRepository,
+		BrandRepository:   brandRepository,
+	}
+}
+
+// GetBrandPage собирает данные страницы бренда: сам бренд, число товаров,
+// диапазон цен активных вариантов, топ категорий и страницу товаров
+func (s *CatalogService) GetBrandPage(ctx context.Context, req *GetBrandPageRequest) (*GetBrandPageResponse, error) {
+	var br *brand.Brand
+	var redirected bool
+	var err error
+	switch {
+	case req.Slug != "":
+		br, redirected, err = s.BrandRepository.FindBySlug(req.Slug)
+	case req.BrandId != 0:
+		br, err = s.BrandRepository.FindBrandByID(uint(req.BrandId))
+	default:
+		logger.Error("GetBrandPage error: slug or brand ID is required")
+		return nil, status.Error(codes.InvalidArgument, "slug or brand ID is required")
+	}
+	if err != nil {
+		logger.Errorf("GetBrandPage error: brand not found: %v", err)
+		return nil, status.Error(codes.NotFound, "brand not found")
+	}
+
+	resp := &GetBrandPageResponse{
+		Brand: brand.ConvertDBToProto(br),
+		Slug:  br.Slug,
+	}
+	if redirected {
+		// клиенту достаточно адреса для редиректа, остальное он запросит по новому слагу
+		resp.RedirectTo = br.Slug
+		return resp, nil
+	}
+
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+	resp.Page, resp.PageSize = page, pageSize
+
+	if resp.ProductCount, err = s.CatalogRepository.CountBrandProducts(br.ID); err != nil {
+		logger.Errorf("GetBrandPage error: count products: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	priceRange, err := s.CatalogRepository.BrandPriceRange(br.ID)
+	if err != nil {
+		logger.Errorf("GetBrandPage error: price range: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if priceRange.Valid {
+		resp.MinPrice = money.DecimalToCents(priceRange.Min)
+		resp.MaxPrice = money.DecimalToCents(priceRange.Max)
+	}
+
+	topCategories, err := s.CatalogRepository.BrandTopCategories(br.ID, topCategoriesSize)
+	if err != nil {
+		logger.Errorf("GetBrandPage error: top categories: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	for _, c := range topCategories {
+		resp.TopCategories = append(resp.TopCategories, &BrandPageCategory{
+			Category: category.ConvertDBToProto(&c.Category),
+			Slug:     c.Category.Slug,
+			Products: c.Products,
+		})
+	}
+
+	products, err := s.CatalogRepository.BrandProducts(br.ID, int(pageSize), int((page-1)*pageSize))
+	if err != nil {
+		logger.Errorf("GetBrandPage error: products: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp.Products = make([]*pb.Product, 0, len(products))
+	for _, p := range products {
+		resp.Products = append(resp.Products, product.ConvertDBToProto(&p))
+	}
+
+	return resp, nil
+}
+
+// PreviewDelete показывает, что будет затронуто удалением, ничего не удаляя
+func (s *CatalogService) PreviewDelete(ctx context.Context, req *PreviewDeleteRequest) (*PreviewDeleteResponse, error) {
+	if req.Id == 0 {
+		logger.Error("PreviewDelete error: ID is required")
+		return nil, status.Error(codes.InvalidArgument, "ID is required")
+	}
+
+	var impact *DeleteImpact
+	var err error
+	switch req.Entity {
+	case EntityBrand:
+		impact, err = s.CatalogRepository.PreviewBrandDelete(uint(req.Id), req.Unscoped)
+	case EntityCategory:
+		impact, err = s.CatalogRepository.PreviewCategoryDelete(uint(req.Id), req.Unscoped)
+	default:
+		err = ErrUnknownEntity
+	}
+	if err != nil {
+		logger.Errorf("PreviewDelete error: %s %d: %v", req.Entity, req.Id, err)
+		return nil, deleteErrorToStatus(err)
+	}
+
+	return convertImpactToPayload(impact), nil
+}
+
+// DeleteEntity удаляет бренд или категорию по ID с явной политикой для зависимых сущностей
+func (s *CatalogService) DeleteEntity(ctx context.Context, req *DeleteEntityRequest) (*DeleteEntityResponse, error) {
+	if req.Id == 0 {
+		logger.Error("DeleteEntity error: ID is required")
+		return nil, status.Error(codes.InvalidArgument, "ID is required")
+	}
+
+	policy, err := ParseDeletePolicy(req.Policy)
+	if err != nil {
+		logger.Errorf("DeleteEntity error: %v", err)
+		return nil, deleteErrorToStatus(err)
+	}
+	opts := DeleteOptions{
+		Policy:     policy,
+		ReassignTo: uint(req.ReassignTo),
+		Unscoped:   req.Unscoped,
+	}
+
+	var impact *DeleteImpact
+	switch req.Entity {
+	case EntityBrand:
+		impact, err = s.CatalogRepository.DeleteBrand(uint(req.Id), opts)
+	case EntityCategory:
+		impact, err = s.CatalogRepository.DeleteCategory(uint(req.Id), opts)
+	default:
+		err = ErrUnknownEntity
+	}
+	if err != nil {
+		logger.Errorf("DeleteEntity error: %s %d (%s): %v", req.Entity, req.Id, policy, err)
+		return nil, deleteErrorToStatus(err)
+	}
+
+	logger.Infof("%s %d deleted with policy %s: %d products, %d variants, %d subcategories affected",
+		req.Entity, req.Id, policy, impact.Products, impact.Variants, impact.SubCategories)
+	return &DeleteEntityResponse{Impact: convertImpactToPayload(impact)}, nil
+}
+
+func normalizePage(page, pageSize uint32) (uint32, uint32) {
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
+
+func deleteErrorToStatus(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrDeleteRestricted):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrUnknownDeletePolicy),
+		errors.Is(err, ErrUnknownEntity),
+		errors.Is(err, ErrInvalidReassignTarget):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func convertImpactToPayload(impact *DeleteImpact) *PreviewDeleteResponse {
+	if impact == nil {
+		return nil
+	}
+	return &PreviewDeleteResponse{
+		ProductIds:       toUint32Slice(impact.ProductIDs),
+		Products:         impact.Products,
+		Variants:         impact.Variants,
+		SubCategoryIds:   toUint32Slice(impact.SubCategoryIDs),
+		SubCategories:    impact.SubCategories,
+		DeletionBlocking: !impact.IsEmpty(),
+	}
+}
+
+func toUint32Slice(ids []uint) []uint32 {
+	result := make([]uint32, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, uint32(id))
+	}
+	return result
+}
@@ -0,0 +1,41 @@
+package category
+
+import pb "github.com/ShopOnGO/admin-proto/pkg/service"
+
+// Сообщения ID-based RPC CategoryService, которых пока нет в admin-proto
+
+type UpdateCategoryByIDRequest struct {
+	Id          uint32 `json:"id"`
+	Version     uint32 `json:"version"` // версия, прочитанная клиентом
+	Name        string `json:"name"`
+	Slug        string `json:"slug"` // при смене старый слаг остаётся редиректом
+	Description string `json:"description"`
+	ImageUrl    string `json:"image_url"`
+}
+
+type GetCategoryByIDRequest struct {
+	Id uint32 `json:"id"`
+}
+
+type DeleteCategoryByIDRequest struct {
+	Id       uint32 `json:"id"`
+	Version  uint32 `json:"version"` // 0 — без проверки версии
+	Unscoped bool   `json:"unscoped"`
+}
+
+type CategoryVersionResponse struct {
+	Category *pb.Category `json:"category"`
+	Slug     string       `json:"slug"`
+	Version  uint32       `json:"version"`
+}
+
+type FindCategoryBySlugRequest struct {
+	Slug string `json:"slug"`
+}
+
+type FindCategoryBySlugResponse struct {
+	Category   *pb.Category `json:"category"`
+	Slug       string       `json:"slug"`
+	RedirectTo string       `json:"redirect_to"` // непустой, если запрошен устаревший слаг
+	Version    uint32       `json:"version"`
+}
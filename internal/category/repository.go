@@ -1,9 +1,23 @@
 package category
 
 import (
+	"admin/internal/outbox"
+	"admin/internal/slug"
 	"admin/pkg/db"
+	"admin/pkg/event"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// ErrCategoryInUse возвращается, когда у удаляемой категории есть товары или подкатегории.
+// Каскадное удаление и переназначение выполняет catalog.CatalogService
+var ErrCategoryInUse = errors.New("category has products or subcategories, use catalog delete policy")
+
+// ErrVersionConflict возвращается, когда категорию изменили после того, как клиент её прочитал
+var ErrVersionConflict = errors.New("category was modified concurrently")
+
 type CategoryRepository struct {
 	Database *db.Db
 }
@@ -15,9 +29,15 @@ func NewCategoryRepository(database *db.Db) *CategoryRepository {
 }
 
 func (repo *CategoryRepository) Create(category *Category) (*Category, error) {
-	result := repo.Database.DB.Create(category)
-	if result.Error != nil {
-		return nil, result.Error
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := slug.Create(tx, "categories", slug.EntityCategory, category.Slug, category.Name, func(tx *gorm.DB, s string) error {
+			category.Slug = s
+			return tx.Create(category).Error
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 	return category, nil
 }
@@ -40,6 +60,29 @@ func (repo *CategoryRepository) GetFeaturedCategories(amount int, unscoped bool)
 	return categories, nil
 }
 
+// FindBySlug ищет категорию по актуальному слагу, а затем по истории слагов.
+// redirected == true означает, что slug устарел и актуальный адрес — category.Slug
+func (repo *CategoryRepository) FindBySlug(s string) (category *Category, redirected bool, err error) {
+	var found Category
+	result := repo.Database.DB.First(&found, "slug = ?", s)
+	if result.Error == nil {
+		return &found, false, nil
+	}
+	if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, false, result.Error
+	}
+
+	id, err := slug.Resolve(repo.Database.DB, slug.EntityCategory, s)
+	if err != nil {
+		return nil, false, err
+	}
+	category, err = repo.FindCategoryByID(id)
+	if err != nil {
+		return nil, false, err
+	}
+	return category, true, nil
+}
+
 func (repo *CategoryRepository) FindByName(name string) (*Category, error) {
 	var category Category
 	result := repo.Database.DB.First(&category, "name = ?", name)
@@ -48,11 +91,50 @@ func (repo *CategoryRepository) FindByName(name string) (*Category, error) {
 	}
 	return &category, nil
 }
+
+// Update сохраняет категорию, если её версия в БД совпадает с category.Version,
+// и увеличивает версию. Иначе возвращает ErrVersionConflict.
+// При смене слага старый сохраняется для редиректа, при смене родителя пишется category.moved
 func (repo *CategoryRepository) Update(category *Category) (*Category, error) {
-	result := repo.Database.DB.Model(&Category{}).Where("id = ?", category.ID).Updates(category)
-	if result.Error != nil {
-		return nil, result.Error
+	newVersion := category.Version + 1
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var current Category
+		if err := tx.Select("id", "parent_category_id").Where("id = ?", category.ID).Limit(1).Find(&current).Error; err != nil {
+			return err
+		}
+		_, err := slug.Change(tx, "categories", slug.EntityCategory, category.ID, category.Slug, category.Name, func(tx *gorm.DB, s string) error {
+			category.Slug = s
+			result := tx.Model(&Category{}).
+				Where("id = ? AND version = ?", category.ID, category.Version).
+				Updates(map[string]interface{}{
+					"name":               category.Name,
+					"slug":               category.Slug,
+					"description":        category.Description,
+					"image_url":          category.ImageURL,
+					"parent_category_id": category.ParentCategoryID,
+					"version":            newVersion,
+					"updated_at":         time.Now(),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return repo.missingOrConflict(category.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if sameParent(current.ParentCategoryID, category.ParentCategoryID) {
+			return nil
+		}
+		return AddMovedEvent(tx, category.ID, current.ParentCategoryID, category.ParentCategoryID)
+	})
+	if err != nil {
+		return nil, err
 	}
+	category.Version = newVersion
 	return category, nil
 }
 
@@ -65,16 +147,13 @@ func (repo *CategoryRepository) FindCategoryByID(id uint) (*Category, error) {
 	return &category, nil
 }
 
-//	func (repo *CategoryRepository) Delete(id uint) error {
-//		result := repo.Database.DB.Delete(&Category{}, id)
-//		if result.Error != nil {
-//			return result.Error
-//		}
-//		return nil
-//	}
 func (repo *CategoryRepository) Delete(name string, unscoped bool) error {
 	// Принудительно удаляем все категории с таким именем
 	// важно! find в методах поиска не даст удаленные мягко
+	ids := repo.Database.DB.Unscoped().Model(&Category{}).Select("id").Where("name = ?", name)
+	if err := repo.ensureUnused(ids, unscoped); err != nil {
+		return err
+	}
 
 	query := repo.Database.DB
 	if unscoped {
@@ -82,3 +161,77 @@ func (repo *CategoryRepository) Delete(name string, unscoped bool) error {
 	}
 	return query.Where("name = ?", name).Delete(&Category{}).Error
 }
+
+// DeleteByID удаляет категорию по ID. Если version > 0, удаление выполняется
+// только при совпадении версии
+func (repo *CategoryRepository) DeleteByID(id, version uint, unscoped bool) error {
+	if err := repo.ensureUnused([]uint{id}, unscoped); err != nil {
+		return err
+	}
+
+	query := repo.Database.DB.Where("id = ?", id)
+	if unscoped {
+		query = query.Unscoped()
+	}
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	result := query.Delete(&Category{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repo.missingOrConflict(id)
+	}
+	return nil
+}
+
+// ensureUnused проверяет, что у категорий нет товаров и подкатегорий. ids — слайс ID или подзапрос
+func (repo *CategoryRepository) ensureUnused(ids interface{}, unscoped bool) error {
+	var products, children int64
+	productsQuery := repo.Database.DB.Table("products").Where("category_id IN (?)", ids)
+	childrenQuery := repo.Database.DB.Model(&Category{}).Where("parent_category_id IN (?)", ids)
+	if unscoped {
+		childrenQuery = childrenQuery.Unscoped()
+	} else {
+		productsQuery = productsQuery.Where("deleted_at IS NULL")
+	}
+	if err := productsQuery.Count(&products).Error; err != nil {
+		return err
+	}
+	if err := childrenQuery.Count(&children).Error; err != nil {
+		return err
+	}
+	if products > 0 || children > 0 {
+		return ErrCategoryInUse
+	}
+	return nil
+}
+
+func (repo *CategoryRepository) missingOrConflict(id uint) error {
+	var count int64
+	if err := repo.Database.DB.Model(&Category{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return ErrVersionConflict
+}
+
+// AddMovedEvent пишет в outbox category.moved. tx — транзакция, в которой меняется родитель
+func AddMovedEvent(tx *gorm.DB, id uint, oldParentID, newParentID *uint) error {
+	return outbox.Add(tx, event.AggregateCategory, id, event.CategoryMoved, event.CategoryMovedData{
+		CategoryID:  id,
+		OldParentID: oldParentID,
+		NewParentID: newParentID,
+	})
+}
+
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -3,6 +3,7 @@ package category
 import (
 	"admin/pkg/logger"
 	"context"
+	"errors"
 	"time"
 
 	pb "github.com/ShopOnGO/admin-proto/pkg/service"
@@ -95,16 +96,108 @@ func (s *CategoryService) UpdateCategory(ctx context.Context, req *pb.UpdateCate
 	updatedCategory, err := s.CategoryRepository.Update(category)
 	if err != nil {
 		logger.Errorf("failed to update category: %v", err)
-		return nil, status.Error(codes.Internal, "failed to update category")
+		return nil, categoryWriteErrorToStatus(err)
 	}
 
 	return &pb.UpdateCategoryResponse{
 		Category: ConvertDBToProto(updatedCategory)}, nil
 }
 
+// UpdateCategoryByID обновляет категорию с проверкой версии: если категорию изменили
+// после чтения клиентом, возвращается codes.Aborted
+func (s *CategoryService) UpdateCategoryByID(ctx context.Context, req *UpdateCategoryByIDRequest) (*CategoryVersionResponse, error) {
+	if req.Id == 0 || req.Version == 0 {
+		logger.Error("category ID and version are required")
+		return nil, status.Error(codes.InvalidArgument, "category ID and version are required")
+	}
+
+	category, err := s.CategoryRepository.FindCategoryByID(uint(req.Id))
+	if err != nil {
+		logger.Errorf("UpdateCategoryByID error: category not found (id: %d)", req.Id)
+		return nil, status.Error(codes.NotFound, "category not found")
+	}
+	if category.Version != uint(req.Version) {
+		logger.Errorf("UpdateCategoryByID error: stale version %d for category %d (current %d)", req.Version, req.Id, category.Version)
+		return nil, status.Error(codes.Aborted, ErrVersionConflict.Error())
+	}
+
+	if req.Name != "" {
+		category.Name = req.Name
+	}
+	if req.Description != "" {
+		category.Description = req.Description
+	}
+	if req.ImageUrl != "" {
+		category.ImageURL = req.ImageUrl
+	}
+	if req.Slug != "" {
+		category.Slug = req.Slug
+	}
+
+	updatedCategory, err := s.CategoryRepository.Update(category)
+	if err != nil {
+		logger.Errorf("failed to update category: %v", err)
+		return nil, categoryWriteErrorToStatus(err)
+	}
+
+	return &CategoryVersionResponse{
+		Category: ConvertDBToProto(updatedCategory),
+		Slug:     updatedCategory.Slug,
+		Version:  uint32(updatedCategory.Version)}, nil
+}
+
+// FindCategoryBySlug ищет категорию по слагу; для устаревшего слага возвращает актуальный в RedirectTo
+func (s *CategoryService) FindCategoryBySlug(ctx context.Context, req *FindCategoryBySlugRequest) (*FindCategoryBySlugResponse, error) {
+	if req.Slug == "" {
+		logger.Error("category slug is required")
+		return nil, status.Error(codes.InvalidArgument, "category slug is required")
+	}
+
+	category, redirected, err := s.CategoryRepository.FindBySlug(req.Slug)
+	if err != nil {
+		logger.Errorf("category not found by slug '%s': %v", req.Slug, err)
+		return nil, status.Error(codes.NotFound, "category not found")
+	}
+
+	resp := &FindCategoryBySlugResponse{
+		Category: ConvertDBToProto(category),
+		Slug:     category.Slug,
+		Version:  uint32(category.Version),
+	}
+	if redirected {
+		resp.RedirectTo = category.Slug
+	}
+	return resp, nil
+}
+
+// GetCategoryByID возвращает категорию вместе с версией, которую клиент передаёт
+// в UpdateCategoryByID и DeleteCategoryByID: в pb.Category поля версии нет
+func (s *CategoryService) GetCategoryByID(ctx context.Context, req *GetCategoryByIDRequest) (*CategoryVersionResponse, error) {
+	if req.Id == 0 {
+		logger.Error("category ID is required")
+		return nil, status.Error(codes.InvalidArgument, "category ID is required")
+	}
+
+	category, err := s.CategoryRepository.FindCategoryByID(uint(req.Id))
+	if err != nil {
+		logger.Errorf("category %d not found: %v", req.Id, err)
+		return nil, categoryWriteErrorToStatus(err)
+	}
+
+	return &CategoryVersionResponse{
+		Category: ConvertDBToProto(category),
+		Slug:     category.Slug,
+		Version:  uint32(category.Version),
+	}, nil
+}
+
 func (s *CategoryService) DeleteCategory(ctx context.Context, req *pb.DeleteCategoryByNameRequest) (*pb.DeleteCategoryResponse, error) {
 
 	err := s.CategoryRepository.Delete(req.Name, req.Unscoped)
+	if errors.Is(err, ErrCategoryInUse) {
+		logger.Errorf("failed to delete category '%s': %v", req.Name, err)
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
 	if err != nil {
 		logger.Errorf("failed to delete categoryЖ %v", err)
 		return nil, status.Error(codes.Internal, "failed to delete category")
@@ -113,6 +206,34 @@ func (s *CategoryService) DeleteCategory(ctx context.Context, req *pb.DeleteCate
 	return &pb.DeleteCategoryResponse{}, nil
 }
 
+// DeleteCategoryByID удаляет категорию по ID; если передана версия, удаление выполняется только при её совпадении
+func (s *CategoryService) DeleteCategoryByID(ctx context.Context, req *DeleteCategoryByIDRequest) (*pb.DeleteCategoryResponse, error) {
+	if req.Id == 0 {
+		logger.Error("category ID is required")
+		return nil, status.Error(codes.InvalidArgument, "category ID is required")
+	}
+
+	if err := s.CategoryRepository.DeleteByID(uint(req.Id), uint(req.Version), req.Unscoped); err != nil {
+		logger.Errorf("failed to delete category %d: %v", req.Id, err)
+		return nil, categoryWriteErrorToStatus(err)
+	}
+
+	return &pb.DeleteCategoryResponse{}, nil
+}
+
+func categoryWriteErrorToStatus(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "category not found")
+	case errors.Is(err, ErrVersionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, ErrCategoryInUse):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, "failed to write category")
+	}
+}
+
 func ConvertDBToProto(category *Category) *pb.Category {
 	if category == nil {
 		return nil
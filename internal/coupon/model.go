@@ -0,0 +1,92 @@
+package coupon
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	ScopeAll      = "all"
+	ScopeBrand    = "brand"
+	ScopeCategory = "category"
+)
+
+const (
+	DiscountPercentage = "percentage"
+	DiscountFixed      = "fixed"
+)
+
+var (
+	ErrCouponNotFound     = errors.New("coupon not found")
+	ErrCouponInactive     = errors.New("coupon is not active")
+	ErrCouponNotStarted   = errors.New("coupon is not valid yet")
+	ErrCouponExpired      = errors.New("coupon has expired")
+	ErrUsageLimitReached  = errors.New("coupon usage limit reached")
+	ErrUserLimitReached   = errors.New("coupon usage limit for user reached")
+	ErrMinOrderNotReached = errors.New("order value is below coupon minimum")
+	ErrNotEligible        = errors.New("no items in order are eligible for coupon")
+	ErrAlreadyRedeemed    = errors.New("coupon already redeemed for another order")
+)
+
+// NormalizeCode приводит код к виду, в котором он хранится: коды сравниваются
+// без учёта регистра и пробелов по краям
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+type Coupon struct {
+	gorm.Model
+	Code          string          `gorm:"type:varchar(64);not null;uniqueIndex" json:"code"`
+	BatchName     string          `gorm:"type:varchar(255);index" json:"batch_name"` // имя пачки при массовой генерации
+	DiscountType  string          `gorm:"type:varchar(20);not null" json:"discount_type"`
+	Value         decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"value"`
+	MinOrderValue decimal.Decimal `gorm:"type:decimal(10,2);default:0" json:"min_order_value"`
+	UsageLimit    uint            `gorm:"default:0" json:"usage_limit"`    // 0 — без ограничения
+	PerUserLimit  uint            `gorm:"default:0" json:"per_user_limit"` // 0 — без ограничения
+	UsedCount     uint            `gorm:"default:0" json:"used_count"`
+	StartsAt      *time.Time      `json:"starts_at"`
+	EndsAt        *time.Time      `json:"ends_at"`
+	IsActive      bool            `gorm:"not null" json:"is_active"`
+	ScopeType     string          `gorm:"type:varchar(20);not null" json:"scope_type"`
+	ScopeIDs      []uint          `gorm:"type:json;serializer:json" json:"scope_ids"` // ID брендов или категорий
+}
+
+// Redemption — факт применения купона к заказу. Пара (купон, заказ) уникальна,
+// поэтому повторный Redeem того же заказа не списывает использование дважды
+type Redemption struct {
+	gorm.Model
+	CouponID uint            `gorm:"not null;uniqueIndex:idx_coupon_redemptions_coupon_order;index" json:"coupon_id"`
+	OrderID  string          `gorm:"type:varchar(100);not null;uniqueIndex:idx_coupon_redemptions_coupon_order" json:"order_id"`
+	UserID   uint            `gorm:"not null;index" json:"user_id"`
+	Amount   decimal.Decimal `gorm:"type:decimal(10,2);not null" json:"amount"`
+}
+
+func (Redemption) TableName() string {
+	return "coupon_redemptions"
+}
+
+// OrderItem — строка заказа для проверки области действия купона
+type OrderItem struct {
+	BrandID    uint
+	CategoryID uint
+	Amount     decimal.Decimal // сумма строки
+}
+
+// Order — заказ, к которому применяется купон
+type Order struct {
+	ID     string
+	UserID uint
+	Items  []OrderItem
+}
+
+func (o Order) Total() decimal.Decimal {
+	total := decimal.Zero
+	for _, item := range o.Items {
+		total = total.Add(item.Amount)
+	}
+	return total
+}
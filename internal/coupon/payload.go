@@ -0,0 +1,61 @@
+package coupon
+
+import "google.golang.org/protobuf/types/known/timestamppb"
+
+// Сообщения CouponService, которых пока нет в admin-proto
+
+type GenerateCouponsRequest struct {
+	Count         uint32                 `json:"count"`
+	CodeLength    uint32                 `json:"code_length"` // без учёта префикса, по умолчанию 10
+	Alphabet      string                 `json:"alphabet"`    // по умолчанию codegen.CouponAlphabet
+	Prefix        string                 `json:"prefix"`
+	BatchName     string                 `json:"batch_name"`
+	DiscountType  string                 `json:"discount_type"`
+	Value         string                 `json:"value"`           // десятичная строка: процент или сумма
+	MinOrderValue int64                  `json:"min_order_value"` // в копейках
+	UsageLimit    uint32                 `json:"usage_limit"`
+	PerUserLimit  uint32                 `json:"per_user_limit"`
+	StartsAt      *timestamppb.Timestamp `json:"starts_at"`
+	EndsAt        *timestamppb.Timestamp `json:"ends_at"`
+	ScopeType     string                 `json:"scope_type"`
+	ScopeIds      []uint32               `json:"scope_ids"`
+}
+
+type GenerateCouponsResponse struct {
+	Codes []string `json:"codes"`
+}
+
+type OrderItemMessage struct {
+	VariantId uint32 `json:"variant_id"`
+	Amount    int64  `json:"amount"` // сумма строки в копейках
+}
+
+type ValidateCouponRequest struct {
+	Code   string              `json:"code"`
+	UserId uint32              `json:"user_id"`
+	Items  []*OrderItemMessage `json:"items"`
+}
+
+type ValidateCouponResponse struct {
+	Valid    bool   `json:"valid"`
+	Reason   string `json:"reason"`   // причина отказа, если Valid == false
+	Discount int64  `json:"discount"` // в копейках
+}
+
+type RedeemCouponRequest struct {
+	Code    string              `json:"code"`
+	UserId  uint32              `json:"user_id"`
+	OrderId string              `json:"order_id"`
+	Items   []*OrderItemMessage `json:"items"`
+}
+
+type RedeemCouponResponse struct {
+	RedemptionId uint32 `json:"redemption_id"`
+	Discount     int64  `json:"discount"` // в копейках
+}
+
+type DeactivateCouponRequest struct {
+	Code string `json:"code"`
+}
+
+type DeactivateCouponResponse struct{}
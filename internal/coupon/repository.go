@@ -0,0 +1,177 @@
+package coupon
+
+import (
+	"admin/pkg/codegen"
+	"admin/pkg/db"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CouponRepository struct {
+	Database *db.Db
+}
+
+func NewCouponRepository(database *db.Db) *CouponRepository {
+	return &CouponRepository{
+		Database: database,
+	}
+}
+
+// GenerateBatch создаёт count купонов по шаблону со случайными кодами prefix+N символов alphabet.
+// Коллизии с существующими кодами пропускаются и генерируются заново
+func (repo *CouponRepository) GenerateBatch(template *Coupon, count, length int, alphabet, prefix string) ([]Coupon, error) {
+	coupons := make([]Coupon, 0, count)
+	maxAttempts := count*3 + 100
+
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		for attempts := 0; len(coupons) < count; attempts++ {
+			if attempts >= maxAttempts {
+				return fmt.Errorf("too many code collisions: generated %d of %d, use a longer code or alphabet", len(coupons), count)
+			}
+
+			code, err := codegen.Generate(alphabet, length)
+			if err != nil {
+				return err
+			}
+
+			c := *template
+			c.Model = gorm.Model{}
+			c.Code = prefix + code
+			c.UsedCount = 0
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&c)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 1 {
+				coupons = append(coupons, c)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func (repo *CouponRepository) FindByCode(code string) (*Coupon, error) {
+	var coupon Coupon
+	result := repo.Database.DB.First(&coupon, "code = ?", code)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, ErrCouponNotFound
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &coupon, nil
+}
+
+func (repo *CouponRepository) CountUserRedemptions(couponID, userID uint) (int64, error) {
+	var count int64
+	result := repo.Database.DB.Model(&Redemption{}).
+		Where("coupon_id = ? AND user_id = ?", couponID, userID).
+		Count(&count)
+	return count, result.Error
+}
+
+// Redeem применяет купон к заказу. Строка купона блокируется (SELECT ... FOR UPDATE),
+// поэтому параллельные погашения не превышают лимиты. Повторный вызов для того же заказа
+// возвращает уже сохранённое погашение
+func (repo *CouponRepository) Redeem(code string, order Order, now time.Time) (*Redemption, error) {
+	var redemption Redemption
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var coupon Coupon
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coupon, "code = ?", code)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return ErrCouponNotFound
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var existing Redemption
+		result = tx.Where("coupon_id = ? AND order_id = ?", coupon.ID, order.ID).Limit(1).Find(&existing)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			if existing.UserID != order.UserID {
+				return ErrAlreadyRedeemed
+			}
+			redemption = existing
+			return nil
+		}
+
+		if err := coupon.CheckWindow(now); err != nil {
+			return err
+		}
+		var userRedemptions int64
+		if err := tx.Model(&Redemption{}).
+			Where("coupon_id = ? AND user_id = ?", coupon.ID, order.UserID).
+			Count(&userRedemptions).Error; err != nil {
+			return err
+		}
+		if err := coupon.CheckUsage(userRedemptions); err != nil {
+			return err
+		}
+		amount, err := coupon.Discount(order)
+		if err != nil {
+			return err
+		}
+
+		redemption = Redemption{
+			CouponID: coupon.ID,
+			OrderID:  order.ID,
+			UserID:   order.UserID,
+			Amount:   amount,
+		}
+		if err := tx.Create(&redemption).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Coupon{}).
+			Where("id = ?", coupon.ID).
+			Update("used_count", gorm.Expr("used_count + 1")).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+func (repo *CouponRepository) Deactivate(code string) error {
+	result := repo.Database.DB.Model(&Coupon{}).Where("code = ?", code).Update("is_active", false)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCouponNotFound
+	}
+	return nil
+}
+
+// GetVariantScopes возвращает бренд и категорию товара каждого варианта
+func (repo *CouponRepository) GetVariantScopes(variantIDs []uint) (map[uint]OrderItem, error) {
+	var rows []struct {
+		VariantID  uint
+		BrandID    uint
+		CategoryID uint
+	}
+	result := repo.Database.DB.Table("product_variants").
+		Select("product_variants.id AS variant_id, products.brand_id, products.category_id").
+		Joins("JOIN products ON products.id = product_variants.product_id").
+		Where("product_variants.id IN ?", variantIDs).
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	scopes := make(map[uint]OrderItem, len(rows))
+	for _, row := range rows {
+		scopes[row.VariantID] = OrderItem{BrandID: row.BrandID, CategoryID: row.CategoryID}
+	}
+	return scopes, nil
+}
@@ -0,0 +1,85 @@
+package coupon
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// CheckWindow проверяет, что купон включён и действует в момент now
+func (c *Coupon) CheckWindow(now time.Time) error {
+	if !c.IsActive {
+		return ErrCouponInactive
+	}
+	if c.StartsAt != nil && now.Before(*c.StartsAt) {
+		return ErrCouponNotStarted
+	}
+	if c.EndsAt != nil && !now.Before(*c.EndsAt) {
+		return ErrCouponExpired
+	}
+	return nil
+}
+
+// CheckUsage проверяет лимиты использования: общий и на пользователя
+func (c *Coupon) CheckUsage(userRedemptions int64) error {
+	if c.UsageLimit > 0 && c.UsedCount >= c.UsageLimit {
+		return ErrUsageLimitReached
+	}
+	if c.PerUserLimit > 0 && userRedemptions >= int64(c.PerUserLimit) {
+		return ErrUserLimitReached
+	}
+	return nil
+}
+
+// Eligible — строка заказа попадает в область действия купона
+func (c *Coupon) Eligible(item OrderItem) bool {
+	var target uint
+	switch c.ScopeType {
+	case ScopeAll:
+		return true
+	case ScopeBrand:
+		target = item.BrandID
+	case ScopeCategory:
+		target = item.CategoryID
+	default:
+		return false
+	}
+	for _, id := range c.ScopeIDs {
+		if id == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount считает скидку на заказ. Минимальная сумма сравнивается со всем заказом,
+// а скидка начисляется только на подходящие строки
+func (c *Coupon) Discount(order Order) (decimal.Decimal, error) {
+	if order.Total().LessThan(c.MinOrderValue) {
+		return decimal.Zero, ErrMinOrderNotReached
+	}
+
+	eligible := decimal.Zero
+	for _, item := range order.Items {
+		if c.Eligible(item) {
+			eligible = eligible.Add(item.Amount)
+		}
+	}
+	if !eligible.IsPositive() {
+		return decimal.Zero, ErrNotEligible
+	}
+
+	var discount decimal.Decimal
+	switch c.DiscountType {
+	case DiscountPercentage:
+		discount = eligible.Mul(c.Value).Div(hundred)
+	case DiscountFixed:
+		discount = c.Value
+	}
+	if discount.GreaterThan(eligible) {
+		discount = eligible
+	}
+	return discount.Round(2), nil
+}
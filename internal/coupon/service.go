@@ -0,0 +1,237 @@
+package coupon
+
+import (
+	"admin/pkg/codegen"
+	"admin/pkg/logger"
+	"admin/pkg/money"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultCodeLength = 10
+
+type CouponService struct {
+	CouponRepository *CouponRepository
+	validator        *CouponValidator
+}
+
+func NewCouponService(couponRepository *CouponRepository, validator *CouponValidator) *CouponService {
+	return &CouponService{
+		CouponRepository: couponRepository,
+		validator:        validator,
+	}
+}
+
+// GenerateCoupons массово создаёт купоны с одинаковыми условиями
+func (s *CouponService) GenerateCoupons(ctx context.Context, req *GenerateCouponsRequest) (*GenerateCouponsResponse, error) {
+	length := int(req.CodeLength)
+	if length == 0 {
+		length = defaultCodeLength
+	}
+	alphabet := req.Alphabet
+	if alphabet == "" {
+		alphabet = codegen.CouponAlphabet
+	}
+	prefix := req.Prefix
+	if err := s.validator.ValidateGeneration(int(req.Count), length, alphabet, prefix); err != nil {
+		logger.Errorf("GenerateCoupons validation error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	template, err := convertGenerateRequestToDB(req)
+	if err != nil {
+		logger.Errorf("GenerateCoupons error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.validator.Validate(template); err != nil {
+		logger.Errorf("GenerateCoupons validation error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	coupons, err := s.CouponRepository.GenerateBatch(template, int(req.Count), length, dedupe(strings.ToUpper(alphabet)), NormalizeCode(prefix))
+	if err != nil {
+		logger.Errorf("GenerateCoupons error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &GenerateCouponsResponse{Codes: make([]string, 0, len(coupons))}
+	for _, c := range coupons {
+		resp.Codes = append(resp.Codes, c.Code)
+	}
+	logger.Infof("Generated %d coupons for batch '%s'", len(resp.Codes), req.BatchName)
+	return resp, nil
+}
+
+// dedupe убирает повторы символов: после приведения к верхнему регистру
+// "a" и "A" стали бы одним символом с удвоенной вероятностью
+func dedupe(alphabet string) string {
+	seen := make(map[rune]bool)
+	var b strings.Builder
+	for _, r := range alphabet {
+		if !seen[r] {
+			seen[r] = true
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ValidateCoupon проверяет купон для заказа без его погашения
+func (s *CouponService) ValidateCoupon(ctx context.Context, req *ValidateCouponRequest) (*ValidateCouponResponse, error) {
+	code := NormalizeCode(req.Code)
+	if code == "" {
+		logger.Error("ValidateCoupon error: code is required")
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	coupon, err := s.CouponRepository.FindByCode(code)
+	if err != nil {
+		return couponRejection(err)
+	}
+	if err := coupon.CheckWindow(time.Now()); err != nil {
+		return couponRejection(err)
+	}
+	userRedemptions, err := s.CouponRepository.CountUserRedemptions(coupon.ID, uint(req.UserId))
+	if err != nil {
+		logger.Errorf("ValidateCoupon error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err := coupon.CheckUsage(userRedemptions); err != nil {
+		return couponRejection(err)
+	}
+
+	order, err := s.buildOrder("", req.UserId, req.Items)
+	if err != nil {
+		logger.Errorf("ValidateCoupon error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	discount, err := coupon.Discount(order)
+	if err != nil {
+		return couponRejection(err)
+	}
+
+	return &ValidateCouponResponse{Valid: true, Discount: money.DecimalToCents(discount)}, nil
+}
+
+// RedeemCoupon погашает купон для заказа. Безопасен при параллельных вызовах и идемпотентен по OrderId
+func (s *CouponService) RedeemCoupon(ctx context.Context, req *RedeemCouponRequest) (*RedeemCouponResponse, error) {
+	code := NormalizeCode(req.Code)
+	if code == "" || req.OrderId == "" {
+		logger.Error("RedeemCoupon error: code and order ID are required")
+		return nil, status.Error(codes.InvalidArgument, "code and order ID are required")
+	}
+
+	order, err := s.buildOrder(req.OrderId, req.UserId, req.Items)
+	if err != nil {
+		logger.Errorf("RedeemCoupon error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	redemption, err := s.CouponRepository.Redeem(code, order, time.Now())
+	if err != nil {
+		logger.Errorf("RedeemCoupon error: coupon %s, order %s: %v", code, req.OrderId, err)
+		return nil, couponErrorToStatus(err)
+	}
+
+	return &RedeemCouponResponse{
+		RedemptionId: uint32(redemption.ID),
+		Discount:     money.DecimalToCents(redemption.Amount),
+	}, nil
+}
+
+func (s *CouponService) DeactivateCoupon(ctx context.Context, req *DeactivateCouponRequest) (*DeactivateCouponResponse, error) {
+	if err := s.CouponRepository.Deactivate(NormalizeCode(req.Code)); err != nil {
+		logger.Errorf("DeactivateCoupon error: %v", err)
+		return nil, couponErrorToStatus(err)
+	}
+	return &DeactivateCouponResponse{}, nil
+}
+
+func (s *CouponService) buildOrder(orderID string, userID uint32, items []*OrderItemMessage) (Order, error) {
+	variantIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		variantIDs = append(variantIDs, uint(item.VariantId))
+	}
+	scopes, err := s.CouponRepository.GetVariantScopes(variantIDs)
+	if err != nil {
+		return Order{}, err
+	}
+
+	order := Order{ID: orderID, UserID: uint(userID), Items: make([]OrderItem, 0, len(items))}
+	for _, item := range items {
+		orderItem := scopes[uint(item.VariantId)] // неизвестный вариант участвует только в сумме заказа
+		orderItem.Amount = money.CentsToDecimal(item.Amount)
+		order.Items = append(order.Items, orderItem)
+	}
+	return order, nil
+}
+
+// couponRejection — бизнес-отказ валидации возвращается в ответе, а не ошибкой
+func couponRejection(err error) (*ValidateCouponResponse, error) {
+	if isRejection(err) {
+		return &ValidateCouponResponse{Valid: false, Reason: err.Error()}, nil
+	}
+	logger.Errorf("ValidateCoupon error: %v", err)
+	return nil, status.Error(codes.Internal, err.Error())
+}
+
+func isRejection(err error) bool {
+	for _, target := range []error{
+		ErrCouponNotFound, ErrCouponInactive, ErrCouponNotStarted, ErrCouponExpired,
+		ErrUsageLimitReached, ErrUserLimitReached, ErrMinOrderNotReached, ErrNotEligible, ErrAlreadyRedeemed,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func couponErrorToStatus(err error) error {
+	switch {
+	case errors.Is(err, ErrCouponNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case isRejection(err):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func convertGenerateRequestToDB(req *GenerateCouponsRequest) (*Coupon, error) {
+	value, err := decimal.NewFromString(req.Value)
+	if err != nil {
+		return nil, errors.New("invalid discount value")
+	}
+	scopeIDs := make([]uint, 0, len(req.ScopeIds))
+	for _, id := range req.ScopeIds {
+		scopeIDs = append(scopeIDs, uint(id))
+	}
+
+	coupon := &Coupon{
+		BatchName:     req.BatchName,
+		DiscountType:  req.DiscountType,
+		Value:         value,
+		MinOrderValue: money.CentsToDecimal(req.MinOrderValue),
+		UsageLimit:    uint(req.UsageLimit),
+		PerUserLimit:  uint(req.PerUserLimit),
+		IsActive:      true,
+		ScopeType:     req.ScopeType,
+		ScopeIDs:      scopeIDs,
+	}
+	if req.StartsAt != nil {
+		t := req.StartsAt.AsTime()
+		coupon.StartsAt = &t
+	}
+	if req.EndsAt != nil {
+		t := req.EndsAt.AsTime()
+		coupon.EndsAt = &t
+	}
+	return coupon, nil
+}
@@ -0,0 +1,81 @@
+package coupon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MinCodeLength = 6
+	MaxCodeLength = 32
+	MaxBatchSize  = 10000
+	// MaxFullCodeLength — длина колонки code: префикс вместе со случайной частью
+	MaxFullCodeLength = 64
+)
+
+type CouponValidator struct{}
+
+// Validate проверяет шаблон купона перед генерацией
+func (v *CouponValidator) Validate(c *Coupon) error {
+	switch c.DiscountType {
+	case DiscountPercentage:
+		if !c.Value.IsPositive() || c.Value.GreaterThan(hundred) {
+			return errors.New("percentage must be in (0, 100]")
+		}
+	case DiscountFixed:
+		if !c.Value.IsPositive() {
+			return errors.New("fixed discount must be positive")
+		}
+	default:
+		return errors.New("unknown discount type")
+	}
+
+	if c.MinOrderValue.IsNegative() {
+		return errors.New("minimum order value cannot be negative")
+	}
+	if c.StartsAt != nil && c.EndsAt != nil && !c.EndsAt.After(*c.StartsAt) {
+		return errors.New("coupon must end after it starts")
+	}
+
+	switch c.ScopeType {
+	case ScopeAll:
+	case ScopeBrand, ScopeCategory:
+		if len(c.ScopeIDs) == 0 {
+			return errors.New("scope IDs are required for scope " + c.ScopeType)
+		}
+	default:
+		return errors.New("unknown scope type")
+	}
+	return nil
+}
+
+// ValidateGeneration проверяет параметры массовой генерации. Коды хранятся
+// в верхнем регистре (NormalizeCode), поэтому символы алфавита считаются без учёта регистра
+func (v *CouponValidator) ValidateGeneration(count, length int, alphabet, prefix string) error {
+	if count <= 0 || count > MaxBatchSize {
+		return errors.New("count must be in [1, 10000]")
+	}
+	if length < MinCodeLength || length > MaxCodeLength {
+		return errors.New("code length must be in [6, 32]")
+	}
+	distinct := make(map[rune]bool)
+	for _, r := range strings.ToUpper(alphabet) {
+		if unicode.IsSpace(r) {
+			return errors.New("alphabet must not contain whitespace")
+		}
+		distinct[r] = true
+	}
+	if len(distinct) < 10 {
+		return errors.New("alphabet must contain at least 10 distinct case-insensitive symbols")
+	}
+	if strings.IndexFunc(prefix, unicode.IsSpace) >= 0 {
+		return errors.New("prefix must not contain whitespace")
+	}
+	if utf8.RuneCountInString(prefix)+length > MaxFullCodeLength {
+		return fmt.Errorf("prefix and code together must not exceed %d characters", MaxFullCodeLength)
+	}
+	return nil
+}
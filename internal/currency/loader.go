@@ -0,0 +1,55 @@
+package currency
+
+import (
+	"admin/pkg/money"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrInvalidRate = errors.New("exchange rate must be positive")
+
+// LoadRatesFile читает курсы из локального JSON-файла
+func LoadRatesFile(path string) (*RatesFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file RatesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse rates file %s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// buildRates проверяет коды валют и курсы и приводит их к записям для БД
+func buildRates(base string, rates map[string]decimal.Decimal, source string) ([]ExchangeRate, error) {
+	baseCurrency, err := money.LookupCurrency(base)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, base)
+	}
+
+	result := make([]ExchangeRate, 0, len(rates))
+	for code, rate := range rates {
+		quote, err := money.LookupCurrency(code)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, code)
+		}
+		if !rate.IsPositive() {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRate, quote.Code)
+		}
+		if quote.Code == baseCurrency.Code {
+			continue
+		}
+		result = append(result, ExchangeRate{
+			Base:   baseCurrency.Code,
+			Quote:  quote.Code,
+			Rate:   rate,
+			Source: source,
+		})
+	}
+	return result, nil
+}
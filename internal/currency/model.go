@@ -0,0 +1,45 @@
+package currency
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	RateSourceFile  = "file"
+	RateSourceAdmin = "admin"
+)
+
+// ExchangeRate — курс валюты к базовой: 1 Base = Rate Quote
+type ExchangeRate struct {
+	gorm.Model
+	Base   string          `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rates_pair"`
+	Quote  string          `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rates_pair"`
+	Rate   decimal.Decimal `gorm:"type:decimal(18,8);not null"`
+	Source string          `gorm:"type:varchar(20);not null"`
+}
+
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
+
+// VariantPriceOverride — цена варианта, заданная вручную для конкретной валюты вместо пересчёта по курсу
+type VariantPriceOverride struct {
+	gorm.Model
+	VariantID uint            `gorm:"not null;uniqueIndex:idx_variant_price_overrides_currency"`
+	Currency  string          `gorm:"type:char(3);not null;uniqueIndex:idx_variant_price_overrides_currency"`
+	Price     decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	Discount  decimal.Decimal `gorm:"type:decimal(12,2);default:0"`
+}
+
+func (VariantPriceOverride) TableName() string {
+	return "variant_price_overrides"
+}
+
+// RatesFile — формат локального файла курсов:
+//
+//	{"base": "RUB", "rates": {"KZT": "5.62", "BYN": "0.0355"}}
+type RatesFile struct {
+	Base  string                     `json:"base"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
@@ -0,0 +1,54 @@
+package currency
+
+// Сообщения CurrencyService. Когда RPC появятся в admin-proto,
+// эти типы заменяются сгенерированными.
+
+type SetExchangeRatesRequest struct {
+	Base  string            `json:"base"`  // должна совпадать с базовой валютой сервиса
+	Rates map[string]string `json:"rates"` // код ISO 4217 -> десятичная строка: 1 base = rate
+}
+
+type ListExchangeRatesRequest struct{}
+
+type ExchangeRateMessage struct {
+	Quote     string `json:"quote"`
+	Rate      string `json:"rate"`
+	Source    string `json:"source"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+type ListExchangeRatesResponse struct {
+	Base  string                 `json:"base"`
+	Rates []*ExchangeRateMessage `json:"rates"`
+}
+
+type SetPriceOverrideRequest struct {
+	VariantId uint32 `json:"variant_id"`
+	Currency  string `json:"currency"`
+	Price     int64  `json:"price"`    // в минимальных единицах валюты
+	Discount  int64  `json:"discount"` // в минимальных единицах валюты
+}
+
+type DeletePriceOverrideRequest struct {
+	VariantId uint32 `json:"variant_id"`
+	Currency  string `json:"currency"`
+}
+
+type GetVariantPricesRequest struct {
+	VariantIds []uint32 `json:"variant_ids"`
+	Currency   string   `json:"currency"` // пусто — базовая валюта
+}
+
+type VariantPriceMessage struct {
+	VariantId  uint32 `json:"variant_id"`
+	Currency   string `json:"currency"`
+	Price      int64  `json:"price"`    // в минимальных единицах валюты
+	Discount   int64  `json:"discount"` // в минимальных единицах валюты
+	FinalPrice int64  `json:"final_price"`
+	Amount     string `json:"amount"` // цена десятичной строкой, например "12450.00"
+	Overridden bool   `json:"overridden"`
+}
+
+type GetVariantPricesResponse struct {
+	Prices []*VariantPriceMessage `json:"prices"`
+}
@@ -0,0 +1,89 @@
+package currency
+
+import (
+	"admin/pkg/db"
+	"admin/pkg/money"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CurrencyRepository struct {
+	Database *db.Db
+}
+
+func NewCurrencyRepository(database *db.Db) *CurrencyRepository {
+	return &CurrencyRepository{
+		Database: database,
+	}
+}
+
+// UpsertRates сохраняет курсы к базовой валюте, перезаписывая существующие пары
+func (repo *CurrencyRepository) UpsertRates(rates []ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return repo.Database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base"}, {Name: "quote"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"rate": gorm.Expr("EXCLUDED.rate"), "source": gorm.Expr("EXCLUDED.source"), "updated_at": time.Now(), "deleted_at": nil}),
+	}).Create(&rates).Error
+}
+
+func (repo *CurrencyRepository) ListRates(base string) ([]ExchangeRate, error) {
+	var rates []ExchangeRate
+	result := repo.Database.DB.Where("base = ?", base).Order("quote").Find(&rates)
+	return rates, result.Error
+}
+
+// RateTable собирает таблицу курсов к базовой валюте
+func (repo *CurrencyRepository) RateTable(base string) (*money.RateTable, error) {
+	rates, err := repo.ListRates(base)
+	if err != nil {
+		return nil, err
+	}
+	table := money.NewRateTable(base)
+	for _, r := range rates {
+		table.Set(r.Quote, r.Rate)
+	}
+	return table, nil
+}
+
+func (repo *CurrencyRepository) SetPriceOverride(override *VariantPriceOverride) (*VariantPriceOverride, error) {
+	result := repo.Database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "variant_id"}, {Name: "currency"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"price": override.Price, "discount": override.Discount, "updated_at": time.Now(), "deleted_at": nil}),
+	}).Create(override)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return override, nil
+}
+
+func (repo *CurrencyRepository) DeletePriceOverride(variantID uint, currency string) error {
+	result := repo.Database.DB.Unscoped().
+		Where("variant_id = ? AND currency = ?", variantID, currency).
+		Delete(&VariantPriceOverride{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetPriceOverrides возвращает ручные цены вариантов в валюте, ключ — ID варианта
+func (repo *CurrencyRepository) GetPriceOverrides(variantIDs []uint, currency string) (map[uint]VariantPriceOverride, error) {
+	var overrides []VariantPriceOverride
+	if err := repo.Database.DB.
+		Where("variant_id IN ? AND currency = ?", variantIDs, currency).
+		Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[uint]VariantPriceOverride, len(overrides))
+	for _, o := range overrides {
+		result[o.VariantID] = o
+	}
+	return result, nil
+}
@@ -0,0 +1,230 @@
+package currency
+
+import (
+	"admin/internal/productVariant"
+	"admin/pkg/logger"
+	"admin/pkg/money"
+	"context"
+	"errors"
+	"strings"
+
+	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// DefaultBaseCurrency — валюта, в которой хранятся цены вариантов, если она не задана в конфиге
+const DefaultBaseCurrency = "RUB"
+
+type CurrencyService struct {
+	CurrencyRepository       *CurrencyRepository
+	ProductVariantRepository *productVariant.ProductVariantRepository
+	Base                     string
+}
+
+func NewCurrencyService(currencyRepository *CurrencyRepository, productVariantRepository *productVariant.ProductVariantRepository, base string) *CurrencyService {
+	if base == "" {
+		base = DefaultBaseCurrency
+	}
+	return &CurrencyService{
+		CurrencyRepository:       currencyRepository,
+		ProductVariantRepository: productVariantRepository,
+		Base:                     strings.ToUpper(base),
+	}
+}
+
+// LoadRatesFromFile загружает курсы из локального файла в БД. Вызывается при старте сервиса
+func (s *CurrencyService) LoadRatesFromFile(path string) error {
+	file, err := LoadRatesFile(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(file.Base, s.Base) {
+		return errors.New("rates file base " + file.Base + " does not match " + s.Base)
+	}
+	rates, err := buildRates(s.Base, file.Rates, RateSourceFile)
+	if err != nil {
+		return err
+	}
+	if err := s.CurrencyRepository.UpsertRates(rates); err != nil {
+		return err
+	}
+	logger.Infof("Loaded %d exchange rates from %s", len(rates), path)
+	return nil
+}
+
+// SetExchangeRates обновляет курсы к базовой валюте
+func (s *CurrencyService) SetExchangeRates(ctx context.Context, req *SetExchangeRatesRequest) (*ListExchangeRatesResponse, error) {
+	if req.Base != "" && !strings.EqualFold(req.Base, s.Base) {
+		logger.Errorf("SetExchangeRates error: base %s, expected %s", req.Base, s.Base)
+		return nil, status.Errorf(codes.InvalidArgument, "rates must be relative to %s", s.Base)
+	}
+
+	parsed := make(map[string]decimal.Decimal, len(req.Rates))
+	for code, value := range req.Rates {
+		rate, err := decimal.NewFromString(value)
+		if err != nil {
+			logger.Errorf("SetExchangeRates error: rate for %s: %v", code, err)
+			return nil, status.Errorf(codes.InvalidArgument, "invalid rate for %s", code)
+		}
+		parsed[code] = rate
+	}
+	rates, err := buildRates(s.Base, parsed, RateSourceAdmin)
+	if err != nil {
+		logger.Errorf("SetExchangeRates error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.CurrencyRepository.UpsertRates(rates); err != nil {
+		logger.Errorf("SetExchangeRates error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return s.ListExchangeRates(ctx, &ListExchangeRatesRequest{})
+}
+
+func (s *CurrencyService) ListExchangeRates(ctx context.Context, req *ListExchangeRatesRequest) (*ListExchangeRatesResponse, error) {
+	rates, err := s.CurrencyRepository.ListRates(s.Base)
+	if err != nil {
+		logger.Errorf("ListExchangeRates error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &ListExchangeRatesResponse{Base: s.Base, Rates: make([]*ExchangeRateMessage, 0, len(rates))}
+	for _, r := range rates {
+		resp.Rates = append(resp.Rates, &ExchangeRateMessage{
+			Quote:     r.Quote,
+			Rate:      r.Rate.String(),
+			Source:    r.Source,
+			UpdatedAt: r.UpdatedAt.Unix(),
+		})
+	}
+	return resp, nil
+}
+
+// SetPriceOverride задаёт цену варианта в валюте вручную, без пересчёта по курсу
+func (s *CurrencyService) SetPriceOverride(ctx context.Context, req *SetPriceOverrideRequest) (*VariantPriceMessage, error) {
+	price, err := money.FromMinor(req.Price, req.Currency)
+	if err != nil {
+		logger.Errorf("SetPriceOverride error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	discount, _ := money.FromMinor(req.Discount, req.Currency)
+	if err := money.ValidatePrice(price.Amount, discount.Amount); err != nil {
+		logger.Errorf("SetPriceOverride error: price %s, discount %s: %v", price, discount, err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	variant, err := s.ProductVariantRepository.GetByID(uint(req.VariantId), false)
+	if err != nil || variant == nil {
+		logger.Errorf("SetPriceOverride error: variant %d not found: %v", req.VariantId, err)
+		return nil, status.Error(codes.NotFound, "variant not found")
+	}
+
+	override, err := s.CurrencyRepository.SetPriceOverride(&VariantPriceOverride{
+		VariantID: variant.ID,
+		Currency:  price.Currency.Code,
+		Price:     price.Amount,
+		Discount:  discount.Amount,
+	})
+	if err != nil {
+		logger.Errorf("SetPriceOverride error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return convertPriceToPayload(variant.ID, price.Currency, override.Price, override.Discount, true), nil
+}
+
+func (s *CurrencyService) DeletePriceOverride(ctx context.Context, req *DeletePriceOverrideRequest) (*pb.Error, error) {
+	currency, err := money.LookupCurrency(req.Currency)
+	if err != nil {
+		logger.Errorf("DeletePriceOverride error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.CurrencyRepository.DeletePriceOverride(uint(req.VariantId), currency.Code); err != nil {
+		logger.Errorf("DeletePriceOverride error: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "price override not found")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.Error{}, nil
+}
+
+// GetVariantPrices возвращает цены вариантов в запрошенной валюте.
+// Ручная цена для валюты имеет приоритет над пересчётом по курсу
+func (s *CurrencyService) GetVariantPrices(ctx context.Context, req *GetVariantPricesRequest) (*GetVariantPricesResponse, error) {
+	code := req.Currency
+	if code == "" {
+		code = s.Base
+	}
+	target, err := money.LookupCurrency(code)
+	if err != nil {
+		logger.Errorf("GetVariantPrices error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if len(req.VariantIds) == 0 {
+		return &GetVariantPricesResponse{}, nil
+	}
+
+	ids := make([]uint, 0, len(req.VariantIds))
+	for _, id := range req.VariantIds {
+		ids = append(ids, uint(id))
+	}
+	variants, err := s.ProductVariantRepository.GetByIDs(ids)
+	if err != nil {
+		logger.Errorf("GetVariantPrices error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	overrides, err := s.CurrencyRepository.GetPriceOverrides(ids, target.Code)
+	if err != nil {
+		logger.Errorf("GetVariantPrices error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	table, err := s.CurrencyRepository.RateTable(s.Base)
+	if err != nil {
+		logger.Errorf("GetVariantPrices error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &GetVariantPricesResponse{Prices: make([]*VariantPriceMessage, 0, len(variants))}
+	for _, v := range variants {
+		if o, ok := overrides[v.ID]; ok {
+			resp.Prices = append(resp.Prices, convertPriceToPayload(v.ID, target, o.Price, o.Discount, true))
+			continue
+		}
+
+		price, err := s.convert(table, v.Price, target.Code)
+		if err != nil {
+			logger.Errorf("GetVariantPrices error: %s -> %s: %v", s.Base, target.Code, err)
+			return nil, status.Errorf(codes.FailedPrecondition, "no exchange rate %s -> %s", s.Base, target.Code)
+		}
+		discount, err := s.convert(table, v.Discount, target.Code)
+		if err != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "no exchange rate %s -> %s", s.Base, target.Code)
+		}
+		resp.Prices = append(resp.Prices, convertPriceToPayload(v.ID, target, price.Amount, discount.Amount, false))
+	}
+	return resp, nil
+}
+
+func (s *CurrencyService) convert(table *money.RateTable, amount decimal.Decimal, to string) (money.Money, error) {
+	m, err := money.New(amount, s.Base)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return table.Convert(m, to)
+}
+
+func convertPriceToPayload(variantID uint, currency money.Currency, price, discount decimal.Decimal, overridden bool) *VariantPriceMessage {
+	p := money.Money{Amount: price, Currency: currency}
+	d := money.Money{Amount: discount, Currency: currency}
+	return &VariantPriceMessage{
+		VariantId:  uint32(variantID),
+		Currency:   currency.Code,
+		Price:      p.Minor(),
+		Discount:   d.Minor(),
+		FinalPrice: money.Money{Amount: money.FinalPrice(price, discount), Currency: currency}.Minor(),
+		Amount:     p.Amount.StringFixed(currency.Exponent),
+		Overridden: overridden,
+	}
+}
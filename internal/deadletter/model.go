@@ -0,0 +1,75 @@
+package deadletter
+
+import (
+	"time"
+)
+
+const (
+	StatusRetrying    = "retrying"    // процессор отправил на повтор
+	StatusParked      = "parked"      // попытки исчерпаны или правило отправило на стоянку
+	StatusQuarantined = "quarantined" // не распарсилось или не прошло проверку модели и схемы payload
+	StatusSkipped     = "skipped"     // категория не повторяется автоматически
+	StatusReplayed    = "replayed"    // переотправлено вручную
+	StatusDiscarded   = "discarded"   // отброшено вручную, больше не показывается по умолчанию
+)
+
+// ReplayableStatuses — статусы, которые массовая переотправка выбирает без явного Status.
+// Сообщения в retrying процессор уже отправил повторно, а replayed — уже переотправлены:
+// повторная отправка продублировала бы уведомление
+var ReplayableStatuses = []string{StatusParked, StatusQuarantined, StatusSkipped}
+
+const (
+	MaxListLimit   = 100
+	MaxBulkReplay  = 1000
+	MaxHistorySize = 50 // старые записи истории отбрасываются
+)
+
+// HistoryEntry — одно решение по сообщению: процессора или оператора
+type HistoryEntry struct {
+	At      time.Time `json:"at"`
+	Attempt int       `json:"attempt"`
+	Outcome string    `json:"outcome"`
+	Reason  string    `json:"reason"`
+	Actor   string    `json:"actor,omitempty"` // пусто — процессор DLQ
+}
+
+// Message — сообщение, прошедшее через DLQ. Повторные попадания одного уведомления
+// обновляют ту же строку (по DedupKey) и дописываются в History
+type Message struct {
+	ID               uint              `gorm:"primarykey" json:"id"`
+	DedupKey         string            `gorm:"type:varchar(255);not null;uniqueIndex" json:"dedup_key"`
+	NotificationID   string            `gorm:"type:varchar(255);index" json:"notification_id"`
+	Key              string            `gorm:"type:text" json:"key"`
+	Payload          string            `gorm:"type:text;not null" json:"payload"` // как пришло, может быть невалидным JSON
+	Headers          map[string]string `gorm:"type:jsonb;serializer:json" json:"headers"`
+	Category         string            `gorm:"type:varchar(50);index:idx_dead_letter_filter" json:"category"`
+	Subtype          string            `gorm:"type:varchar(100);index:idx_dead_letter_filter" json:"subtype"`
+	UserID           uint32            `json:"user_id"`
+	Status           string            `gorm:"type:varchar(20);not null;index:idx_dead_letter_filter" json:"status"`
+	Attempts         int               `gorm:"not null" json:"attempts"`
+	LastReason       string            `gorm:"type:text" json:"last_reason"`
+	ValidationErrors []string          `gorm:"type:jsonb;serializer:json" json:"validation_errors"` // для quarantined
+	History          []HistoryEntry    `gorm:"type:jsonb;serializer:json" json:"history"`
+	SourceTopic      string            `gorm:"type:varchar(255)" json:"source_topic"`
+	SourcePartition  int               `json:"source_partition"`
+	SourceOffset     int64             `json:"source_offset"`
+	CreatedAt        time.Time         `gorm:"index" json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	ReplayedAt       *time.Time        `json:"replayed_at"`
+}
+
+func (Message) TableName() string {
+	return "dead_letter_messages"
+}
+
+// Filter — условия выборки сообщений, нулевые поля не учитываются.
+// Без Status отброшенные сообщения не показываются
+type Filter struct {
+	Category       string
+	Subtype        string
+	Status         string
+	NotificationID string
+	UserID         uint32
+	From           time.Time
+	To             time.Time
+}
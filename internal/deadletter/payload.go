@@ -0,0 +1,83 @@
+package deadletter
+
+// Сообщения DeadLetterService. Когда RPC появятся в admin-proto,
+// эти типы заменяются сгенерированными.
+
+type FilterMessage struct {
+	Category       string `json:"category"`
+	Subtype        string `json:"subtype"`
+	Status         string `json:"status"` // пусто — все, кроме discarded
+	NotificationId string `json:"notification_id"`
+	UserId         uint32 `json:"user_id"`
+	From           int64  `json:"from"` // unix-время, включительно
+	To             int64  `json:"to"`   // unix-время, не включая
+}
+
+type ListDeadLettersRequest struct {
+	Filter *FilterMessage `json:"filter"`
+	Limit  int32          `json:"limit"`
+	Offset int32          `json:"offset"`
+}
+
+type HistoryEntryMessage struct {
+	At      int64  `json:"at"`
+	Attempt int32  `json:"attempt"`
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason"`
+	Actor   string `json:"actor"`
+}
+
+type DeadLetterMessage struct {
+	Id               uint32                 `json:"id"`
+	NotificationId   string                 `json:"notification_id"`
+	Key              string                 `json:"key"`
+	Payload          string                 `json:"payload"`
+	Headers          map[string]string      `json:"headers"`
+	Category         string                 `json:"category"`
+	Subtype          string                 `json:"subtype"`
+	UserId           uint32                 `json:"user_id"`
+	Status           string                 `json:"status"`
+	Attempts         int32                  `json:"attempts"`
+	LastReason       string                 `json:"last_reason"`
+	ValidationErrors []string               `json:"validation_errors"`
+	History          []*HistoryEntryMessage `json:"history"`
+	CreatedAt        int64                  `json:"created_at"`
+	UpdatedAt        int64                  `json:"updated_at"`
+}
+
+type ListDeadLettersResponse struct {
+	Messages []*DeadLetterMessage `json:"messages"` // без истории и заголовков, их отдаёт GetDeadLetter
+	Total    int64                `json:"total"`
+}
+
+type GetDeadLetterRequest struct {
+	Id uint32 `json:"id"`
+}
+
+type ReplayDeadLetterRequest struct {
+	Id    uint32 `json:"id"`
+	Actor string `json:"actor"` // кто переотправил, попадает в историю
+}
+
+type BulkReplayRequest struct {
+	Filter *FilterMessage `json:"filter"` // пустой фильтр не допускается; без status — parked, quarantined и skipped
+	Limit  int32          `json:"limit"`
+	Actor  string         `json:"actor"`
+}
+
+type BulkReplayResponse struct {
+	Replayed  int32    `json:"replayed"`
+	FailedIds []uint32 `json:"failed_ids"`
+}
+
+type EditAndReplayRequest struct {
+	Id      uint32 `json:"id"`
+	Payload string `json:"payload"` // новое тело, JSON-объект уведомления
+	Actor   string `json:"actor"`
+}
+
+type DiscardDeadLetterRequest struct {
+	Id     uint32 `json:"id"`
+	Reason string `json:"reason"`
+	Actor  string `json:"actor"`
+}
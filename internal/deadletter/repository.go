@@ -0,0 +1,203 @@
+package deadletter
+
+import (
+	"admin/pkg/db"
+	"admin/pkg/dlq"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// historyAppend дописывает новые записи истории и оставляет последние MaxHistorySize
+var historyAppend = fmt.Sprintf(`(
+	SELECT COALESCE(jsonb_agg(t.e ORDER BY t.i), '[]'::jsonb)
+	FROM jsonb_array_elements(COALESCE(dead_letter_messages.history, '[]'::jsonb) || EXCLUDED.history) WITH ORDINALITY AS t(e, i)
+	WHERE t.i > jsonb_array_length(COALESCE(dead_letter_messages.history, '[]'::jsonb) || EXCLUDED.history) - %d)`, MaxHistorySize)
+
+type DeadLetterRepository struct {
+	Database *db.Db
+}
+
+func NewDeadLetterRepository(database *db.Db) *DeadLetterRepository {
+	return &DeadLetterRepository{
+		Database: database,
+	}
+}
+
+// Record сохраняет решение процессора DLQ, реализует dlq.Recorder
+func (repo *DeadLetterRepository) Record(ctx context.Context, rec dlq.Record) error {
+	now := time.Now()
+	msg := Message{
+		DedupKey:         dedupKey(rec),
+		NotificationID:   rec.Notification.ID,
+		Key:              string(rec.Message.Key),
+		Payload:          string(rec.Message.Value),
+		Headers:          headersToMap(rec.Message),
+		Category:         rec.Notification.Category,
+		Subtype:          rec.Notification.Subtype,
+		UserID:           rec.Notification.UserID,
+		Status:           statusFor(rec.Outcome),
+		Attempts:         rec.Attempt,
+		LastReason:       rec.Reason,
+		ValidationErrors: rec.Errors,
+		History:          []HistoryEntry{{At: now, Attempt: rec.Attempt, Outcome: rec.Outcome, Reason: rec.Reason}},
+		SourceTopic:      rec.Message.Topic,
+		SourcePartition:  rec.Message.Partition,
+		SourceOffset:     rec.Message.Offset,
+	}
+	return repo.Database.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "dedup_key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"key":               gorm.Expr("EXCLUDED.key"),
+			"payload":           gorm.Expr("EXCLUDED.payload"),
+			"headers":           gorm.Expr("EXCLUDED.headers"),
+			"category":          gorm.Expr("EXCLUDED.category"),
+			"subtype":           gorm.Expr("EXCLUDED.subtype"),
+			"user_id":           gorm.Expr("EXCLUDED.user_id"),
+			"status":            gorm.Expr("EXCLUDED.status"),
+			"attempts":          gorm.Expr("EXCLUDED.attempts"),
+			"last_reason":       gorm.Expr("EXCLUDED.last_reason"),
+			"validation_errors": gorm.Expr("EXCLUDED.validation_errors"),
+			"history":           gorm.Expr(historyAppend),
+			"source_topic":      gorm.Expr("EXCLUDED.source_topic"),
+			"source_partition":  gorm.Expr("EXCLUDED.source_partition"),
+			"source_offset":     gorm.Expr("EXCLUDED.source_offset"),
+			"updated_at":        gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(&msg).Error
+}
+
+func (repo *DeadLetterRepository) GetByID(id uint) (*Message, error) {
+	var msg Message
+	if err := repo.Database.DB.First(&msg, id).Error; err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// List возвращает страницу сообщений, новые первыми, и общее число подходящих
+func (repo *DeadLetterRepository) List(filter Filter, limit, offset int) ([]Message, int64, error) {
+	query := applyFilter(repo.Database.DB.Model(&Message{}), filter)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var messages []Message
+	err := query.Order("updated_at DESC, id DESC").Limit(limit).Offset(offset).Find(&messages).Error
+	return messages, total, err
+}
+
+// FindForReplay возвращает до limit сообщений под фильтр, старые первыми,
+// чтобы при массовой переотправке сохранялся исходный порядок.
+// Без Status выбираются только ReplayableStatuses
+func (repo *DeadLetterRepository) FindForReplay(filter Filter, limit int) ([]Message, error) {
+	query := repo.Database.DB.Model(&Message{})
+	if filter.Status == "" {
+		query = query.Where("status IN ?", ReplayableStatuses)
+	}
+	var messages []Message
+	err := applyFilter(query, filter).
+		Order("created_at, id").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// UpdatePayload заменяет тело сообщения перед переотправкой
+func (repo *DeadLetterRepository) UpdatePayload(id uint, payload, category, subtype string, entry HistoryEntry) (*Message, error) {
+	return repo.update(id, map[string]interface{}{
+		"payload":  payload,
+		"category": category,
+		"subtype":  subtype,
+		// исправленное тело уже проверено, старые ошибки к нему не относятся
+		"validation_errors": gorm.Expr("NULL"),
+	}, entry)
+}
+
+func (repo *DeadLetterRepository) MarkReplayed(id uint, entry HistoryEntry) (*Message, error) {
+	return repo.update(id, map[string]interface{}{
+		"status":      StatusReplayed,
+		"replayed_at": entry.At,
+		"last_reason": entry.Reason,
+	}, entry)
+}
+
+func (repo *DeadLetterRepository) Discard(id uint, entry HistoryEntry) (*Message, error) {
+	return repo.update(id, map[string]interface{}{
+		"status":      StatusDiscarded,
+		"last_reason": entry.Reason,
+	}, entry)
+}
+
+// update меняет поля и дописывает запись в историю под блокировкой строки
+func (repo *DeadLetterRepository) update(id uint, fields map[string]interface{}, entry HistoryEntry) (*Message, error) {
+	var msg Message
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&msg, id).Error; err != nil {
+			return err
+		}
+		msg.History = append(msg.History, entry)
+		if len(msg.History) > MaxHistorySize {
+			msg.History = msg.History[len(msg.History)-MaxHistorySize:]
+		}
+		fields["history"] = msg.History
+		fields["updated_at"] = entry.At
+		if err := tx.Model(&msg).Updates(fields).Error; err != nil {
+			return err
+		}
+		return tx.First(&msg, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func applyFilter(query *gorm.DB, filter Filter) *gorm.DB {
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Subtype != "" {
+		query = query.Where("subtype = ?", filter.Subtype)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	} else {
+		query = query.Where("status <> ?", StatusDiscarded)
+	}
+	if filter.NotificationID != "" {
+		query = query.Where("notification_id = ?", filter.NotificationID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	return query
+}
+
+// dedupKey связывает повторные попадания одного уведомления в DLQ.
+// Нераспарсенное сообщение идентифицируется позицией в топике
+func dedupKey(rec dlq.Record) string {
+	if rec.Notification.ID != "" {
+		return "id:" + rec.Notification.ID
+	}
+	return fmt.Sprintf("offset:%s/%d/%d", rec.Message.Topic, rec.Message.Partition, rec.Message.Offset)
+}
+
+func statusFor(outcome string) string {
+	switch outcome {
+	case dlq.OutcomeRetried:
+		return StatusRetrying
+	case dlq.OutcomeParked:
+		return StatusParked
+	case dlq.OutcomeQuarantined:
+		return StatusQuarantined
+	}
+	return StatusSkipped
+}
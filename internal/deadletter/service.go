@@ -0,0 +1,327 @@
+package deadletter
+
+import (
+	"admin/pkg/dlq"
+	"admin/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	OutcomeReplayed  = "replayed"
+	OutcomeEdited    = "edited"
+	OutcomeDiscarded = "discarded"
+
+	defaultListLimit = 20
+)
+
+// resetHeaders — служебные заголовки DLQ, которые не переносятся в ручную переотправку:
+// она начинает счёт попыток заново
+var resetHeaders = map[string]bool{
+	dlq.HeaderAttempt:          true,
+	dlq.HeaderParkedReason:     true,
+	dlq.HeaderValidationErrors: true,
+}
+
+type DeadLetterService struct {
+	DeadLetterRepository *DeadLetterRepository
+	Router               *dlq.Router         // выбирает топик переотправки так же, как процессор DLQ
+	Producers            dlq.ProducerSource  // продюсеры топиков переотправки
+	Schemas              *dlq.SchemaRegistry // проверка исправленных сообщений перед переотправкой
+}
+
+func NewDeadLetterService(deadLetterRepository *DeadLetterRepository, router *dlq.Router, producers dlq.ProducerSource, schemas *dlq.SchemaRegistry) *DeadLetterService {
+	if schemas == nil {
+		schemas = dlq.NewSchemaRegistry()
+	}
+	return &DeadLetterService{
+		DeadLetterRepository: deadLetterRepository,
+		Router:               router,
+		Producers:            producers,
+		Schemas:              schemas,
+	}
+}
+
+func (s *DeadLetterService) ListDeadLetters(ctx context.Context, req *ListDeadLettersRequest) (*ListDeadLettersResponse, error) {
+	filter, err := convertFilter(req.Filter)
+	if err != nil {
+		logger.Errorf("ListDeadLetters error: %v", err)
+		return nil, err
+	}
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	messages, total, err := s.DeadLetterRepository.List(filter, min(limit, MaxListLimit), max(int(req.Offset), 0))
+	if err != nil {
+		logger.Errorf("ListDeadLetters error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	result := make([]*DeadLetterMessage, 0, len(messages))
+	for i := range messages {
+		msg := ConvertDBToPayload(&messages[i])
+		msg.History, msg.Headers = nil, nil
+		result = append(result, msg)
+	}
+	return &ListDeadLettersResponse{Messages: result, Total: total}, nil
+}
+
+func (s *DeadLetterService) GetDeadLetter(ctx context.Context, req *GetDeadLetterRequest) (*DeadLetterMessage, error) {
+	msg, err := s.DeadLetterRepository.GetByID(uint(req.Id))
+	if err != nil {
+		logger.Errorf("GetDeadLetter error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	return ConvertDBToPayload(msg), nil
+}
+
+// ReplayDeadLetter отправляет сохранённое сообщение в исходный топик как новое,
+// счётчик попыток начинается заново
+func (s *DeadLetterService) ReplayDeadLetter(ctx context.Context, req *ReplayDeadLetterRequest) (*DeadLetterMessage, error) {
+	msg, err := s.DeadLetterRepository.GetByID(uint(req.Id))
+	if err != nil {
+		logger.Errorf("ReplayDeadLetter error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	if msg.Status == StatusRetrying {
+		logger.Errorf("ReplayDeadLetter error: message %d is being retried by the processor", req.Id)
+		return nil, status.Error(codes.FailedPrecondition, "message is being retried by the processor")
+	}
+	replayed, err := s.replay(ctx, msg, req.Actor, "manual replay")
+	if err != nil {
+		logger.Errorf("ReplayDeadLetter %d error: %v", req.Id, err)
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return ConvertDBToPayload(replayed), nil
+}
+
+// BulkReplay переотправляет до Limit сообщений под фильтр. Ошибка отправки одного
+// сообщения не прерывает остальные, его ID возвращается в FailedIds.
+// Без статуса в фильтре выбираются parked, quarantined и skipped; retrying не переотправляется
+func (s *DeadLetterService) BulkReplay(ctx context.Context, req *BulkReplayRequest) (*BulkReplayResponse, error) {
+	filter, err := convertFilter(req.Filter)
+	if err != nil {
+		logger.Errorf("BulkReplay error: %v", err)
+		return nil, err
+	}
+	if filter == (Filter{}) {
+		logger.Error("BulkReplay error: empty filter")
+		return nil, status.Error(codes.InvalidArgument, "filter is required for bulk replay")
+	}
+	if filter.Status == StatusRetrying {
+		logger.Error("BulkReplay error: retrying messages are already re-sent by the processor")
+		return nil, status.Error(codes.InvalidArgument, "retrying messages are already re-sent by the processor")
+	}
+	limit := int(req.Limit)
+	if limit <= 0 || limit > MaxBulkReplay {
+		limit = MaxBulkReplay
+	}
+
+	messages, err := s.DeadLetterRepository.FindForReplay(filter, limit)
+	if err != nil {
+		logger.Errorf("BulkReplay error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &BulkReplayResponse{FailedIds: []uint32{}}
+	for i := range messages {
+		if ctx.Err() != nil {
+			response.FailedIds = append(response.FailedIds, uint32(messages[i].ID))
+			continue
+		}
+		if _, err := s.replay(ctx, &messages[i], req.Actor, "bulk replay"); err != nil {
+			logger.Errorf("BulkReplay: message %d: %v", messages[i].ID, err)
+			response.FailedIds = append(response.FailedIds, uint32(messages[i].ID))
+			continue
+		}
+		response.Replayed++
+	}
+	logger.Infof("BulkReplay by %q: %d replayed, %d failed", req.Actor, response.Replayed, len(response.FailedIds))
+	return response, nil
+}
+
+// EditAndReplay исправляет тело сообщения и сразу переотправляет его.
+// Исходное тело остаётся в истории
+func (s *DeadLetterService) EditAndReplay(ctx context.Context, req *EditAndReplayRequest) (*DeadLetterMessage, error) {
+	var n dlq.Notification
+	if err := json.Unmarshal([]byte(req.Payload), &n); err != nil {
+		logger.Errorf("EditAndReplay error: %v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "payload must be a notification JSON object: %v", err)
+	}
+	// исправление не должно вернуться в карантин: проверяем его теми же схемами, что и процессор
+	if _, errs := s.Schemas.Check(n); len(errs) > 0 {
+		logger.Errorf("EditAndReplay error: %v", errs)
+		return nil, status.Errorf(codes.InvalidArgument, "payload is invalid: %s", strings.Join(errs, "; "))
+	}
+
+	existing, err := s.DeadLetterRepository.GetByID(uint(req.Id))
+	if err != nil {
+		logger.Errorf("EditAndReplay error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	edited, err := s.DeadLetterRepository.UpdatePayload(existing.ID, req.Payload, n.Category, n.Subtype, HistoryEntry{
+		At:      time.Now(),
+		Attempt: existing.Attempts,
+		Outcome: OutcomeEdited,
+		Reason:  "previous payload: " + existing.Payload,
+		Actor:   req.Actor,
+	})
+	if err != nil {
+		logger.Errorf("EditAndReplay error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+
+	replayed, err := s.replay(ctx, edited, req.Actor, "edited and replayed")
+	if err != nil {
+		logger.Errorf("EditAndReplay %d error: %v", req.Id, err)
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return ConvertDBToPayload(replayed), nil
+}
+
+// DiscardDeadLetter помечает сообщение отброшенным, оно остаётся в БД для истории
+func (s *DeadLetterService) DiscardDeadLetter(ctx context.Context, req *DiscardDeadLetterRequest) (*DeadLetterMessage, error) {
+	existing, err := s.DeadLetterRepository.GetByID(uint(req.Id))
+	if err != nil {
+		logger.Errorf("DiscardDeadLetter error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = "discarded manually"
+	}
+	discarded, err := s.DeadLetterRepository.Discard(existing.ID, HistoryEntry{
+		At:      time.Now(),
+		Attempt: existing.Attempts,
+		Outcome: OutcomeDiscarded,
+		Reason:  reason,
+		Actor:   req.Actor,
+	})
+	if err != nil {
+		logger.Errorf("DiscardDeadLetter error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	return ConvertDBToPayload(discarded), nil
+}
+
+func (s *DeadLetterService) replay(ctx context.Context, msg *Message, actor, reason string) (*Message, error) {
+	headers := make([]kafka.Header, 0, len(msg.Headers))
+	for key, value := range msg.Headers {
+		if !resetHeaders[key] {
+			headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+		}
+	}
+	if err := s.produce(ctx, s.replayTopic(msg), kafka.Message{
+		Key:     []byte(msg.Key),
+		Value:   []byte(msg.Payload),
+		Headers: headers,
+	}); err != nil {
+		return nil, err
+	}
+	return s.DeadLetterRepository.MarkReplayed(msg.ID, HistoryEntry{
+		At:      time.Now(),
+		Attempt: msg.Attempts,
+		Outcome: OutcomeReplayed,
+		Reason:  reason,
+		Actor:   actor,
+	})
+}
+
+// replayTopic — топик, в который процессор DLQ повторил бы сообщение по текущим правилам.
+// Для тела, которое не распарсилось, маршрут выбирается по сохранённым полям
+func (s *DeadLetterService) replayTopic(msg *Message) string {
+	n := dlq.Notification{Category: msg.Category, Subtype: msg.Subtype, UserID: msg.UserID}
+	_ = json.Unmarshal([]byte(msg.Payload), &n)
+	return s.Router.Route(n).Topic
+}
+
+// produce отправляет сообщение в topic. Продюсер создаётся при первой отправке и
+// паникует, если Kafka недоступна, — паника превращается в ошибку RPC
+func (s *DeadLetterService) produce(ctx context.Context, topic string, msg kafka.Message) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("kafka producer for %s: %v", topic, p)
+		}
+	}()
+	return s.Producers.For(topic).ProduceMessage(ctx, msg)
+}
+
+func convertFilter(f *FilterMessage) (Filter, error) {
+	if f == nil {
+		return Filter{}, nil
+	}
+	switch f.Status {
+	case "", StatusRetrying, StatusParked, StatusQuarantined, StatusSkipped, StatusReplayed, StatusDiscarded:
+	default:
+		return Filter{}, status.Errorf(codes.InvalidArgument, "unknown status %q", f.Status)
+	}
+	filter := Filter{
+		Category:       f.Category,
+		Subtype:        f.Subtype,
+		Status:         f.Status,
+		NotificationID: f.NotificationId,
+		UserID:         f.UserId,
+	}
+	if f.From > 0 {
+		filter.From = time.Unix(f.From, 0)
+	}
+	if f.To > 0 {
+		filter.To = time.Unix(f.To, 0)
+	}
+	return filter, nil
+}
+
+func writeErrorToStatus(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Error(codes.NotFound, "dead letter message not found")
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func headersToMap(msg kafka.Message) map[string]string {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	return headers
+}
+
+func ConvertDBToPayload(msg *Message) *DeadLetterMessage {
+	history := make([]*HistoryEntryMessage, 0, len(msg.History))
+	for _, entry := range msg.History {
+		history = append(history, &HistoryEntryMessage{
+			At:      entry.At.Unix(),
+			Attempt: int32(entry.Attempt),
+			Outcome: entry.Outcome,
+			Reason:  entry.Reason,
+			Actor:   entry.Actor,
+		})
+	}
+	return &DeadLetterMessage{
+		Id:               uint32(msg.ID),
+		NotificationId:   msg.NotificationID,
+		Key:              msg.Key,
+		Payload:          msg.Payload,
+		Headers:          msg.Headers,
+		Category:         msg.Category,
+		Subtype:          msg.Subtype,
+		UserId:           msg.UserID,
+		Status:           msg.Status,
+		Attempts:         int32(msg.Attempts),
+		LastReason:       msg.LastReason,
+		ValidationErrors: msg.ValidationErrors,
+		History:          history,
+		CreatedAt:        msg.CreatedAt.Unix(),
+		UpdatedAt:        msg.UpdatedAt.Unix(),
+	}
+}
@@ -0,0 +1,12 @@
+package home
+
+import (
+	"admin/internal/promotion"
+
+	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+)
+
+type HomeDataWithPromotionsResponse struct {
+	HomeData   *pb.HomeDataResponse          `json:"home_data"`
+	Promotions []*promotion.PromotionMessage `json:"promotions"`
+}
@@ -2,10 +2,12 @@ package home
 
 import (
 	"context"
+	"time"
 
 	"admin/internal/brand"
 	"admin/internal/category"
 	"admin/internal/product"
+	"admin/internal/promotion"
 	"admin/pkg/di"
 	"admin/pkg/logger"
 
@@ -20,13 +22,35 @@ type HomeService struct {
 	CategoryRepository di.ICategoryRepository
 	ProductsRepository di.IProductRepository
 	BrandRepository    di.IBrandRepository
+	PromoRepository    di.IPromotionRepository
 }
 
-func NewHomeService(categoryRepository di.ICategoryRepository, productsRepository di.IProductRepository, brandRepository di.IBrandRepository) *HomeService {
+func NewHomeService(categoryRepository di.ICategoryRepository, productsRepository di.IProductRepository, brandRepository di.IBrandRepository, promoRepository di.IPromotionRepository) *HomeService {
 	return &HomeService{
 		CategoryRepository: categoryRepository,
 		ProductsRepository: productsRepository,
-		BrandRepository:    brandRepository}
+		BrandRepository:    brandRepository,
+		PromoRepository:    promoRepository}
+}
+
+// GetHomeDataWithPromotions возвращает данные главной вместе с идущими акциями.
+// HomeDataResponse в admin-proto пока не содержит поля для акций
+func (s *HomeService) GetHomeDataWithPromotions(ctx context.Context, req *pb.EmptyRequest) (*HomeDataWithPromotionsResponse, error) {
+	homeData, err := s.GetHomeData(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions, err := s.PromoRepository.GetActivePromotions(time.Now(), 10)
+	if err != nil {
+		logger.Errorf("failed to get promotions: %v", err)
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	return &HomeDataWithPromotionsResponse{
+		HomeData:   homeData,
+		Promotions: promotion.ConvertDBListToPayload(promotions),
+	}, nil
 }
 
 func (s *HomeService) GetHomeData(ctx context.Context, req *pb.EmptyRequest) (*pb.HomeDataResponse, error) {
@@ -42,10 +66,6 @@ func (s *HomeService) GetHomeData(ctx context.Context, req *pb.EmptyRequest) (*p
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
 
-	// promotions, err := s.promoRepo.GetActivePromotions()
-	// if err != nil {
-	// 	return nil, err
-	// }
 	brands, err := s.BrandRepository.GetFeaturedBrands(5, false)
 	if err != nil {
 		logger.Errorf("failed to get brands: %v", err)
@@ -0,0 +1,100 @@
+package inventory
+
+import (
+	"admin/pkg/consumer"
+	"admin/pkg/dlq"
+	"admin/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Consumer применяет остатки из топика WMS. Повтор уже применённого сообщения
+// отсеивается по MessageID. Позиции с неизвестными SKU и непригодные сообщения уходят в DLQ
+type Consumer struct {
+	*consumer.Consumer
+	*InventoryRepository
+	Validator *StockMessageValidator
+
+	producers *dlq.TopicProducers
+	dlqTopic  string
+}
+
+func NewConsumer(repo *InventoryRepository, brokers []string, topic, dlqTopic string) *Consumer {
+	if topic == "" {
+		topic = DefaultTopic
+	}
+	c := &Consumer{
+		InventoryRepository: repo,
+		Validator:           NewStockMessageValidator(),
+		producers:           dlq.NewTopicProducers(brokers),
+		dlqTopic:            dlqTopic,
+	}
+	c.Consumer = consumer.New("Inventory", brokers, topic, ConsumerGroup, c.Handle)
+	return c
+}
+
+// Stop останавливает чтение и закрывает продюсер DLQ
+func (c *Consumer) Stop(ctx context.Context) error {
+	return errors.Join(c.Consumer.Stop(ctx), c.producers.Close())
+}
+
+// Handle применяет одно сообщение. Ошибка означает, что сообщение нужно повторить
+func (c *Consumer) Handle(ctx context.Context, msg kafka.Message) error {
+	var stock StockMessage
+	if err := json.Unmarshal(msg.Value, &stock); err != nil {
+		return c.reject(ctx, msg, "unparseable: "+err.Error())
+	}
+	if err := c.Validator.Validate(&stock); err != nil {
+		return c.reject(ctx, msg, err.Error())
+	}
+
+	result, err := c.Apply(&stock, func(unknown []StockItem) error {
+		return c.producers.For(c.dlqTopic).Produce(ctx, c.dlqTopic, stock.MessageID, dlq.Notification{
+			ID:       stock.MessageID + ":unknown",
+			Category: DLQCategory,
+			Subtype:  SubtypeUnknown,
+			Payload: map[string]interface{}{
+				"message_id": stock.MessageID,
+				"type":       stock.Type,
+				"warehouse":  stock.Warehouse,
+				"topic":      msg.Topic,
+				"items":      unknown,
+			},
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.Duplicate {
+		logger.Infof("Inventory: message %s already applied, skipped", stock.MessageID)
+		return nil
+	}
+	for _, m := range result.Mismatches {
+		logger.Warnf("Inventory: %s for variant %d (%s): our %d, WMS %d, applied %d", m.Kind, m.VariantID, m.SKU, m.OurStock, m.WMSStock, m.Applied)
+	}
+	if len(result.Unknown) > 0 {
+		logger.Warnf("Inventory: message %s: %d unknown items sent to DLQ", stock.MessageID, len(result.Unknown))
+	}
+	logger.Infof("Inventory: message %s (%s) applied to %d variants", stock.MessageID, stock.Type, result.Applied)
+	return nil
+}
+
+// reject отправляет непригодное сообщение в DLQ целиком: повтор его не исправит
+func (c *Consumer) reject(ctx context.Context, msg kafka.Message, reason string) error {
+	logger.Errorf("Inventory: partition %d offset %d rejected: %s", msg.Partition, msg.Offset, reason)
+	return c.producers.For(c.dlqTopic).Produce(ctx, c.dlqTopic, string(msg.Key), dlq.Notification{
+		ID:       fmt.Sprintf("%s-%d-%d", msg.Topic, msg.Partition, msg.Offset),
+		Category: DLQCategory,
+		Subtype:  SubtypeInvalid,
+		Payload: map[string]interface{}{
+			"reason": reason,
+			"topic":  msg.Topic,
+			"value":  string(msg.Value),
+		},
+	})
+}
@@ -0,0 +1,110 @@
+package inventory
+
+import "time"
+
+// Типы сообщений WMS
+const (
+	MessageSnapshot = "snapshot" // Quantity — фактический остаток
+	MessageDelta    = "delta"    // Delta — изменение остатка, Expected — остаток WMS после него
+)
+
+// Виды расхождений с WMS
+const (
+	MismatchSnapshot      = "snapshot_differs" // наш остаток отличался от снимка WMS
+	MismatchExpected      = "expected_differs" // после дельты остаток не совпал с ожидаемым WMS, принят остаток WMS
+	MismatchNegative      = "negative_stock"   // дельта увела остаток ниже нуля, остаток обнулён
+	MismatchBelowReserved = "below_reserved"   // общий остаток стал меньше брони
+)
+
+const (
+	DefaultTopic   = "wms.inventory"
+	ConsumerGroup  = "admin-inventory"
+	MaxItems       = 10000
+	DLQCategory    = "INVENTORY"
+	SubtypeUnknown = "UNKNOWN_SKU"
+	SubtypeInvalid = "INVALID_MESSAGE"
+)
+
+// StockMessage — снимок или изменение остатков из WMS
+type StockMessage struct {
+	MessageID  string      `json:"message_id"`
+	Type       string      `json:"type"`
+	Warehouse  string      `json:"warehouse"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Items      []StockItem `json:"items"`
+}
+
+// StockItem — позиция сообщения. Вариант ищется по SKU, затем по Barcode
+type StockItem struct {
+	SKU      string `json:"sku,omitempty"`
+	Barcode  string `json:"barcode,omitempty"`
+	Quantity int64  `json:"quantity,omitempty"`
+	Delta    int64  `json:"delta,omitempty"`
+	Expected *int64 `json:"expected,omitempty"`
+}
+
+// ProcessedMessage — обработанное сообщение WMS. Запись создаётся в транзакции
+// применения, поэтому повтор сообщения с тем же ID ничего не меняет
+type ProcessedMessage struct {
+	MessageID   string    `gorm:"type:varchar(100);primaryKey" json:"message_id"`
+	Type        string    `gorm:"type:varchar(20);not null" json:"type"`
+	Warehouse   string    `gorm:"type:varchar(100)" json:"warehouse"`
+	Items       int       `gorm:"not null" json:"items"`
+	Applied     int       `gorm:"not null" json:"applied"`
+	Unknown     int       `gorm:"not null" json:"unknown"`
+	ProcessedAt time.Time `gorm:"not null" json:"processed_at"`
+}
+
+func (ProcessedMessage) TableName() string {
+	return "inventory_processed_messages"
+}
+
+// WarehouseStock — остаток варианта на одном складе по данным WMS. Общий остаток
+// варианта — сумма по складам, поэтому снимок одного склада не затирает остальные
+type WarehouseStock struct {
+	VariantID uint      `gorm:"primaryKey;autoIncrement:false" json:"variant_id"`
+	Warehouse string    `gorm:"type:varchar(100);primaryKey" json:"warehouse"`
+	Quantity  int64     `gorm:"not null" json:"quantity"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (WarehouseStock) TableName() string {
+	return "inventory_warehouse_stocks"
+}
+
+// Mismatch — расхождение нашего остатка с данными WMS
+type Mismatch struct {
+	ID        uint   `gorm:"primarykey" json:"id"`
+	MessageID string `gorm:"type:varchar(100);not null;index" json:"message_id"`
+	Warehouse string `gorm:"type:varchar(100)" json:"warehouse"`
+	VariantID uint   `gorm:"not null;index" json:"variant_id"`
+	SKU       string `gorm:"type:varchar(100)" json:"sku"`
+	Kind      string `gorm:"type:varchar(30);not null;index" json:"kind"`
+	// остаток склада до сообщения, значение WMS и остаток склада после применения;
+	// для below_reserved — общий остаток варианта
+	OurStock  int64     `gorm:"not null" json:"our_stock"`
+	WMSStock  int64     `gorm:"not null" json:"wms_stock"`
+	Applied   int64     `gorm:"not null" json:"applied"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (Mismatch) TableName() string {
+	return "inventory_mismatches"
+}
+
+// Result — итог применения сообщения
+type Result struct {
+	Duplicate  bool
+	Applied    int
+	Unknown    []StockItem
+	Mismatches []Mismatch
+}
+
+// MismatchFilter — фильтр отчёта о расхождениях
+type MismatchFilter struct {
+	Kind      string
+	VariantID uint
+	Since     time.Time
+	Limit     int
+	Offset    int
+}
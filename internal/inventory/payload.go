@@ -0,0 +1,30 @@
+package inventory
+
+// Сообщения InventoryService. Когда RPC появятся в admin-proto,
+// эти типы заменяются сгенерированными.
+
+type ListInventoryMismatchesRequest struct {
+	Kind      string `json:"kind"` // пусто — все виды
+	VariantId uint32 `json:"variant_id"`
+	Since     int64  `json:"since"` // unix-время, 0 — без ограничения
+	Limit     int32  `json:"limit"`
+	Offset    int32  `json:"offset"`
+}
+
+type InventoryMismatchMessage struct {
+	Id        uint32 `json:"id"`
+	MessageId string `json:"message_id"`
+	Warehouse string `json:"warehouse"`
+	VariantId uint32 `json:"variant_id"`
+	Sku       string `json:"sku"`
+	Kind      string `json:"kind"`
+	OurStock  int64  `json:"our_stock"`
+	WmsStock  int64  `json:"wms_stock"`
+	Applied   int64  `json:"applied"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type ListInventoryMismatchesResponse struct {
+	Mismatches []*InventoryMismatchMessage `json:"mismatches"`
+	Total      int64                       `json:"total"`
+}
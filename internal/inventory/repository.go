@@ -0,0 +1,197 @@
+package inventory
+
+import (
+	"admin/internal/productVariant"
+	"admin/pkg/db"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InventoryRepository struct {
+	Database *db.Db
+}
+
+func NewInventoryRepository(database *db.Db) *InventoryRepository {
+	return &InventoryRepository{
+		Database: database,
+	}
+}
+
+// Apply применяет сообщение WMS в одной транзакции вместе с отметкой об обработке.
+// Сообщение с уже обработанным ID пропускается (Result.Duplicate). beforeCommit
+// получает позиции с неизвестными SKU внутри транзакции: его ошибка откатывает
+// применение, и сообщение будет обработано повторно
+func (repo *InventoryRepository) Apply(msg *StockMessage, beforeCommit func(unknown []StockItem) error) (*Result, error) {
+	result := &Result{}
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		processed := &ProcessedMessage{
+			MessageID:   msg.MessageID,
+			Type:        msg.Type,
+			Warehouse:   msg.Warehouse,
+			Items:       len(msg.Items),
+			ProcessedAt: time.Now(),
+		}
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(processed)
+		if created.Error != nil {
+			return created.Error
+		}
+		if created.RowsAffected == 0 {
+			result.Duplicate = true
+			return nil
+		}
+
+		for _, item := range msg.Items {
+			variant, err := productVariant.LockForStock(tx, item.SKU, item.Barcode)
+			if err != nil {
+				return err
+			}
+			if variant == nil {
+				result.Unknown = append(result.Unknown, item)
+				continue
+			}
+			current, err := warehouseStock(tx, variant, msg.Warehouse)
+			if err != nil {
+				return err
+			}
+			target, mismatches := reconcile(msg, item, variant, current)
+			total, err := setWarehouseStock(tx, variant.ID, msg.Warehouse, target)
+			if err != nil {
+				return err
+			}
+			if total < int64(variant.ReservedStock) {
+				mismatches = append(mismatches, Mismatch{
+					MessageID: msg.MessageID,
+					Warehouse: msg.Warehouse,
+					VariantID: variant.ID,
+					SKU:       variant.SKU,
+					Kind:      MismatchBelowReserved,
+					OurStock:  int64(variant.Stock),
+					WMSStock:  total,
+					Applied:   total,
+				})
+			}
+			if err := productVariant.SetStock(tx, variant.ID, uint32(min(total, math.MaxUint32))); err != nil {
+				return err
+			}
+			result.Applied++
+			result.Mismatches = append(result.Mismatches, mismatches...)
+		}
+
+		if len(result.Mismatches) > 0 {
+			if err := tx.Create(&result.Mismatches).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(processed).Updates(map[string]interface{}{
+			"applied": result.Applied,
+			"unknown": len(result.Unknown),
+		}).Error; err != nil {
+			return err
+		}
+		if len(result.Unknown) > 0 && beforeCommit != nil {
+			return beforeCommit(result.Unknown)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// warehouseStock — наш остаток варианта на складе. Пока у варианта нет ни одной записи
+// по складам, весь его остаток считается остатком первого приславшего данные склада
+func warehouseStock(tx *gorm.DB, variant *productVariant.ProductVariant, warehouse string) (int64, error) {
+	var stocks []WarehouseStock
+	if err := tx.Where("variant_id = ?", variant.ID).Find(&stocks).Error; err != nil {
+		return 0, err
+	}
+	if len(stocks) == 0 {
+		return int64(variant.Stock), nil
+	}
+	for _, stock := range stocks {
+		if stock.Warehouse == warehouse {
+			return stock.Quantity, nil
+		}
+	}
+	return 0, nil
+}
+
+// setWarehouseStock сохраняет остаток склада и возвращает общий остаток варианта по складам.
+// Строка варианта уже заблокирована, поэтому сумма согласована с другими сообщениями
+func setWarehouseStock(tx *gorm.DB, variantID uint, warehouse string, quantity int64) (int64, error) {
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "variant_id"}, {Name: "warehouse"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+	}).Create(&WarehouseStock{VariantID: variantID, Warehouse: warehouse, Quantity: quantity}).Error; err != nil {
+		return 0, err
+	}
+	var total int64
+	err := tx.Model(&WarehouseStock{}).Where("variant_id = ?", variantID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&total).Error
+	return total, err
+}
+
+// reconcile вычисляет новый остаток склада current и расхождения с WMS.
+// WMS — источник истины о физическом остатке, поэтому при расхождении принимается её значение
+func reconcile(msg *StockMessage, item StockItem, variant *productVariant.ProductVariant, current int64) (int64, []Mismatch) {
+	var mismatches []Mismatch
+	report := func(kind string, reported, applied int64) {
+		mismatches = append(mismatches, Mismatch{
+			MessageID: msg.MessageID,
+			Warehouse: msg.Warehouse,
+			VariantID: variant.ID,
+			SKU:       variant.SKU,
+			Kind:      kind,
+			OurStock:  current,
+			WMSStock:  reported,
+			Applied:   applied,
+		})
+	}
+
+	var target int64
+	switch msg.Type {
+	case MessageSnapshot:
+		target = item.Quantity
+		if target != current {
+			report(MismatchSnapshot, item.Quantity, target)
+		}
+	case MessageDelta:
+		target = current + item.Delta
+		if item.Expected != nil && *item.Expected != target {
+			report(MismatchExpected, *item.Expected, *item.Expected)
+			target = *item.Expected
+		}
+	}
+
+	if target < 0 {
+		report(MismatchNegative, target, 0)
+		target = 0
+	}
+	return min(target, math.MaxUint32), mismatches
+}
+
+// ListMismatches возвращает расхождения от новых к старым и их общее число
+func (repo *InventoryRepository) ListMismatches(filter MismatchFilter) ([]Mismatch, int64, error) {
+	query := repo.Database.DB.Model(&Mismatch{})
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+	if filter.VariantID != 0 {
+		query = query.Where("variant_id = ?", filter.VariantID)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var mismatches []Mismatch
+	err := query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&mismatches).Error
+	return mismatches, total, err
+}
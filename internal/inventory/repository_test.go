@@ -0,0 +1,96 @@
+package inventory
+
+import (
+	"admin/internal/productVariant"
+	"math"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestReconcile(t *testing.T) {
+	variant := &productVariant.ProductVariant{Model: gorm.Model{ID: 7}, SKU: "SKU-7"}
+	expected := func(v int64) *int64 { return &v }
+	mismatch := func(kind string, ours, wms, applied int64) Mismatch {
+		return Mismatch{MessageID: "m1", Warehouse: "w1", VariantID: 7, SKU: "SKU-7", Kind: kind, OurStock: ours, WMSStock: wms, Applied: applied}
+	}
+
+	tests := []struct {
+		name           string
+		msgType        string
+		item           StockItem
+		current        int64
+		want           int64
+		wantMismatches []Mismatch
+	}{
+		{
+			name:    "snapshot equal to ours",
+			msgType: MessageSnapshot, item: StockItem{Quantity: 10}, current: 10,
+			want: 10,
+		},
+		{
+			name:    "snapshot differs, WMS wins",
+			msgType: MessageSnapshot, item: StockItem{Quantity: 8}, current: 10,
+			want:           8,
+			wantMismatches: []Mismatch{mismatch(MismatchSnapshot, 10, 8, 8)},
+		},
+		{
+			name:    "negative snapshot is zeroed",
+			msgType: MessageSnapshot, item: StockItem{Quantity: -3}, current: 0,
+			want: 0,
+			wantMismatches: []Mismatch{
+				mismatch(MismatchSnapshot, 0, -3, -3),
+				mismatch(MismatchNegative, 0, -3, 0),
+			},
+		},
+		{
+			name:    "delta without expected",
+			msgType: MessageDelta, item: StockItem{Delta: -4}, current: 10,
+			want: 6,
+		},
+		{
+			name:    "delta matching expected",
+			msgType: MessageDelta, item: StockItem{Delta: 5, Expected: expected(15)}, current: 10,
+			want: 15,
+		},
+		{
+			name:    "delta not matching expected takes expected",
+			msgType: MessageDelta, item: StockItem{Delta: 5, Expected: expected(12)}, current: 10,
+			want:           12,
+			wantMismatches: []Mismatch{mismatch(MismatchExpected, 10, 12, 12)},
+		},
+		{
+			name:    "delta below zero is zeroed",
+			msgType: MessageDelta, item: StockItem{Delta: -15}, current: 10,
+			want:           0,
+			wantMismatches: []Mismatch{mismatch(MismatchNegative, 10, -5, 0)},
+		},
+		{
+			name:    "negative expected is reported twice and zeroed",
+			msgType: MessageDelta, item: StockItem{Delta: -1, Expected: expected(-2)}, current: 0,
+			want: 0,
+			wantMismatches: []Mismatch{
+				mismatch(MismatchExpected, 0, -2, -2),
+				mismatch(MismatchNegative, 0, -2, 0),
+			},
+		},
+		{
+			name:    "stock above uint32 is capped",
+			msgType: MessageSnapshot, item: StockItem{Quantity: math.MaxUint32 + 10}, current: math.MaxUint32 + 10,
+			want: math.MaxUint32,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &StockMessage{MessageID: "m1", Type: tt.msgType, Warehouse: "w1"}
+			got, mismatches := reconcile(msg, tt.item, variant, tt.current)
+			if got != tt.want {
+				t.Errorf("stock = %d, want %d", got, tt.want)
+			}
+			if !reflect.DeepEqual(mismatches, tt.wantMismatches) {
+				t.Errorf("mismatches = %+v, want %+v", mismatches, tt.wantMismatches)
+			}
+		})
+	}
+}
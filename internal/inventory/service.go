@@ -0,0 +1,74 @@
+package inventory
+
+import (
+	"admin/pkg/logger"
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+type InventoryService struct {
+	InventoryRepository *InventoryRepository
+}
+
+func NewInventoryService(inventoryRepository *InventoryRepository) *InventoryService {
+	return &InventoryService{
+		InventoryRepository: inventoryRepository,
+	}
+}
+
+// ListInventoryMismatches — отчёт о расхождениях остатков с WMS
+func (s *InventoryService) ListInventoryMismatches(ctx context.Context, req *ListInventoryMismatchesRequest) (*ListInventoryMismatchesResponse, error) {
+	switch req.Kind {
+	case "", MismatchSnapshot, MismatchExpected, MismatchNegative, MismatchBelowReserved:
+	default:
+		logger.Errorf("ListInventoryMismatches error: unknown kind %q", req.Kind)
+		return nil, status.Errorf(codes.InvalidArgument, "unknown mismatch kind %q", req.Kind)
+	}
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	filter := MismatchFilter{
+		Kind:      req.Kind,
+		VariantID: uint(req.VariantId),
+		Limit:     min(limit, MaxListLimit),
+		Offset:    max(int(req.Offset), 0),
+	}
+	if req.Since > 0 {
+		filter.Since = time.Unix(req.Since, 0)
+	}
+
+	mismatches, total, err := s.InventoryRepository.ListMismatches(filter)
+	if err != nil {
+		logger.Errorf("ListInventoryMismatches error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	result := make([]*InventoryMismatchMessage, 0, len(mismatches))
+	for i := range mismatches {
+		result = append(result, ConvertDBToPayload(&mismatches[i]))
+	}
+	return &ListInventoryMismatchesResponse{Mismatches: result, Total: total}, nil
+}
+
+func ConvertDBToPayload(m *Mismatch) *InventoryMismatchMessage {
+	return &InventoryMismatchMessage{
+		Id:        uint32(m.ID),
+		MessageId: m.MessageID,
+		Warehouse: m.Warehouse,
+		VariantId: uint32(m.VariantID),
+		Sku:       m.SKU,
+		Kind:      m.Kind,
+		OurStock:  m.OurStock,
+		WmsStock:  m.WMSStock,
+		Applied:   m.Applied,
+		CreatedAt: m.CreatedAt.Unix(),
+	}
+}
@@ -0,0 +1,48 @@
+package inventory
+
+import (
+	"admin/pkg/validation"
+	"fmt"
+)
+
+// DefaultRules — проверки сообщения WMS до применения
+func DefaultRules() []validation.Rule[*StockMessage] {
+	return []validation.Rule[*StockMessage]{
+		validation.Required("message_id", func(m *StockMessage) string { return m.MessageID }),
+		validation.MaxLength("message_id", 100, func(m *StockMessage) string { return m.MessageID }),
+		validation.Check("type", "must be snapshot or delta", func(m *StockMessage) bool {
+			return m.Type == MessageSnapshot || m.Type == MessageDelta
+		}),
+		validation.Check("items", fmt.Sprintf("must contain from 1 to %d items", MaxItems), func(m *StockMessage) bool {
+			return len(m.Items) > 0 && len(m.Items) <= MaxItems
+		}),
+		func(m *StockMessage) []validation.FieldError {
+			var errs []validation.FieldError
+			for i, item := range m.Items {
+				field := fmt.Sprintf("items[%d]", i)
+				if item.SKU == "" && item.Barcode == "" {
+					errs = append(errs, validation.FieldError{Field: field, Description: "sku or barcode is required"})
+				}
+				if m.Type == MessageSnapshot && item.Quantity < 0 {
+					errs = append(errs, validation.FieldError{Field: field + ".quantity", Description: "must not be negative"})
+				}
+			}
+			return errs
+		},
+	}
+}
+
+type StockMessageValidator struct {
+	rules *validation.Validator[*StockMessage]
+}
+
+func NewStockMessageValidator(extra ...validation.Rule[*StockMessage]) *StockMessageValidator {
+	return &StockMessageValidator{
+		rules: validation.New(DefaultRules()...).Add(extra...),
+	}
+}
+
+// Validate возвращает validation.Errors со всеми нарушениями
+func (v *StockMessageValidator) Validate(msg *StockMessage) error {
+	return v.rules.Validate(msg)
+}
@@ -1,9 +1,9 @@
 package link
 
 import (
+	"admin/pkg/codegen"
 	"admin/pkg/logger"
 	"context"
-	"math/rand"
 
 	pb "github.com/ShopOnGO/admin-proto/pkg/service"
 
@@ -25,13 +25,17 @@ func NewLinkService(linkRepository *LinkRepository) *LinkService {
 }
 
 func (s *LinkService) Create(ctx context.Context, req *pb.CreateLinkRequest) (*pb.CreateLinkResponse, error) {
-	link := NewLink(req.Url)
-	for {
+	link, err := NewLink(req.Url)
+	for err == nil {
 		existedLink, _ := s.LinkRepository.GetByHash(link.Hash)
 		if existedLink == nil {
 			break
 		}
-		link.GenerateHash()
+		err = link.GenerateHash()
+	}
+	if err != nil {
+		logger.Errorf("failed to generate link hash: %v", err)
+		return nil, status.Errorf(codes.Internal, ErrCreateLink, err)
 	}
 	newLink, err := s.LinkRepository.Create(link)
 	if err != nil {
@@ -108,26 +112,28 @@ func (s *LinkService) CountLinks(ctx context.Context, req *pb.CountLinksRequest)
 	}, nil
 }
 
-func NewLink(url string) *Link {
+func NewLink(url string) (*Link, error) {
 	link := &Link{
 		Url: url,
 	}
-	link.GenerateHash()
-	return link
+	if err := link.GenerateHash(); err != nil {
+		return nil, err
+	}
+	return link, nil
 }
 
-func (link *Link) GenerateHash() {
-	link.Hash = RandStringRunes(10)
+func (link *Link) GenerateHash() error {
+	hash, err := RandStringRunes(10)
+	if err != nil {
+		return err
+	}
+	link.Hash = hash
+	return nil
 }
 
-var letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
-
-func RandStringRunes(n int) string {
-	b := make([]rune, n)
-	for i := range b {
-		b[i] = letterRunes[rand.Intn(len(letterRunes))]
-	}
-	return string(b)
+// RandStringRunes возвращает случайную строку из латинских букв; ошибка — crypto/rand недоступен
+func RandStringRunes(n int) (string, error) {
+	return codegen.Generate(codegen.Letters, n)
 }
 
 func convertDeletedAt(d gorm.DeletedAt) *timestamppb.Timestamp {
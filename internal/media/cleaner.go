@@ -0,0 +1,64 @@
+package media
+
+import (
+	"admin/pkg/logger"
+	"context"
+	"time"
+)
+
+const (
+	cleanerBatch = 100
+	// DefaultOrphanGrace — сколько хранятся изображения мягко удалённого владельца,
+	// чтобы его можно было восстановить вместе с ними
+	DefaultOrphanGrace = 7 * 24 * time.Hour
+)
+
+// OrphanCleaner периодически удаляет изображения удалённых товаров, вариантов и брендов.
+// Владельцы удаляются разными путями (каталог, каскады в БД), поэтому сироты
+// ищутся по таблицам владельцев, а не по событиям удаления
+type OrphanCleaner struct {
+	MediaService *MediaService
+	Grace        time.Duration
+}
+
+func NewOrphanCleaner(mediaService *MediaService, grace time.Duration) *OrphanCleaner {
+	if grace <= 0 {
+		grace = DefaultOrphanGrace
+	}
+	return &OrphanCleaner{
+		MediaService: mediaService,
+		Grace:        grace,
+	}
+}
+
+// Run блокируется до отмены ctx, проверяя сирот раз в interval
+func (c *OrphanCleaner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.cleanup(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *OrphanCleaner) cleanup(ctx context.Context) {
+	for {
+		removed, err := c.MediaService.CleanupOrphans(ctx, time.Now().Add(-c.Grace), cleanerBatch)
+		if err != nil {
+			logger.Errorf("OrphanCleaner error: %v", err)
+			return
+		}
+		if removed > 0 {
+			logger.Infof("OrphanCleaner: removed %d images", removed)
+		}
+		// неполная пачка или файлы не удаляются — повторим на следующем тике
+		if removed < cleanerBatch {
+			return
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package media
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+const (
+	OwnerProduct = "product"
+	OwnerVariant = "variant"
+	OwnerBrand   = "brand"
+)
+
+const (
+	MaxFileSize       = 10 << 20 // 10 МБ
+	MaxDimension      = 10000    // пикселей по любой стороне
+	MaxImagesPerOwner = 20
+	MaxAltLength      = 255
+)
+
+var (
+	ErrUnknownOwner  = errors.New("unknown owner type")
+	ErrOwnerNotFound = errors.New("owner not found")
+	ErrTooManyImages = errors.New("too many images")
+	ErrInvalidOrder  = errors.New("order must list every image of the owner exactly once")
+)
+
+// Image — изображение товара, варианта или бренда. Порядок задаёт Position,
+// у владельца не больше одного основного изображения.
+// Product.Images, ProductVariant.Images и Brand.Logo заполняются из этой таблицы
+// для клиентов admin-proto
+type Image struct {
+	gorm.Model
+	OwnerType  string `gorm:"type:varchar(20);not null;index:idx_media_images_owner;uniqueIndex:idx_media_images_primary,where:is_primary AND deleted_at IS NULL" json:"owner_type"`
+	OwnerID    uint   `gorm:"not null;index:idx_media_images_owner;uniqueIndex:idx_media_images_primary,where:is_primary AND deleted_at IS NULL" json:"owner_id"`
+	StorageKey string `gorm:"type:varchar(255);not null" json:"storage_key"`
+	URL        string `gorm:"type:varchar(512);not null" json:"url"`
+	Alt        string `gorm:"type:varchar(255)" json:"alt"`
+	Position   int    `gorm:"not null" json:"position"`
+	// частичный уникальный индекс учитывает только строки с is_primary = true
+	IsPrimary bool   `gorm:"not null;uniqueIndex:idx_media_images_primary,where:is_primary AND deleted_at IS NULL" json:"is_primary"`
+	MimeType  string `gorm:"type:varchar(50);not null" json:"mime_type"`
+	Width     int    `gorm:"not null" json:"width"`
+	Height    int    `gorm:"not null" json:"height"`
+	Size      int64  `gorm:"not null" json:"size"`
+}
+
+func (Image) TableName() string {
+	return "media_images"
+}
+
+// ValidOwner проверяет тип владельца изображения
+func ValidOwner(ownerType string) bool {
+	switch ownerType {
+	case OwnerProduct, OwnerVariant, OwnerBrand:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package media
+
+// Сообщения MediaService. Когда RPC появятся в admin-proto,
+// эти типы заменяются сгенерированными.
+
+type UploadImageRequest struct {
+	OwnerType string `json:"owner_type"` // product, variant или brand
+	OwnerId   uint32 `json:"owner_id"`
+	Data      []byte `json:"data"` // содержимое файла; тип и размеры определяются по нему
+	Alt       string `json:"alt"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type ImageMessage struct {
+	Id        uint32 `json:"id"`
+	OwnerType string `json:"owner_type"`
+	OwnerId   uint32 `json:"owner_id"`
+	Url       string `json:"url"`
+	Alt       string `json:"alt"`
+	Position  int32  `json:"position"`
+	IsPrimary bool   `json:"is_primary"`
+	MimeType  string `json:"mime_type"`
+	Width     int32  `json:"width"`
+	Height    int32  `json:"height"`
+	Size      int64  `json:"size"`
+}
+
+type ListImagesRequest struct {
+	OwnerType string `json:"owner_type"`
+	OwnerId   uint32 `json:"owner_id"`
+}
+
+type ListImagesResponse struct {
+	Images []*ImageMessage `json:"images"`
+}
+
+type UpdateImageRequest struct {
+	Id  uint32 `json:"id"`
+	Alt string `json:"alt"`
+}
+
+type SetPrimaryImageRequest struct {
+	Id uint32 `json:"id"`
+}
+
+type ReorderImagesRequest struct {
+	OwnerType string   `json:"owner_type"`
+	OwnerId   uint32   `json:"owner_id"`
+	ImageIds  []uint32 `json:"image_ids"` // все изображения владельца в новом порядке
+}
+
+type DeleteImageRequest struct {
+	Id uint32 `json:"id"`
+}
@@ -0,0 +1,117 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	MimeJPEG = "image/jpeg"
+	MimePNG  = "image/png"
+	MimeGIF  = "image/gif"
+	MimeWebP = "image/webp"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrCorruptImage    = errors.New("image header is corrupt")
+)
+
+// extensions — допустимые типы и расширения файлов для них
+var extensions = map[string]string{
+	MimeJPEG: ".jpg",
+	MimePNG:  ".png",
+	MimeGIF:  ".gif",
+	MimeWebP: ".webp",
+}
+
+// mimeByExtension угадывает тип по расширению в ссылке; пустая строка — тип неизвестен
+func mimeByExtension(url string) string {
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+	ext := strings.ToLower(path.Ext(url))
+	if ext == ".jpeg" {
+		return MimeJPEG
+	}
+	for mime, known := range extensions {
+		if known == ext {
+			return mime
+		}
+	}
+	return ""
+}
+
+// ProbeResult — тип и размеры изображения, определённые по содержимому
+type ProbeResult struct {
+	MimeType string
+	Width    int
+	Height   int
+}
+
+// Probe определяет тип по сигнатуре файла, а размеры — по заголовку, не декодируя
+// изображение целиком. Расширение и Content-Type от клиента не учитываются
+func Probe(data []byte) (ProbeResult, error) {
+	mime := http.DetectContentType(data)
+	if _, ok := extensions[mime]; !ok {
+		return ProbeResult{}, ErrUnsupportedType
+	}
+
+	var width, height int
+	if mime == MimeWebP {
+		w, h, err := webpSize(data)
+		if err != nil {
+			return ProbeResult{}, err
+		}
+		width, height = w, h
+	} else {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return ProbeResult{}, ErrCorruptImage
+		}
+		width, height = cfg.Width, cfg.Height
+	}
+	if width <= 0 || height <= 0 {
+		return ProbeResult{}, ErrCorruptImage
+	}
+	return ProbeResult{MimeType: mime, Width: width, Height: height}, nil
+}
+
+// webpSize читает размеры из первого чанка RIFF: VP8 (lossy), VP8L (lossless)
+// или VP8X (расширенный формат). В стандартной библиотеке декодера WebP нет
+func webpSize(data []byte) (int, int, error) {
+	if len(data) < 30 {
+		return 0, 0, ErrCorruptImage
+	}
+	switch string(data[12:16]) {
+	case "VP8 ":
+		// кадр начинается с 3 байт тега и стартового кода 9d 01 2a
+		if !bytes.Equal(data[23:26], []byte{0x9d, 0x01, 0x2a}) {
+			return 0, 0, ErrCorruptImage
+		}
+		w := int(binary.LittleEndian.Uint16(data[26:28]) & 0x3fff)
+		h := int(binary.LittleEndian.Uint16(data[28:30]) & 0x3fff)
+		return w, h, nil
+	case "VP8L":
+		if data[20] != 0x2f {
+			return 0, 0, ErrCorruptImage
+		}
+		bits := binary.LittleEndian.Uint32(data[21:25])
+		w := int(bits&0x3fff) + 1
+		h := int((bits>>14)&0x3fff) + 1
+		return w, h, nil
+	case "VP8X":
+		w := int(uint32(data[24])|uint32(data[25])<<8|uint32(data[26])<<16) + 1
+		h := int(uint32(data[27])|uint32(data[28])<<8|uint32(data[29])<<16) + 1
+		return w, h, nil
+	}
+	return 0, 0, ErrCorruptImage
+}
@@ -0,0 +1,389 @@
+package media
+
+import (
+	"admin/pkg/db"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ownerTables — таблицы владельцев изображений
+var ownerTables = map[string]string{
+	OwnerProduct: "products",
+	OwnerVariant: "product_variants",
+	OwnerBrand:   "brands",
+}
+
+type MediaRepository struct {
+	Database *db.Db
+}
+
+func NewMediaRepository(database *db.Db) *MediaRepository {
+	return &MediaRepository{
+		Database: database,
+	}
+}
+
+// OwnerExists проверяет, что владелец существует и не удалён
+func (repo *MediaRepository) OwnerExists(ownerType string, ownerID uint) (bool, error) {
+	table, ok := ownerTables[ownerType]
+	if !ok {
+		return false, ErrUnknownOwner
+	}
+	var count int64
+	err := repo.Database.DB.Table(table).Where("id = ? AND deleted_at IS NULL", ownerID).Count(&count).Error
+	return count > 0, err
+}
+
+// Create добавляет изображение в конец списка владельца.
+// Первое изображение владельца становится основным
+func (repo *MediaRepository) Create(image *Image) (*Image, error) {
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockOwner(tx, image.OwnerType, image.OwnerID); err != nil {
+			return err
+		}
+		var stats struct {
+			Count       int64
+			MaxPosition int
+		}
+		if err := tx.Model(&Image{}).
+			Select("COUNT(*) AS count, COALESCE(MAX(position), -1) AS max_position").
+			Where("owner_type = ? AND owner_id = ?", image.OwnerType, image.OwnerID).
+			Scan(&stats).Error; err != nil {
+			return err
+		}
+		if stats.Count == 0 {
+			// до первой загрузки ссылки владельца ещё не перенесены в media_images
+			imported, err := importLegacy(tx, image.OwnerType, image.OwnerID)
+			if err != nil {
+				return err
+			}
+			stats.Count, stats.MaxPosition = int64(imported), imported-1
+		}
+		if stats.Count >= MaxImagesPerOwner {
+			return ErrTooManyImages
+		}
+		image.Position = stats.MaxPosition + 1
+		if stats.Count == 0 {
+			image.IsPrimary = true
+		}
+		if image.IsPrimary {
+			if err := clearPrimary(tx, image.OwnerType, image.OwnerID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(image).Error; err != nil {
+			return err
+		}
+		return syncOwner(tx, image.OwnerType, image.OwnerID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+func (repo *MediaRepository) GetByID(id uint) (*Image, error) {
+	var image Image
+	if err := repo.Database.DB.First(&image, id).Error; err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// ListByOwner возвращает изображения в порядке показа
+func (repo *MediaRepository) ListByOwner(ownerType string, ownerID uint) ([]Image, error) {
+	var images []Image
+	result := repo.Database.DB.
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Order("position, id").
+		Find(&images)
+	return images, result.Error
+}
+
+func (repo *MediaRepository) UpdateAlt(id uint, alt string) (*Image, error) {
+	var image Image
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Image{}).Where("id = ?", id).Update("alt", alt).Error; err != nil {
+			return err
+		}
+		return tx.First(&image, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// SetPrimary делает изображение основным, снимая флаг с остальных изображений владельца
+func (repo *MediaRepository) SetPrimary(id uint) (*Image, error) {
+	var image Image
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&image, id).Error; err != nil {
+			return err
+		}
+		if err := lockOwner(tx, image.OwnerType, image.OwnerID); err != nil {
+			return err
+		}
+		if err := clearPrimary(tx, image.OwnerType, image.OwnerID); err != nil {
+			return err
+		}
+		if err := tx.Model(&image).Update("is_primary", true).Error; err != nil {
+			return err
+		}
+		return syncOwner(tx, image.OwnerType, image.OwnerID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// Reorder задаёт порядок изображений. ids должен содержать все изображения владельца
+func (repo *MediaRepository) Reorder(ownerType string, ownerID uint, ids []uint) ([]Image, error) {
+	var images []Image
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockOwner(tx, ownerType, ownerID); err != nil {
+			return err
+		}
+		var existing []uint
+		if err := tx.Model(&Image{}).
+			Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+			Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		if !sameSet(existing, ids) {
+			return ErrInvalidOrder
+		}
+		for position, id := range ids {
+			if err := tx.Model(&Image{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		if err := syncOwner(tx, ownerType, ownerID); err != nil {
+			return err
+		}
+		return tx.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).Order("position, id").Find(&images).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// Delete удаляет запись изображения и возвращает её, чтобы вызывающий удалил файл.
+// Если удалено основное изображение, основным становится первое из оставшихся
+func (repo *MediaRepository) Delete(id uint) (*Image, error) {
+	var image Image
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&image, id).Error; err != nil {
+			return err
+		}
+		if err := lockOwner(tx, image.OwnerType, image.OwnerID); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&Image{}, id).Error; err != nil {
+			return err
+		}
+		if image.IsPrimary {
+			var next Image
+			err := tx.Where("owner_type = ? AND owner_id = ?", image.OwnerType, image.OwnerID).
+				Order("position, id").First(&next).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil {
+				if err := tx.Model(&next).Update("is_primary", true).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return syncOwner(tx, image.OwnerType, image.OwnerID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// FindOrphans возвращает изображения, владелец которых удалён физически
+// или мягко удалён раньше deletedBefore. Мягко удалённый владелец может быть
+// восстановлен, поэтому его изображения хранятся до истечения срока
+func (repo *MediaRepository) FindOrphans(deletedBefore time.Time, limit int) ([]Image, error) {
+	var images []Image
+	query := repo.Database.DB.Where("1 = 0")
+	for ownerType, table := range ownerTables {
+		query = query.Or("owner_type = ? AND NOT EXISTS (SELECT 1 FROM "+table+" o WHERE o.id = media_images.owner_id AND (o.deleted_at IS NULL OR o.deleted_at >= ?))",
+			ownerType, deletedBefore)
+	}
+	result := repo.Database.DB.Where(query).Order("id").Limit(limit).Find(&images)
+	return images, result.Error
+}
+
+// Purge удаляет записи изображений, файлы которых уже удалены из хранилища
+func (repo *MediaRepository) Purge(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return repo.Database.DB.Unscoped().Where("id IN ?", ids).Delete(&Image{}).Error
+}
+
+// lockOwner сериализует изменения списка изображений одного владельца
+func lockOwner(tx *gorm.DB, ownerType string, ownerID uint) error {
+	table, ok := ownerTables[ownerType]
+	if !ok {
+		return ErrUnknownOwner
+	}
+	var ids []uint
+	if err := tx.Table(table).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND deleted_at IS NULL", ownerID).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return ErrOwnerNotFound
+	}
+	return nil
+}
+
+func clearPrimary(tx *gorm.DB, ownerType string, ownerID uint) error {
+	return tx.Model(&Image{}).
+		Where("owner_type = ? AND owner_id = ? AND is_primary", ownerType, ownerID).
+		Update("is_primary", false).Error
+}
+
+// ImportLegacy переносит в media_images ссылки владельцев, которые ещё не
+// загружали изображения через media, чтобы первая загрузка их не затёрла
+func ImportLegacy(database *gorm.DB) error {
+	for ownerType, table := range ownerTables {
+		var ids []uint
+		if err := database.Table(table).
+			Where("deleted_at IS NULL AND NOT EXISTS (SELECT 1 FROM media_images m WHERE m.owner_type = ? AND m.owner_id = "+table+".id)", ownerType).
+			Order("id").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		for _, id := range ids {
+			err := database.Transaction(func(tx *gorm.DB) error {
+				if err := lockOwner(tx, ownerType, id); err != nil {
+					return err
+				}
+				var count int64
+				if err := tx.Model(&Image{}).Where("owner_type = ? AND owner_id = ?", ownerType, id).Count(&count).Error; err != nil || count > 0 {
+					return err
+				}
+				_, err := importLegacy(tx, ownerType, id)
+				return err
+			})
+			if err != nil && !errors.Is(err, ErrOwnerNotFound) {
+				return fmt.Errorf("import %s %d images: %w", ownerType, id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// importLegacy создаёт записи для ссылок, сохранённых в полях владельца до media_images.
+// Файлов этих изображений нет в хранилище, поэтому StorageKey пустой, а размеры неизвестны.
+// Возвращает число созданных записей
+func importLegacy(tx *gorm.DB, ownerType string, ownerID uint) (int, error) {
+	urls, err := ownerURLs(tx, ownerType, ownerID)
+	if err != nil {
+		return 0, err
+	}
+	if len(urls) > MaxImagesPerOwner {
+		urls = urls[:MaxImagesPerOwner]
+	}
+	for i, url := range urls {
+		image := Image{
+			OwnerType: ownerType,
+			OwnerID:   ownerID,
+			URL:       url,
+			Position:  i,
+			IsPrimary: i == 0,
+			MimeType:  mimeByExtension(url),
+		}
+		if err := tx.Create(&image).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(urls), nil
+}
+
+// ownerURLs читает ссылки из полей владельца: логотип бренда или JSON-массив images
+func ownerURLs(tx *gorm.DB, ownerType string, ownerID uint) ([]string, error) {
+	column := "images"
+	if ownerType == OwnerBrand {
+		column = "logo"
+	}
+	var values []*string
+	if err := tx.Table(ownerTables[ownerType]).Where("id = ?", ownerID).Pluck(column, &values).Error; err != nil {
+		return nil, err
+	}
+	if len(values) == 0 || values[0] == nil || *values[0] == "" {
+		return nil, nil
+	}
+	if ownerType == OwnerBrand {
+		return []string{*values[0]}, nil
+	}
+	var urls []string
+	if err := json.Unmarshal([]byte(*values[0]), &urls); err != nil {
+		return nil, nil // не массив ссылок — переносить нечего
+	}
+	result := urls[:0]
+	for _, url := range urls {
+		if url != "" && len(url) <= 512 {
+			result = append(result, url)
+		}
+	}
+	return result, nil
+}
+
+// syncOwner переносит список изображений в поля владельца, которые читают
+// клиенты admin-proto: JSON-массив ссылок (основное первым) или ссылку на логотип
+func syncOwner(tx *gorm.DB, ownerType string, ownerID uint) error {
+	var urls []string
+	if err := tx.Model(&Image{}).
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Order("is_primary DESC, position, id").
+		Pluck("url", &urls).Error; err != nil {
+		return err
+	}
+
+	table := ownerTables[ownerType]
+	if ownerType == OwnerBrand {
+		logo := ""
+		if len(urls) > 0 {
+			logo = urls[0]
+		}
+		return tx.Table(table).Where("id = ?", ownerID).Update("logo", logo).Error
+	}
+
+	if urls == nil {
+		urls = []string{}
+	}
+	encoded, err := json.Marshal(urls)
+	if err != nil {
+		return err
+	}
+	return tx.Table(table).Where("id = ?", ownerID).Update("images", string(encoded)).Error
+}
+
+func sameSet(existing, ids []uint) bool {
+	if len(existing) != len(ids) {
+		return false
+	}
+	seen := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		seen[id] = true
+	}
+	for _, id := range ids {
+		if !seen[id] {
+			return false
+		}
+		delete(seen, id)
+	}
+	return true
+}
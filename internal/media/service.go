@@ -0,0 +1,279 @@
+package media
+
+import (
+	"admin/pkg/logger"
+	"admin/pkg/storage"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type MediaService struct {
+	MediaRepository *MediaRepository
+	Storage         storage.Storage
+}
+
+func NewMediaService(mediaRepository *MediaRepository, storage storage.Storage) *MediaService {
+	return &MediaService{
+		MediaRepository: mediaRepository,
+		Storage:         storage,
+	}
+}
+
+// UploadImage проверяет файл по содержимому, сохраняет его в хранилище и регистрирует
+// в конце списка изображений владельца
+func (s *MediaService) UploadImage(ctx context.Context, req *UploadImageRequest) (*ImageMessage, error) {
+	if err := checkOwner(req.OwnerType, req.OwnerId); err != nil {
+		logger.Errorf("UploadImage error: %v", err)
+		return nil, err
+	}
+	if len(req.Data) == 0 {
+		logger.Error("UploadImage error: empty file")
+		return nil, status.Error(codes.InvalidArgument, "image data is required")
+	}
+	if len(req.Data) > MaxFileSize {
+		logger.Errorf("UploadImage error: file size %d", len(req.Data))
+		return nil, status.Errorf(codes.InvalidArgument, "image must not exceed %d bytes", MaxFileSize)
+	}
+	if err := checkAlt(req.Alt); err != nil {
+		logger.Errorf("UploadImage error: %v", err)
+		return nil, err
+	}
+
+	probe, err := Probe(req.Data)
+	if err != nil {
+		logger.Errorf("UploadImage error: %v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "%v: jpeg, png, gif and webp are accepted", err)
+	}
+	if probe.Width > MaxDimension || probe.Height > MaxDimension {
+		logger.Errorf("UploadImage error: dimensions %dx%d", probe.Width, probe.Height)
+		return nil, status.Errorf(codes.InvalidArgument, "image must not exceed %dx%d pixels", MaxDimension, MaxDimension)
+	}
+
+	exists, err := s.MediaRepository.OwnerExists(req.OwnerType, uint(req.OwnerId))
+	if err != nil {
+		logger.Errorf("UploadImage error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !exists {
+		logger.Errorf("UploadImage error: %s %d not found", req.OwnerType, req.OwnerId)
+		return nil, status.Errorf(codes.NotFound, "%s %d not found", req.OwnerType, req.OwnerId)
+	}
+
+	key, err := newKey(req.OwnerType, uint(req.OwnerId), extensions[probe.MimeType])
+	if err != nil {
+		logger.Errorf("UploadImage error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err := s.Storage.Put(ctx, key, bytes.NewReader(req.Data)); err != nil {
+		logger.Errorf("UploadImage error: storing %s: %v", key, err)
+		return nil, status.Error(codes.Internal, "failed to store image")
+	}
+
+	image, err := s.MediaRepository.Create(&Image{
+		OwnerType:  req.OwnerType,
+		OwnerID:    uint(req.OwnerId),
+		StorageKey: key,
+		URL:        s.Storage.URL(key),
+		Alt:        req.Alt,
+		IsPrimary:  req.IsPrimary,
+		MimeType:   probe.MimeType,
+		Width:      probe.Width,
+		Height:     probe.Height,
+		Size:       int64(len(req.Data)),
+	})
+	if err != nil {
+		logger.Errorf("UploadImage error: %v", err)
+		if delErr := s.Storage.Delete(ctx, key); delErr != nil {
+			logger.Errorf("UploadImage error: removing %s: %v", key, delErr)
+		}
+		return nil, writeErrorToStatus(err)
+	}
+	return ConvertDBToPayload(image), nil
+}
+
+func (s *MediaService) ListImages(ctx context.Context, req *ListImagesRequest) (*ListImagesResponse, error) {
+	if err := checkOwner(req.OwnerType, req.OwnerId); err != nil {
+		logger.Errorf("ListImages error: %v", err)
+		return nil, err
+	}
+	images, err := s.MediaRepository.ListByOwner(req.OwnerType, uint(req.OwnerId))
+	if err != nil {
+		logger.Errorf("ListImages error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &ListImagesResponse{Images: convertImages(images)}, nil
+}
+
+// UpdateImage меняет alt-текст изображения
+func (s *MediaService) UpdateImage(ctx context.Context, req *UpdateImageRequest) (*ImageMessage, error) {
+	if req.Id == 0 {
+		logger.Error("UpdateImage error: image ID required")
+		return nil, status.Error(codes.InvalidArgument, "image ID required")
+	}
+	if err := checkAlt(req.Alt); err != nil {
+		logger.Errorf("UpdateImage error: %v", err)
+		return nil, err
+	}
+	if _, err := s.MediaRepository.GetByID(uint(req.Id)); err != nil {
+		logger.Errorf("UpdateImage error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	image, err := s.MediaRepository.UpdateAlt(uint(req.Id), req.Alt)
+	if err != nil {
+		logger.Errorf("UpdateImage error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	return ConvertDBToPayload(image), nil
+}
+
+func (s *MediaService) SetPrimaryImage(ctx context.Context, req *SetPrimaryImageRequest) (*ImageMessage, error) {
+	if req.Id == 0 {
+		logger.Error("SetPrimaryImage error: image ID required")
+		return nil, status.Error(codes.InvalidArgument, "image ID required")
+	}
+	image, err := s.MediaRepository.SetPrimary(uint(req.Id))
+	if err != nil {
+		logger.Errorf("SetPrimaryImage error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	return ConvertDBToPayload(image), nil
+}
+
+func (s *MediaService) ReorderImages(ctx context.Context, req *ReorderImagesRequest) (*ListImagesResponse, error) {
+	if err := checkOwner(req.OwnerType, req.OwnerId); err != nil {
+		logger.Errorf("ReorderImages error: %v", err)
+		return nil, err
+	}
+	ids := make([]uint, len(req.ImageIds))
+	for i, id := range req.ImageIds {
+		ids[i] = uint(id)
+	}
+	images, err := s.MediaRepository.Reorder(req.OwnerType, uint(req.OwnerId), ids)
+	if err != nil {
+		logger.Errorf("ReorderImages error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	return &ListImagesResponse{Images: convertImages(images)}, nil
+}
+
+// DeleteImage удаляет изображение и его файл. Если файл удалить не удалось,
+// запись всё равно удаляется — файл останется в хранилище до ручной очистки
+func (s *MediaService) DeleteImage(ctx context.Context, req *DeleteImageRequest) (*pb.Error, error) {
+	if req.Id == 0 {
+		logger.Error("DeleteImage error: image ID required")
+		return nil, status.Error(codes.InvalidArgument, "image ID required")
+	}
+	image, err := s.MediaRepository.Delete(uint(req.Id))
+	if err != nil {
+		logger.Errorf("DeleteImage error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	if image.StorageKey == "" {
+		return &pb.Error{}, nil // перенесённая ссылка, файла в хранилище нет
+	}
+	if err := s.Storage.Delete(ctx, image.StorageKey); err != nil {
+		logger.Errorf("DeleteImage error: removing %s: %v", image.StorageKey, err)
+	}
+	return &pb.Error{}, nil
+}
+
+// CleanupOrphans удаляет файлы и записи изображений удалённых владельцев.
+// Запись удаляется только после файла, поэтому неудачная попытка повторится
+func (s *MediaService) CleanupOrphans(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	orphans, err := s.MediaRepository.FindOrphans(deletedBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+	removed := make([]uint, 0, len(orphans))
+	for _, image := range orphans {
+		if image.StorageKey == "" {
+			removed = append(removed, image.ID)
+			continue
+		}
+		if err := s.Storage.Delete(ctx, image.StorageKey); err != nil {
+			logger.Errorf("CleanupOrphans error: removing %s: %v", image.StorageKey, err)
+			continue
+		}
+		removed = append(removed, image.ID)
+	}
+	if err := s.MediaRepository.Purge(removed); err != nil {
+		return 0, err
+	}
+	return len(removed), nil
+}
+
+func checkOwner(ownerType string, ownerID uint32) error {
+	if !ValidOwner(ownerType) {
+		return status.Errorf(codes.InvalidArgument, "owner_type must be %s, %s or %s", OwnerProduct, OwnerVariant, OwnerBrand)
+	}
+	if ownerID == 0 {
+		return status.Error(codes.InvalidArgument, "owner ID required")
+	}
+	return nil
+}
+
+func checkAlt(alt string) error {
+	if utf8.RuneCountInString(alt) > MaxAltLength {
+		return status.Errorf(codes.InvalidArgument, "alt must not exceed %d characters", MaxAltLength)
+	}
+	return nil
+}
+
+func writeErrorToStatus(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "image not found")
+	case errors.Is(err, ErrOwnerNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrTooManyImages):
+		return status.Errorf(codes.FailedPrecondition, "owner already has %d images", MaxImagesPerOwner)
+	case errors.Is(err, ErrInvalidOrder), errors.Is(err, ErrUnknownOwner):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// newKey строит ключ файла со случайным именем, чтобы ссылки не угадывались
+// и новая версия изображения не попадала в чужой кэш
+func newKey(ownerType string, ownerID uint, ext string) (string, error) {
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%d/%s%s", ownerType, ownerID, hex.EncodeToString(name), ext), nil
+}
+
+func convertImages(images []Image) []*ImageMessage {
+	result := make([]*ImageMessage, 0, len(images))
+	for i := range images {
+		result = append(result, ConvertDBToPayload(&images[i]))
+	}
+	return result
+}
+
+func ConvertDBToPayload(image *Image) *ImageMessage {
+	return &ImageMessage{
+		Id:        uint32(image.ID),
+		OwnerType: image.OwnerType,
+		OwnerId:   uint32(image.OwnerID),
+		Url:       image.URL,
+		Alt:       image.Alt,
+		Position:  int32(image.Position),
+		IsPrimary: image.IsPrimary,
+		MimeType:  image.MimeType,
+		Width:     int32(image.Width),
+		Height:    int32(image.Height),
+		Size:      image.Size,
+	}
+}
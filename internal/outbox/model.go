@@ -0,0 +1,43 @@
+package outbox
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultTopic     = "catalog.events"
+	DefaultBatchSize = 100
+	// опубликованные события хранятся неделю для разбора инцидентов
+	DefaultRetention = 7 * 24 * time.Hour
+)
+
+// Kafka-заголовки публикуемых событий
+const (
+	HeaderEventID   = "event-id"
+	HeaderEventType = "event-type"
+)
+
+// Event — доменное событие, записанное в той же транзакции, что и изменение.
+// Relay публикует неопубликованные события в порядке ID, так что порядок
+// событий одного агрегата сохраняется
+type Event struct {
+	ID            uint       `gorm:"primarykey;index:idx_outbox_events_pending,where:published_at IS NULL" json:"id"`
+	AggregateType string     `gorm:"type:varchar(50);not null" json:"aggregate_type"`
+	AggregateID   uint       `gorm:"not null" json:"aggregate_id"`
+	Type          string     `gorm:"type:varchar(100);not null" json:"type"`
+	Payload       string     `gorm:"type:jsonb;not null" json:"payload"`
+	OccurredAt    time.Time  `gorm:"not null" json:"occurred_at"`
+	PublishedAt   *time.Time `gorm:"index:idx_outbox_events_published,where:published_at IS NOT NULL" json:"published_at"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `gorm:"type:text" json:"last_error"`
+}
+
+func (Event) TableName() string {
+	return "outbox_events"
+}
+
+// Key — ключ сообщения Kafka: события одного агрегата попадают в одну партицию
+func (e *Event) Key() string {
+	return e.AggregateType + ":" + strconv.FormatUint(uint64(e.AggregateID), 10)
+}
@@ -0,0 +1,25 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Add записывает событие в outbox. tx должна быть транзакцией, в которой меняется
+// агрегат: событие появится тогда и только тогда, когда изменение зафиксировано
+func Add(tx *gorm.DB, aggregateType string, aggregateID uint, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("outbox: encode %s: %w", eventType, err)
+	}
+	return tx.Create(&Event{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Type:          eventType,
+		Payload:       string(payload),
+		OccurredAt:    time.Now(),
+	}).Error
+}
@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Publisher отправляет партию событий; nil означает, что все события приняты брокером
+type Publisher interface {
+	Publish(ctx context.Context, events []Event) error
+}
+
+// Envelope — тело сообщения в Kafka
+type Envelope struct {
+	ID            uint            `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   uint            `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// KafkaPublisher пишет события в один топик. Hash-балансировщик по ключу агрегата
+// держит события агрегата в одной партиции, а синхронная запись с подтверждением
+// всех реплик гарантирует, что опубликованным помечается только принятое брокером
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	if topic == "" {
+		topic = DefaultTopic
+	}
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, events []Event) error {
+	messages := make([]kafka.Message, len(events))
+	for i := range events {
+		e := &events[i]
+		value, err := json.Marshal(Envelope{
+			ID:            e.ID,
+			Type:          e.Type,
+			AggregateType: e.AggregateType,
+			AggregateID:   e.AggregateID,
+			OccurredAt:    e.OccurredAt,
+			Data:          json.RawMessage(e.Payload),
+		})
+		if err != nil {
+			return err
+		}
+		messages[i] = kafka.Message{
+			Key:   []byte(e.Key()),
+			Value: value,
+			Headers: []kafka.Header{
+				{Key: HeaderEventID, Value: []byte(strconv.FormatUint(uint64(e.ID), 10))},
+				{Key: HeaderEventType, Value: []byte(e.Type)},
+			},
+		}
+	}
+	return p.writer.WriteMessages(ctx, messages...)
+}
+
+// Close дожидается отправки буферизованных сообщений и закрывает соединения
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
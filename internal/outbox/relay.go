@@ -0,0 +1,61 @@
+package outbox
+
+import (
+	"admin/pkg/logger"
+	"context"
+	"time"
+)
+
+// Relay переносит события из outbox в Kafka
+type Relay struct {
+	*OutboxRepository
+	Publisher Publisher
+	BatchSize int
+	Retention time.Duration
+}
+
+func NewRelay(repo *OutboxRepository, publisher Publisher) *Relay {
+	return &Relay{
+		OutboxRepository: repo,
+		Publisher:        publisher,
+		BatchSize:        DefaultBatchSize,
+		Retention:        DefaultRetention,
+	}
+}
+
+// Run публикует накопившиеся события каждые interval и раз в час чистит опубликованные
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+
+	for {
+		r.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-purge.C:
+			if n, err := r.Purge(time.Now().Add(-r.Retention)); err != nil {
+				logger.Errorf("Outbox: purge failed: %v", err)
+			} else if n > 0 {
+				logger.Infof("Outbox: purged %d published events", n)
+			}
+		}
+	}
+}
+
+// drain публикует партии, пока они заполняются целиком
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := r.Dispatch(ctx, r.BatchSize, r.Publisher.Publish)
+		if err != nil {
+			logger.Errorf("Outbox: publish failed: %v", err)
+			return
+		}
+		if n < r.BatchSize {
+			return
+		}
+	}
+}
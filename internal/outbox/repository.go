@@ -0,0 +1,85 @@
+package outbox
+
+import (
+	"admin/pkg/db"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// relayLockKey — ключ advisory-блокировки: публикует только один экземпляр relay,
+// иначе события одного агрегата могли бы уйти в Kafka не по порядку
+const relayLockKey = 7_346_501
+
+type OutboxRepository struct {
+	Database *db.Db
+}
+
+func NewOutboxRepository(database *db.Db) *OutboxRepository {
+	return &OutboxRepository{
+		Database: database,
+	}
+}
+
+// Dispatch передаёт publish до limit самых старых неопубликованных событий и помечает
+// их опубликованными, если publish вернул nil. При ошибке партия остаётся неопубликованной
+// целиком и будет отправлена снова, поэтому порядок не нарушается, а дубликаты возможны.
+// Возвращает число опубликованных событий; 0 — если публикует другой экземпляр
+func (repo *OutboxRepository) Dispatch(ctx context.Context, limit int, publish func(ctx context.Context, events []Event) error) (int, error) {
+	var published int
+	var publishErr error
+	err := repo.Database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", relayLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var events []Event
+		if err := tx.Where("published_at IS NULL").Order("id").Limit(limit).Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+		}
+
+		if publishErr = publish(ctx, events); publishErr != nil {
+			return tx.Model(&Event{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+				"attempts":   gorm.Expr("attempts + 1"),
+				"last_error": publishErr.Error(),
+			}).Error
+		}
+		published = len(events)
+		return tx.Model(&Event{}).Where("id IN ?", ids).Update("published_at", time.Now()).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, publishErr
+}
+
+// Pending возвращает число неопубликованных событий и время самого старого из них
+func (repo *OutboxRepository) Pending() (int64, *time.Time, error) {
+	var stats struct {
+		Count  int64
+		Oldest *time.Time
+	}
+	err := repo.Database.DB.Model(&Event{}).
+		Select("COUNT(*) AS count, MIN(occurred_at) AS oldest").
+		Where("published_at IS NULL").
+		Scan(&stats).Error
+	return stats.Count, stats.Oldest, err
+}
+
+// Purge удаляет события, опубликованные раньше before
+func (repo *OutboxRepository) Purge(before time.Time) (int64, error) {
+	result := repo.Database.DB.Where("published_at < ?", before).Delete(&Event{})
+	return result.RowsAffected, result.Error
+}
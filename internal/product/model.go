@@ -3,7 +3,11 @@ package product
 import (
 	"admin/internal/brand"
 	"admin/internal/category"
+	"admin/internal/productVariant"
+	"admin/pkg/imaging"
+	"admin/pkg/money"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -12,25 +16,44 @@ type Product struct {
 
 	Name        string `gorm:"type:varchar(255);not null" json:"name"`
 	Description string `gorm:"type:text" json:"description"`
-	Price       int64  `gorm:"not null" json:"price"`
-	Discount    int64  `gorm:"default:0" json:"discount"`
 	IsActive    bool   `gorm:"default:true" json:"is_active"`
 
+	// Цена и скидка товара с активными вариантами — это цена варианта с минимальной
+	// итоговой ценой, её поддерживает productVariant.SyncProductPrice.
+	// Discount — абсолютная сумма, не процент
+	Price    decimal.Decimal `gorm:"type:decimal(12,2);not null" json:"price"`
+	Discount decimal.Decimal `gorm:"type:decimal(12,2);default:0" json:"discount"`
+
+	// Средняя оценка одобренных отзывов в сотых (435 = 4.35) и их число, обновляет пакет review
+	Rating       uint `gorm:"not null;default:0" json:"rating"`
+	ReviewsCount uint `gorm:"not null;default:0" json:"reviews_count"`
+
 	// 🔹 Внешние ключи
-	CategoryID uint              `gorm:"not null" json:"category_id"`
-	Category   category.Category `gorm:"foreignKey:CategoryID;constraint:OnDelete:CASCADE"`
+	// Удаление бренда/категории с товарами запрещено на уровне БД,
+	// каскад и переназначение выполняет catalog.CatalogRepository явно
+	CategoryID uint              `gorm:"not null;index" json:"category_id"`
+	Category   category.Category `gorm:"foreignKey:CategoryID;constraint:OnDelete:RESTRICT"`
+
+	BrandID uint        `gorm:"not null;index" json:"brand_id"`
+	Brand   brand.Brand `gorm:"foreignKey:BrandID;constraint:OnDelete:RESTRICT"`
 
-	BrandID uint        `gorm:"not null" json:"brand_id"`
-	Brand   brand.Brand `gorm:"foreignKey:BrandID;constraint:OnDelete:CASCADE"`
+	Variants []productVariant.ProductVariant `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE" json:"-"`
 
 	// 🔹 Дополнительные данные
-	Images   string `gorm:"type:json" json:"images"`            // Храним ссылки на изображения JSON-массивом
+	Images   string `gorm:"type:json" json:"images"`            // JSON-массив ссылок, заполняется из media_images
 	VideoURL string `gorm:"type:varchar(255)" json:"video_url"` // Видеообзор
+
+	// Уменьшенные копии изображений по ссылке на исходное, заполняет rendition.Worker
+	Renditions map[string]imaging.Renditions `gorm:"type:jsonb;serializer:json" json:"renditions"`
+}
+
+// FinalPrice — цена с учётом скидки
+func (p *Product) FinalPrice() decimal.Decimal {
+	return money.FinalPrice(p.Price, p.Discount)
 }
 
 //на поле discount,IsActive нужно делать слушателей (productVariants)
 
 //для продукт варианта-//VendorCode   string  `gorm:"type:varchar(100);unique;not null"json:"vendor_code"`//артикул
-//ReviewsCount int    `gorm:"default:0" json:"reviews_count"`     // Кол-во отзывов
 //ProductGender gender = 12;
 //ProductSeason season = 13;
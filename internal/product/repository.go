@@ -1,7 +1,12 @@
 package product
 
 import (
+	"admin/internal/outbox"
+	"admin/internal/productVariant"
 	"admin/pkg/db"
+	"admin/pkg/event"
+
+	"gorm.io/gorm"
 )
 
 type ProductRepository struct {
@@ -15,11 +20,25 @@ func NewProductRepository(database *db.Db) *ProductRepository {
 }
 
 func (repo *ProductRepository) Create(product *Product) (*Product, error) {
-	result := repo.Database.DB.Create(product)
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		return addEvent(tx, event.ProductCreated, product)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (repo *ProductRepository) GetByID(id uint) (*Product, error) {
+	var product Product
+	result := repo.Database.DB.First(&product, id)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	return product, nil
+	return &product, nil
 }
 
 func (repo *ProductRepository) GetByCategoryID(id uint) ([]Product, error) { //limit 20
@@ -69,19 +88,113 @@ func (repo *ProductRepository) GetFeaturedProducts(amount uint, random, includeD
 	return products, result.Error
 }
 
+// Update обновляет товар. Цена товара с активными вариантами сразу пересчитывается по вариантам.
+// Images не меняется: после создания товара им управляет media
 func (repo *ProductRepository) Update(product *Product) (*Product, error) {
-	result := repo.Database.DB.Model(&Product{}).Where("id = ?", product.ID).Updates(product)
-	if result.Error != nil {
-		return nil, result.Error
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Product{}).Where("id = ?", product.ID).Updates(updateColumns(product)).Error; err != nil {
+			return err
+		}
+		if err := productVariant.SyncProductPrice(tx, product.ID); err != nil {
+			return err
+		}
+		var synced Product
+		if err := tx.First(&synced, product.ID).Error; err != nil {
+			return err
+		}
+		product.Price, product.Discount = synced.Price, synced.Discount
+		return addEvent(tx, event.ProductUpdated, &synced)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return product, nil
 }
 
+// Delete удаляет продукт вместе с его вариантами, чтобы не оставлять
+// активные варианты у мягко удалённого продукта
 func (repo *ProductRepository) Delete(id uint, unscoped bool) error {
-	query := repo.Database.DB.Where("id = ?", id)
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if unscoped {
+			tx = tx.Unscoped().Session(&gorm.Session{})
+		}
+		if err := tx.Where("product_id = ?", id).Delete(&productVariant.ProductVariant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", id).Delete(&Product{}).Error; err != nil {
+			return err
+		}
+		return AddDeletedEvents(tx, []uint{id}, unscoped)
+	})
+}
 
-	if unscoped {
-		return query.Unscoped().Delete(&Product{}).Error
+// AddUpdatedEvents пишет в outbox product.updated для товаров, изменённых в обход
+// репозитория (например, при переназначении бренда или категории). tx — транзакция изменения
+func AddUpdatedEvents(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var products []Product
+	if err := tx.Unscoped().Where("id IN ?", ids).Order("id").Find(&products).Error; err != nil {
+		return err
 	}
-	return query.Delete(&Product{}).Error
+	for i := range products {
+		if err := addEvent(tx, event.ProductUpdated, &products[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddDeletedEvents пишет в outbox product.deleted; hard — товары удалены без возможности восстановления
+func AddDeletedEvents(tx *gorm.DB, ids []uint, hard bool) error {
+	for _, id := range ids {
+		data := event.ProductDeletedData{ProductID: id, Hard: hard}
+		if err := outbox.Add(tx, event.AggregateProduct, id, event.ProductDeleted, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateColumns — поля частичного обновления: пустые строки, нулевые ID и нулевые цены
+// не меняют значение. Список явный, потому что Updates(struct) пишет нулевой
+// decimal.Decimal — он не считается нулевым значением
+func updateColumns(product *Product) map[string]interface{} {
+	columns := make(map[string]interface{})
+	if product.Name != "" {
+		columns["name"] = product.Name
+	}
+	if product.Description != "" {
+		columns["description"] = product.Description
+	}
+	if !product.Price.IsZero() {
+		columns["price"] = product.Price
+	}
+	if !product.Discount.IsZero() {
+		columns["discount"] = product.Discount
+	}
+	if product.IsActive {
+		columns["is_active"] = true
+	}
+	if product.CategoryID != 0 {
+		columns["category_id"] = product.CategoryID
+	}
+	if product.BrandID != 0 {
+		columns["brand_id"] = product.BrandID
+	}
+	if product.VideoURL != "" {
+		columns["video_url"] = product.VideoURL
+	}
+	return columns
+}
+
+func addEvent(tx *gorm.DB, eventType string, product *Product) error {
+	return outbox.Add(tx, event.AggregateProduct, product.ID, eventType, event.ProductData{
+		ProductID:  product.ID,
+		Name:       product.Name,
+		CategoryID: product.CategoryID,
+		BrandID:    product.BrandID,
+		IsActive:   product.IsActive,
+	})
 }
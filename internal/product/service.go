@@ -4,7 +4,10 @@ import (
 	"admin/internal/brand"
 	"admin/internal/category"
 	"admin/pkg/logger"
+	"admin/pkg/money"
+	"admin/pkg/validation"
 	"context"
+	"errors"
 	"time"
 
 	pb "github.com/ShopOnGO/admin-proto/pkg/service"
@@ -18,15 +21,28 @@ import (
 type ProductServiceServer struct {
 	pb.UnimplementedProductServiceServer
 	ProductRepository *ProductRepository
+	validator         *ProductValidator
 }
 
-func NewProductServiceServer(productRepository *ProductRepository) *ProductServiceServer {
-	return &ProductServiceServer{ProductRepository: productRepository}
+// NewProductServiceServer создаёт сервис; nil validator — только DefaultRules
+func NewProductServiceServer(productRepository *ProductRepository, validator *ProductValidator) *ProductServiceServer {
+	if validator == nil {
+		validator = NewProductValidator()
+	}
+	return &ProductServiceServer{
+		ProductRepository: productRepository,
+		validator:         validator,
+	}
 }
 
 func (s *ProductServiceServer) CreateProduct(ctx context.Context, req *pb.Product) (*pb.ProductResponse, error) {
+	dbProduct := ConvertProtoToDB(req)
+	if err := s.validator.Validate(dbProduct); err != nil {
+		logger.Errorf("Product validation error: %v", err)
+		return nil, validation.ToStatus(err)
+	}
 
-	product, err := s.ProductRepository.Create(ConvertProtoToDB(req))
+	product, err := s.ProductRepository.Create(dbProduct)
 
 	if err != nil {
 		logger.Errorf("Failed to create product: %v", err)
@@ -92,8 +108,23 @@ func (s *ProductServiceServer) GetFeaturedProducts(ctx context.Context, req *pb.
 }
 
 func (s *ProductServiceServer) UpdateProduct(ctx context.Context, req *pb.Product) (*pb.ProductResponse, error) {
+	dbProduct := ConvertProtoToDB(req)
+	current, err := s.ProductRepository.GetByID(dbProduct.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Errorf("Failed to update product: %v", err)
+		return nil, status.Error(codes.NotFound, "product not found")
+	}
+	if err != nil {
+		logger.Errorf("Failed to update product: %v", err)
+		return nil, status.Error(codes.Internal, "failed to load product")
+	}
+	// обновление частичное, поэтому проверяется товар с применёнными изменениями
+	if err := s.validator.Validate(MergeForValidation(current, dbProduct)); err != nil {
+		logger.Errorf("Product validation error: %v", err)
+		return nil, validation.ToStatus(err)
+	}
 
-	product, err := s.ProductRepository.Update(ConvertProtoToDB(req))
+	product, err := s.ProductRepository.Update(dbProduct)
 	if err != nil {
 		logger.Errorf("Failed to update product: %v", err)
 		return nil, status.Errorf(codes.Internal, err.Error())
@@ -130,8 +161,8 @@ func ConvertDBToProto(product *Product) *pb.Product {
 		},
 		Name:        product.Name,
 		Description: product.Description,
-		Price:       product.Price,
-		Discount:    product.Discount,
+		Price:       money.DecimalToCents(product.Price),
+		Discount:    money.DecimalToCents(product.Discount),
 		IsActive:    product.IsActive,
 		CategoryId:  uint32(product.CategoryID),
 		BrandId:     uint32(product.BrandID),
@@ -190,8 +221,8 @@ func ConvertProtoToDB(protoProduct *pb.Product) *Product {
 		Model:       model,
 		Name:        protoProduct.Name,
 		Description: protoProduct.Description,
-		Price:       protoProduct.Price,
-		Discount:    protoProduct.Discount,
+		Price:       money.CentsToDecimal(protoProduct.Price),
+		Discount:    money.CentsToDecimal(protoProduct.Discount),
 		IsActive:    protoProduct.IsActive,
 		CategoryID:  uint(protoProduct.CategoryId),
 		BrandID:     uint(protoProduct.BrandId),
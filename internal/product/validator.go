@@ -0,0 +1,86 @@
+package product
+
+import (
+	"admin/pkg/validation"
+	"encoding/json"
+)
+
+// DefaultRules — правила, которые проверяются для любого товара
+func DefaultRules() []validation.Rule[*Product] {
+	return []validation.Rule[*Product]{
+		validation.Required("name", func(p *Product) string { return p.Name }),
+		validation.MaxLength("name", 255, func(p *Product) string { return p.Name }),
+		validation.Check("category_id", "is required", func(p *Product) bool { return p.CategoryID != 0 }),
+		validation.Check("brand_id", "is required", func(p *Product) bool { return p.BrandID != 0 }),
+		validation.Check("price", "must be positive", func(p *Product) bool { return p.Price.IsPositive() }),
+		validation.Check("discount", "must be non-negative and less than price", func(p *Product) bool {
+			return !p.Discount.IsNegative() && p.Discount.LessThan(p.Price)
+		}),
+		validation.Check("images", "must be a JSON array of URLs", func(p *Product) bool {
+			_, err := parseImages(p.Images)
+			return err == nil
+		}),
+		validation.ImageURLs("images", func(p *Product) []string {
+			images, _ := parseImages(p.Images)
+			return images
+		}),
+		validation.URL("video_url", func(p *Product) string { return p.VideoURL }),
+	}
+}
+
+type ProductValidator struct {
+	rules *validation.Validator[*Product]
+}
+
+// NewProductValidator создаёт валидатор с DefaultRules и дополнительными правилами
+func NewProductValidator(extra ...validation.Rule[*Product]) *ProductValidator {
+	return &ProductValidator{
+		rules: validation.New(DefaultRules()...).Add(extra...),
+	}
+}
+
+// Validate возвращает validation.Errors со всеми нарушениями.
+// Валидатор создаётся только через NewProductValidator и дальше не меняется,
+// поэтому его можно вызывать из параллельных RPC
+func (v *ProductValidator) Validate(product *Product) error {
+	return v.rules.Validate(product)
+}
+
+// MergeForValidation накладывает заданные поля частичного обновления на текущий товар,
+// так же как ProductRepository.Update: пустые строки, нулевые ID и нулевые цены не меняют поле.
+// Images не обновляется: после создания товара им управляет media
+func MergeForValidation(current, update *Product) *Product {
+	merged := *current
+	if update.Name != "" {
+		merged.Name = update.Name
+	}
+	if update.Description != "" {
+		merged.Description = update.Description
+	}
+	if !update.Price.IsZero() {
+		merged.Price = update.Price
+	}
+	if !update.Discount.IsZero() {
+		merged.Discount = update.Discount
+	}
+	if update.CategoryID != 0 {
+		merged.CategoryID = update.CategoryID
+	}
+	if update.BrandID != 0 {
+		merged.BrandID = update.BrandID
+	}
+	if update.VideoURL != "" {
+		merged.VideoURL = update.VideoURL
+	}
+	return &merged
+}
+
+// Images хранится JSON-массивом в строке, пустая строка — нет изображений
+func parseImages(images string) ([]string, error) {
+	if images == "" {
+		return nil, nil
+	}
+	var urls []string
+	err := json.Unmarshal([]byte(images), &urls)
+	return urls, err
+}
@@ -0,0 +1,107 @@
+package productVariant
+
+import (
+	"admin/pkg/barcode"
+	"admin/pkg/logger"
+	"context"
+	"errors"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BarcodeService выдаёт внутренние EAN-13 вариантам и рисует штрих-коды для этикеток
+type BarcodeService struct {
+	ProductVariantRepository *ProductVariantRepository
+	CompanyPrefix            string // префикс компании GS1, пустой — генерация выключена
+}
+
+func NewBarcodeService(productVariantRepository *ProductVariantRepository, companyPrefix string) *BarcodeService {
+	return &BarcodeService{
+		ProductVariantRepository: productVariantRepository,
+		CompanyPrefix:            companyPrefix,
+	}
+}
+
+// GenerateBarcodes выдаёт EAN-13 вариантам без штрих-кода
+func (s *BarcodeService) GenerateBarcodes(ctx context.Context, req *GenerateBarcodesRequest) (*GenerateBarcodesResponse, error) {
+	if s.CompanyPrefix == "" {
+		logger.Error("GenerateBarcodes error: GS1 company prefix is not configured")
+		return nil, status.Error(codes.FailedPrecondition, "GS1 company prefix is not configured")
+	}
+	if _, err := barcode.EAN13(s.CompanyPrefix, 0); err != nil {
+		logger.Errorf("GenerateBarcodes error: %v", err)
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	ids := make([]uint, 0, len(req.VariantIds))
+	for _, id := range req.VariantIds {
+		ids = append(ids, uint(id))
+	}
+	if len(ids) == 0 {
+		if req.ProductId == 0 {
+			logger.Error("GenerateBarcodes error: variant IDs or product ID is required")
+			return nil, status.Error(codes.InvalidArgument, "variant IDs or product ID is required")
+		}
+		var err error
+		if ids, err = s.ProductVariantRepository.GetIDsWithoutBarcode(uint(req.ProductId)); err != nil {
+			logger.Errorf("GenerateBarcodes error: %v", err)
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	if len(ids) == 0 {
+		return &GenerateBarcodesResponse{}, nil
+	}
+
+	assigned, err := s.ProductVariantRepository.AssignBarcodes(s.CompanyPrefix, ids, func(reference uint64) (string, error) {
+		return barcode.EAN13(s.CompanyPrefix, reference)
+	})
+	if err != nil {
+		logger.Errorf("GenerateBarcodes error: %v", err)
+		if errors.Is(err, barcode.ErrPrefixFull) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &GenerateBarcodesResponse{Assigned: make([]*AssignedBarcode, 0, len(assigned))}
+	for id, code := range assigned {
+		resp.Assigned = append(resp.Assigned, &AssignedBarcode{VariantId: uint32(id), Barcode: code})
+	}
+	sort.Slice(resp.Assigned, func(i, j int) bool { return resp.Assigned[i].VariantId < resp.Assigned[j].VariantId })
+	logger.Infof("Assigned %d barcodes with prefix %s", len(resp.Assigned), s.CompanyPrefix)
+	return resp, nil
+}
+
+// RenderBarcode рисует штрих-код варианта или переданный код в PNG или SVG
+func (s *BarcodeService) RenderBarcode(ctx context.Context, req *RenderBarcodeRequest) (*RenderBarcodeResponse, error) {
+	code := req.Code
+	if req.VariantId != 0 {
+		variant, err := s.ProductVariantRepository.GetByID(uint(req.VariantId), false)
+		if err != nil || variant == nil {
+			logger.Errorf("RenderBarcode error: variant %d not found: %v", req.VariantId, err)
+			return nil, status.Error(codes.NotFound, "variant not found")
+		}
+		code = variant.Barcode
+	}
+	if code == "" {
+		logger.Error("RenderBarcode error: no barcode to render")
+		return nil, status.Error(codes.FailedPrecondition, "variant has no barcode")
+	}
+
+	format := req.Format
+	if format == "" {
+		format = barcode.FormatPNG
+	}
+	data, err := barcode.Render(code, format, barcode.Options{Scale: int(req.Scale), Height: int(req.Height)})
+	if err != nil {
+		logger.Errorf("RenderBarcode error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &RenderBarcodeResponse{
+		Code:        code,
+		ContentType: barcode.ContentType(format),
+		Data:        data,
+	}, nil
+}
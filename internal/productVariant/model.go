@@ -1,30 +1,96 @@
 package productVariant
 
 import (
+	"admin/pkg/imaging"
+	"admin/pkg/money"
+	"time"
+
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
+// ProductVariant — вариант товара. Sizes, Colors, Material и Dimensions остаются ради admin-proto,
+// остальные характеристики задаются схемой атрибутов категории (пакет attribute).
+// Rating — средняя оценка одобренных отзывов в сотых (435 = 4.35), её обновляет пакет review
 type ProductVariant struct {
 	gorm.Model
 	ProductID     uint            `gorm:"index;not null"`                // на всякий
 	SKU           string          `gorm:"type:varchar(100);uniqueIndex"` // Уникальный артикул
-	Price         decimal.Decimal `gorm:"type:decimal(10,2);not null"`
-	Discount      decimal.Decimal `gorm:"type:decimal(8,2);default:0"`
-	ReservedStock uint32          `gorm:"not null"` // бронь (пока оплатишь типа)
+	Price         decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	Discount      decimal.Decimal `gorm:"type:decimal(12,2);default:0"` // абсолютная сумма скидки, не процент
+	ReservedStock uint32          `gorm:"not null"`                     // бронь (пока оплатишь типа)
 	Rating        uint            `gorm:"default:0"`
 	Sizes         []uint32        `gorm:"type:json"`         // Храним размеры как JSON-массив
 	Colors        []string        `gorm:"type:json"`         // Храним цвета как JSON-массив
 	Stock         uint32          `gorm:"default:0"`         // Общий остаток на складе
 	Material      string          `gorm:"type:varchar(200)"` // Материал изготовления
 	//Weight          uint      `gorm:"default:0"`                           // Вес в граммах
-	Barcode    string   `gorm:"type:varchar(50)"` // Штрих-код
+	// Штрих-код, уникален среди активных вариантов
+	Barcode    string   `gorm:"type:varchar(50);uniqueIndex:idx_product_variants_barcode_active,where:barcode <> '' AND is_active = true AND deleted_at IS NULL"`
 	IsActive   bool     `gorm:"default:true"`     // Активен ли вариант
-	Images     []string `gorm:"type:json"`        // Массив URL изображений
+	Images     []string `gorm:"type:json"`        // Массив URL изображений, заполняется из media_images
 	MinOrder   uint     `gorm:"default:1"`        // Минимальный заказ
 	Dimensions string   `gorm:"type:varchar(50)"` // Габариты (например "20x30x5 см")
+
+	// Уменьшенные копии изображений по ссылке на исходное, заполняет rendition.Worker
+	Renditions map[string]imaging.Renditions `gorm:"type:jsonb;serializer:json"`
 }
 
 func (ProductVariant) TableName() string {
 	return "product_variants"
 }
+
+// FinalPrice — цена с учётом скидки
+func (v *ProductVariant) FinalPrice() decimal.Decimal {
+	return money.FinalPrice(v.Price, v.Discount)
+}
+
+const (
+	PriceSourceManual    = "manual"
+	PriceSourceScheduled = "scheduled"
+)
+
+const (
+	ScheduleStatusPending   = "pending"
+	ScheduleStatusApplied   = "applied"
+	ScheduleStatusCancelled = "cancelled"
+)
+
+// PriceHistory — цена варианта, действующая с EffectiveFrom до следующей записи
+type PriceHistory struct {
+	gorm.Model
+	VariantID     uint            `gorm:"not null;index:idx_price_histories_variant_from"`
+	Price         decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	Discount      decimal.Decimal `gorm:"type:decimal(12,2);default:0"`
+	EffectiveFrom time.Time       `gorm:"not null;index:idx_price_histories_variant_from"`
+	Source        string          `gorm:"type:varchar(20);not null"` // manual или scheduled
+}
+
+func (PriceHistory) TableName() string {
+	return "variant_price_histories"
+}
+
+// ScheduledPriceChange — изменение цены, которое PriceScheduler применит в EffectiveAt
+type ScheduledPriceChange struct {
+	gorm.Model
+	VariantID   uint            `gorm:"not null;index"`
+	Price       decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	Discount    decimal.Decimal `gorm:"type:decimal(12,2);default:0"`
+	EffectiveAt time.Time       `gorm:"not null;index:idx_scheduled_price_changes_due"`
+	Status      string          `gorm:"type:varchar(20);not null;default:'pending';index:idx_scheduled_price_changes_due"`
+	AppliedAt   *time.Time
+}
+
+func (ScheduledPriceChange) TableName() string {
+	return "variant_scheduled_price_changes"
+}
+
+// BarcodeSequence — следующий свободный номер товара для префикса компании GS1
+type BarcodeSequence struct {
+	Prefix        string `gorm:"type:varchar(11);primaryKey"`
+	NextReference uint64 `gorm:"not null;default:0"`
+}
+
+func (BarcodeSequence) TableName() string {
+	return "barcode_sequences"
+}
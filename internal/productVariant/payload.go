@@ -0,0 +1,106 @@
+package productVariant
+
+import pb "github.com/ShopOnGO/admin-proto/pkg/service"
+
+// Сообщения для истории и расписания цен. Когда RPC появятся в admin-proto,
+// эти типы заменяются сгенерированными.
+
+// Цены передаются десятичной строкой ("1299.90"), как decimal.Decimal в БД,
+// чтобы не терять точность на переводе в копейки
+
+type SchedulePriceChangeRequest struct {
+	VariantId   uint32 `json:"variant_id"`
+	Price       string `json:"price"`
+	Discount    string `json:"discount"`     // пустая строка — без скидки
+	EffectiveAt int64  `json:"effective_at"` // unix-время, должно быть в будущем
+}
+
+type ScheduledPriceChangeMessage struct {
+	Id          uint32 `json:"id"`
+	VariantId   uint32 `json:"variant_id"`
+	Price       string `json:"price"`
+	Discount    string `json:"discount"`
+	EffectiveAt int64  `json:"effective_at"`
+	Status      string `json:"status"`
+	AppliedAt   int64  `json:"applied_at"` // 0, если ещё не применено
+}
+
+type CancelPriceChangeRequest struct {
+	Id uint32 `json:"id"`
+}
+
+type ListScheduledPriceChangesRequest struct {
+	VariantId   uint32 `json:"variant_id"`
+	PendingOnly bool   `json:"pending_only"`
+}
+
+type ListScheduledPriceChangesResponse struct {
+	Changes []*ScheduledPriceChangeMessage `json:"changes"`
+}
+
+type GetPriceHistoryRequest struct {
+	VariantId uint32 `json:"variant_id"`
+	Since     int64  `json:"since"` // unix-время, 0 — вся история
+}
+
+type PriceHistoryEntry struct {
+	Price         string `json:"price"`
+	Discount      string `json:"discount"`
+	EffectiveFrom int64  `json:"effective_from"`
+	Source        string `json:"source"`
+}
+
+type GetPriceHistoryResponse struct {
+	Entries []*PriceHistoryEntry `json:"entries"`
+}
+
+type GetLowestPriceRequest struct {
+	VariantId uint32 `json:"variant_id"`
+	Days      uint32 `json:"days"` // по умолчанию 30 (Omnibus)
+}
+
+type GetLowestPriceResponse struct {
+	VariantId     uint32 `json:"variant_id"`
+	Price         string `json:"price"` // цена с учётом скидки
+	EffectiveFrom int64  `json:"effective_from"`
+	Since         int64  `json:"since"`
+}
+
+type GenerateBarcodesRequest struct {
+	VariantIds []uint32 `json:"variant_ids"`
+	ProductId  uint32   `json:"product_id"` // все варианты товара без штрих-кода, если variant_ids пуст
+}
+
+type AssignedBarcode struct {
+	VariantId uint32 `json:"variant_id"`
+	Barcode   string `json:"barcode"`
+}
+
+type GenerateBarcodesResponse struct {
+	Assigned []*AssignedBarcode `json:"assigned"` // варианты, у которых штрих-код уже был, не попадают
+}
+
+type RenderBarcodeRequest struct {
+	VariantId uint32 `json:"variant_id"`
+	Code      string `json:"code"`   // используется, если variant_id не задан
+	Format    string `json:"format"` // png (по умолчанию) или svg
+	Scale     uint32 `json:"scale"`  // ширина модуля в пикселях
+	Height    uint32 `json:"height"` // высота полос в пикселях
+}
+
+type RenderBarcodeResponse struct {
+	Code        string `json:"code"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// VariantAttributeMessage — значения атрибута нового варианта, см. attribute.AttributeValueMessage
+type VariantAttributeMessage struct {
+	Code   string   `json:"code"`
+	Values []string `json:"values"` // несколько значений только у multi_enum
+}
+
+type CreateVariantWithAttributesRequest struct {
+	Variant    *pb.ProductVariant         `json:"variant"`
+	Attributes []*VariantAttributeMessage `json:"attributes"` // проверяются по схеме категории товара
+}
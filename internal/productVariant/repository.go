@@ -1,12 +1,19 @@
 package productVariant
 
 import (
+	"admin/internal/outbox"
 	"admin/pkg/db"
+	"admin/pkg/event"
+	"admin/pkg/money"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductVariantRepository struct {
@@ -19,15 +26,66 @@ func NewProductVariantRepository(database *db.Db) *ProductVariantRepository {
 	}
 }
 
-// Create создает новый вариант продукта
+// Create создает новый вариант продукта и первую запись истории цен
 func (repo *ProductVariantRepository) Create(variant *ProductVariant) (*ProductVariant, error) {
-	result := repo.Database.DB.Create(variant)
-	if result.Error != nil {
-		return nil, result.Error
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(variant).Error; err != nil {
+			return err
+		}
+		if err := recordPrice(tx, variant.ID, variant.Price, variant.Discount, variant.CreatedAt, PriceSourceManual); err != nil {
+			return err
+		}
+		if err := addCreatedEvents(tx, variant); err != nil {
+			return err
+		}
+		return SyncProductPrice(tx, variant.ProductID)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return variant, nil
 }
 
+// CreateBatch создаёт варианты одного товара в одной транзакции.
+// afterCreate вызывается для каждого варианта внутри транзакции и может дописать связанные данные
+func (repo *ProductVariantRepository) CreateBatch(productID uint, variants []*ProductVariant, afterCreate func(tx *gorm.DB, index int, variant *ProductVariant) error) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		for i, variant := range variants {
+			variant.ProductID = productID
+			if err := tx.Create(variant).Error; err != nil {
+				return err
+			}
+			if err := recordPrice(tx, variant.ID, variant.Price, variant.Discount, variant.CreatedAt, PriceSourceManual); err != nil {
+				return err
+			}
+			if err := addCreatedEvents(tx, variant); err != nil {
+				return err
+			}
+			if afterCreate != nil {
+				if err := afterCreate(tx, i, variant); err != nil {
+					return err
+				}
+			}
+		}
+		return SyncProductPrice(tx, productID)
+	})
+}
+
+// SKUsExist возвращает артикулы из списка, которые уже заняты, включая мягко удалённые варианты
+func (repo *ProductVariantRepository) SKUsExist(skus []string) (map[string]bool, error) {
+	var taken []string
+	if err := repo.Database.DB.Unscoped().Model(&ProductVariant{}).
+		Where("sku IN ?", skus).
+		Pluck("sku", &taken).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]bool, len(taken))
+	for _, sku := range taken {
+		result[sku] = true
+	}
+	return result, nil
+}
+
 func (repo *ProductVariantRepository) GetBySKU(sku string, unscoped bool) (*ProductVariant, error) {
 	var variant ProductVariant
 	db := repo.Database.DB
@@ -64,43 +122,27 @@ func (repo *ProductVariantRepository) GetByProductID(productID uint, includeInac
 
 // UpdateStock обновляет общий остаток на складе
 func (repo *ProductVariantRepository) UpdateStock(variantID uint, newStock uint32) error {
-	return repo.Database.DB.Model(&ProductVariant{}).
-		Where("id = ?", variantID).
-		Update("stock", newStock).Error
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		return SetStock(tx, variantID, newStock)
+	})
 }
 
-// ReserveStock резервирует указанное количество товара
+// ReserveStock резервирует указанное количество товара. Как и консьюмер заказов,
+// меняет бронь через AdjustStock под блокировкой строки
 func (repo *ProductVariantRepository) ReserveStock(variantID uint, quantity uint32) error {
 	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
-		var variant ProductVariant
-		if err := tx.First(&variant, variantID).Error; err != nil {
-			return err
+		err := AdjustStock(tx, variantID, 0, int64(quantity))
+		if errors.Is(err, ErrStockConflict) {
+			return ErrInsufficientStock
 		}
-
-		if variant.Stock < variant.ReservedStock+quantity {
-			return errors.New("not enough stock")
-		}
-
-		return tx.Model(&variant).
-			Update("reserved_stock", variant.ReservedStock+quantity).Error
+		return err
 	})
 }
 
-// ReleaseStock освобождает зарезервированный товар
+// ReleaseStock освобождает зарезервированный товар; снять больше брони нельзя (ErrStockConflict)
 func (repo *ProductVariantRepository) ReleaseStock(variantID uint, quantity uint32) error {
 	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
-		var variant ProductVariant
-		if err := tx.First(&variant, variantID).Error; err != nil {
-			return err
-		}
-
-		newReserved := variant.ReservedStock - quantity
-		if newReserved < 0 {
-			return errors.New("invalid quantity to release")
-		}
-
-		return tx.Model(&variant).
-			Update("reserved_stock", newReserved).Error
+		return AdjustStock(tx, variantID, 0, -int64(quantity))
 	})
 }
 
@@ -130,6 +172,13 @@ func (repo *ProductVariantRepository) GetByID(id uint, unscoped bool) (*ProductV
 	return &variant, result.Error
 }
 
+// GetByIDs возвращает варианты по списку ID, отсутствующие пропускаются
+func (repo *ProductVariantRepository) GetByIDs(ids []uint) ([]ProductVariant, error) {
+	var variants []ProductVariant
+	result := repo.Database.DB.Where("id IN ?", ids).Order("id").Find(&variants)
+	return variants, result.Error
+}
+
 // GetByBarcode поиск по штрихкоду
 func (repo *ProductVariantRepository) GetByBarcode(barcode string, unscoped bool) (*ProductVariant, error) {
 	var variant ProductVariant
@@ -139,7 +188,8 @@ func (repo *ProductVariantRepository) GetByBarcode(barcode string, unscoped bool
 		db = db.Unscoped()
 	}
 
-	result := db.Where("barcode = ?", barcode).First(&variant)
+	// активный вариант с этим кодом единственный, среди неактивных берём самый новый
+	result := db.Where("barcode = ?", barcode).Order("is_active DESC, id DESC").First(&variant)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, nil
@@ -147,37 +197,82 @@ func (repo *ProductVariantRepository) GetByBarcode(barcode string, unscoped bool
 	return &variant, result.Error
 }
 
-// Update обновляет вариант продукта
+// Update обновляет вариант продукта. Изменение цены или скидки пишется в историю цен.
+// Images не меняется: после создания варианта ими управляет media. Stock и ReservedStock тоже
+// не меняются: их параллельно двигают заказы, остатки меняются только через AdjustStock и SetStock.
+// В возвращаемом варианте они заполнены значениями из БД
 func (repo *ProductVariantRepository) Update(variant *ProductVariant) (*ProductVariant, error) {
-	result := repo.Database.DB.Model(&ProductVariant{}).
-		Select("price", "discount", "reserved_stock", "stock", "material", "barcode", "is_active", "images", "min_order", "dimensions", "updated_at").
-		Where("id = ?", variant.ID).
-		Updates(map[string]interface{}{
-			"price":          variant.Price,
-			"discount":       variant.Discount,
-			"reserved_stock": variant.ReservedStock,
-			"stock":          variant.Stock,
-			"material":       variant.Material,
-			"barcode":        variant.Barcode,
-			"is_active":      variant.IsActive,
-			"images":         variant.Images,
-			"min_order":      variant.MinOrder,
-			"dimensions":     variant.Dimensions,
-			"updated_at":     time.Now(),
-		})
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var current ProductVariant
+		if err := tx.Select("id", "product_id", "price", "discount", "is_active", "stock", "reserved_stock").First(&current, variant.ID).Error; err != nil {
+			return err
+		}
 
-	if result.Error != nil {
-		return nil, result.Error
+		now := time.Now()
+		result := tx.Model(&ProductVariant{}).
+			Select("price", "discount", "material", "barcode", "is_active", "min_order", "dimensions", "updated_at").
+			Where("id = ?", variant.ID).
+			Updates(map[string]interface{}{
+				"price":      variant.Price,
+				"discount":   variant.Discount,
+				"material":   variant.Material,
+				"barcode":    variant.Barcode,
+				"is_active":  variant.IsActive,
+				"min_order":  variant.MinOrder,
+				"dimensions": variant.Dimensions,
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		variant.Stock, variant.ReservedStock = current.Stock, current.ReservedStock
+
+		priceChanged := !current.Price.Equal(variant.Price) || !current.Discount.Equal(variant.Discount)
+		if !priceChanged && current.IsActive == variant.IsActive {
+			return nil
+		}
+		// смена одной активности меняет цену товара, но не цену варианта: события нет
+		if priceChanged {
+			if err := recordPrice(tx, variant.ID, variant.Price, variant.Discount, now, PriceSourceManual); err != nil {
+				return err
+			}
+			if err := addPriceEvent(tx, &current, variant.Price, variant.Discount, PriceSourceManual); err != nil {
+				return err
+			}
+		}
+		return SyncProductPrice(tx, current.ProductID)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return variant, nil
 }
 
 // SoftDelete мягкое удаление
 func (repo *ProductVariantRepository) SoftDelete(id uint) error {
-	return repo.Database.DB.Delete(&ProductVariant{}, id).Error
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var productID uint
+		if err := tx.Model(&ProductVariant{}).Where("id = ?", id).Pluck("product_id", &productID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&ProductVariant{}, id).Error; err != nil {
+			return err
+		}
+		return SyncProductPrice(tx, productID)
+	})
 }
 func (repo *ProductVariantRepository) HardDelete(id uint) error {
-	if err := repo.Database.DB.Unscoped().Delete(&ProductVariant{}, id).Error; err != nil {
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var productID uint
+		if err := tx.Unscoped().Model(&ProductVariant{}).Where("id = ?", id).Pluck("product_id", &productID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&ProductVariant{}, id).Error; err != nil {
+			return err
+		}
+		return SyncProductPrice(tx, productID)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete variant: %w", err)
 	}
 
@@ -226,12 +321,382 @@ func (repo *ProductVariantRepository) GetByFilters(filters map[string]interface{
 func (repo *ProductVariantRepository) BulkUpdateStock(variantStocks map[uint]uint32) error {
 	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
 		for variantID, stock := range variantStocks {
+			if err := SetStock(tx, variantID, stock); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SchedulePriceChange планирует смену цены варианта на момент effectiveAt
+func (repo *ProductVariantRepository) SchedulePriceChange(change *ScheduledPriceChange) (*ScheduledPriceChange, error) {
+	change.Status = ScheduleStatusPending
+	if err := repo.Database.DB.First(&ProductVariant{}, change.VariantID).Error; err != nil {
+		return nil, err
+	}
+	if err := repo.Database.DB.Create(change).Error; err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// CancelScheduledPriceChange отменяет ещё не применённое изменение цены
+func (repo *ProductVariantRepository) CancelScheduledPriceChange(id uint) error {
+	result := repo.Database.DB.Model(&ScheduledPriceChange{}).
+		Where("id = ? AND status = ?", id, ScheduleStatusPending).
+		Update("status", ScheduleStatusCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetScheduledPriceChanges возвращает запланированные изменения цены варианта
+func (repo *ProductVariantRepository) GetScheduledPriceChanges(variantID uint, pendingOnly bool) ([]ScheduledPriceChange, error) {
+	var changes []ScheduledPriceChange
+	query := repo.Database.DB.Where("variant_id = ?", variantID)
+	if pendingOnly {
+		query = query.Where("status = ?", ScheduleStatusPending)
+	}
+	result := query.Order("effective_at").Find(&changes)
+	return changes, result.Error
+}
+
+// ApplyDuePriceChanges применяет изменения цен, время которых наступило.
+// FOR UPDATE SKIP LOCKED позволяет запускать несколько экземпляров сервиса без двойного применения
+func (repo *ProductVariantRepository) ApplyDuePriceChanges(now time.Time, limit int) (int, error) {
+	applied := 0
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		// изменения удалённых вариантов не применяются и не попадают в историю цен
+		if err := tx.Model(&ScheduledPriceChange{}).
+			Where("status = ? AND effective_at <= ? AND NOT "+liveVariant, ScheduleStatusPending, now).
+			Update("status", ScheduleStatusCancelled).Error; err != nil {
+			return err
+		}
+
+		var changes []ScheduledPriceChange
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND effective_at <= ? AND "+liveVariant, ScheduleStatusPending, now).
+			Order("effective_at, id").
+			Limit(limit).
+			Find(&changes).Error; err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			var current ProductVariant
+			if err := tx.Select("id", "product_id", "price", "discount").First(&current, change.VariantID).Error; err != nil {
+				return err
+			}
 			if err := tx.Model(&ProductVariant{}).
-				Where("id = ?", variantID).
-				Update("stock", stock).Error; err != nil {
+				Where("id = ?", change.VariantID).
+				Updates(map[string]interface{}{
+					"price":      change.Price,
+					"discount":   change.Discount,
+					"updated_at": now,
+				}).Error; err != nil {
+				return err
+			}
+			if err := recordPrice(tx, change.VariantID, change.Price, change.Discount, change.EffectiveAt, PriceSourceScheduled); err != nil {
+				return err
+			}
+			if err := addPriceEvent(tx, &current, change.Price, change.Discount, PriceSourceScheduled); err != nil {
+				return err
+			}
+			if err := SyncProductPrice(tx, current.ProductID); err != nil {
 				return err
 			}
+			if err := tx.Model(&ScheduledPriceChange{}).
+				Where("id = ?", change.ID).
+				Updates(map[string]interface{}{
+					"status":     ScheduleStatusApplied,
+					"applied_at": now,
+				}).Error; err != nil {
+				return err
+			}
+			applied++
 		}
 		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
+	return applied, nil
+}
+
+// liveVariant — условие «вариант не удалён» для таблиц с колонкой variant_id
+const liveVariant = "EXISTS (SELECT 1 FROM product_variants v WHERE v.id = variant_id AND v.deleted_at IS NULL)"
+
+// GetPriceHistory возвращает историю цен варианта начиная с since, от новых к старым.
+// У удалённого варианта истории нет
+func (repo *ProductVariantRepository) GetPriceHistory(variantID uint, since time.Time) ([]PriceHistory, error) {
+	var history []PriceHistory
+	result := repo.Database.DB.
+		Where("variant_id = ? AND effective_from >= ? AND "+liveVariant, variantID, since).
+		Order("effective_from DESC, id DESC").
+		Find(&history)
+	return history, result.Error
+}
+
+// GetLowestPriceSince возвращает минимальную цену, действовавшую с момента since.
+// Учитывается и цена, установленная до since, но ещё действовавшая в начале периода.
+// Для удалённого варианта возвращается gorm.ErrRecordNotFound
+func (repo *ProductVariantRepository) GetLowestPriceSince(variantID uint, since time.Time) (*PriceHistory, error) {
+	var candidates []PriceHistory
+	if err := repo.Database.DB.
+		Where("variant_id = ? AND effective_from >= ? AND "+liveVariant, variantID, since).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	var before PriceHistory
+	result := repo.Database.DB.
+		Where("variant_id = ? AND effective_from < ? AND "+liveVariant, variantID, since).
+		Order("effective_from DESC, id DESC").
+		Limit(1).
+		Find(&before)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		candidates = append(candidates, before)
+	}
+	if len(candidates) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	lowest := candidates[0]
+	for _, c := range candidates[1:] {
+		if money.FinalPrice(c.Price, c.Discount).LessThan(money.FinalPrice(lowest.Price, lowest.Discount)) {
+			lowest = c
+		}
+	}
+	return &lowest, nil
+}
+
+func recordPrice(tx *gorm.DB, variantID uint, price, discount decimal.Decimal, effectiveFrom time.Time, source string) error {
+	return tx.Create(&PriceHistory{
+		VariantID:     variantID,
+		Price:         price,
+		Discount:      discount,
+		EffectiveFrom: effectiveFrom,
+		Source:        source,
+	}).Error
+}
+
+// SyncProductPrice выставляет товару цену и скидку активного варианта с минимальной итоговой ценой.
+// Товар без активных вариантов сохраняет свою цену
+func SyncProductPrice(tx *gorm.DB, productID uint) error {
+	if productID == 0 {
+		return nil
+	}
+	return tx.Exec(`UPDATE products SET price = v.price, discount = v.discount, updated_at = ?
+		FROM (
+			SELECT price, discount FROM product_variants
+			WHERE product_id = ? AND is_active = true AND deleted_at IS NULL
+			ORDER BY GREATEST(price - discount, 0), price
+			LIMIT 1
+		) v
+		WHERE products.id = ?`, time.Now(), productID, productID).Error
+}
+
+// LockForStock находит вариант по артикулу, а если артикул пуст или не найден — по штрих-коду
+// активного варианта, и блокирует его строку до конца транзакции. nil — вариант не найден
+func LockForStock(tx *gorm.DB, sku, barcode string) (*ProductVariant, error) {
+	lookups := []struct{ where, value string }{
+		{"sku = ?", sku},
+		{"barcode = ? AND is_active = true", barcode},
+	}
+	for _, lookup := range lookups {
+		if lookup.value == "" {
+			continue
+		}
+		var variant ProductVariant
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "product_id", "sku", "barcode", "stock", "reserved_stock").
+			Where(lookup.where, lookup.value).
+			Limit(1).
+			Find(&variant)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected > 0 {
+			return &variant, nil
+		}
+	}
+	return nil, nil
+}
+
+// ErrStockConflict — изменение увело бы остаток или бронь ниже нуля либо бронь выше остатка
+var ErrStockConflict = errors.New("stock change would leave stock or reservation inconsistent")
+
+// AdjustStock меняет остаток и бронь варианта на stockDelta и reservedDelta и пишет
+// variant.stock_changed. Строка блокируется до конца транзакции tx; при нарушении
+// инварианта (0 <= бронь <= остаток) возвращается ErrStockConflict.
+// Снимок WMS может оставить остаток меньше брони: такое состояние не мешает
+// изменениям, которые не увеличивают превышение брони над остатком (списание, снятие брони)
+func AdjustStock(tx *gorm.DB, variantID uint, stockDelta, reservedDelta int64) error {
+	var variant ProductVariant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "product_id", "stock", "reserved_stock").
+		First(&variant, variantID).Error; err != nil {
+		return err
+	}
+	stock := int64(variant.Stock) + stockDelta
+	reserved := int64(variant.ReservedStock) + reservedDelta
+	if stock < 0 || reserved < 0 || stock > math.MaxUint32 {
+		return ErrStockConflict
+	}
+	if reserved > stock && reservedDelta > stockDelta {
+		return ErrStockConflict
+	}
+	if err := tx.Model(&ProductVariant{}).Where("id = ?", variantID).Updates(map[string]interface{}{
+		"stock":          stock,
+		"reserved_stock": reserved,
+	}).Error; err != nil {
+		return err
+	}
+	variant.Stock, variant.ReservedStock = uint32(stock), uint32(reserved)
+	return addStockEvent(tx, &variant)
+}
+
+// SetStock выставляет общий остаток и пишет variant.stock_changed, если он изменился.
+// tx — транзакция, в которой меняется остаток
+func SetStock(tx *gorm.DB, variantID uint, stock uint32) error {
+	var variant ProductVariant
+	if err := tx.Select("id", "product_id", "stock", "reserved_stock").First(&variant, variantID).Error; err != nil {
+		return err
+	}
+	if variant.Stock == stock {
+		return nil
+	}
+	if err := tx.Model(&ProductVariant{}).Where("id = ?", variantID).Update("stock", stock).Error; err != nil {
+		return err
+	}
+	variant.Stock = stock
+	return addStockEvent(tx, &variant)
+}
+
+func addCreatedEvents(tx *gorm.DB, variant *ProductVariant) error {
+	if err := addPriceEvent(tx, &ProductVariant{Model: variant.Model, ProductID: variant.ProductID}, variant.Price, variant.Discount, PriceSourceManual); err != nil {
+		return err
+	}
+	return addStockEvent(tx, variant)
+}
+
+// addPriceEvent пишет в outbox variant.price_changed; current — вариант с прежней ценой.
+// Если цена и скидка не изменились, события нет
+func addPriceEvent(tx *gorm.DB, current *ProductVariant, price, discount decimal.Decimal, source string) error {
+	if current.Price.Equal(price) && current.Discount.Equal(discount) {
+		return nil
+	}
+	return outbox.Add(tx, event.AggregateVariant, current.ID, event.VariantPriceChanged, event.PriceChangedData{
+		VariantID:   current.ID,
+		ProductID:   current.ProductID,
+		OldPrice:    current.Price,
+		OldDiscount: current.Discount,
+		Price:       price,
+		Discount:    discount,
+		Source:      source,
+	})
+}
+
+func addStockEvent(tx *gorm.DB, variant *ProductVariant) error {
+	return outbox.Add(tx, event.AggregateVariant, variant.ID, event.VariantStockChanged, event.StockChangedData{
+		VariantID:     variant.ID,
+		ProductID:     variant.ProductID,
+		Stock:         variant.Stock,
+		ReservedStock: variant.ReservedStock,
+	})
+}
+
+// ErrBarcodeTaken — штрих-код уже есть у другого активного варианта
+var ErrBarcodeTaken = errors.New("barcode is already used by another active variant")
+
+// BarcodeInUse проверяет, занят ли штрих-код активным вариантом, кроме excludeID
+func (repo *ProductVariantRepository) BarcodeInUse(barcode string, excludeID uint) (bool, error) {
+	if barcode == "" {
+		return false, nil
+	}
+	var count int64
+	err := repo.Database.DB.Model(&ProductVariant{}).
+		Where("barcode = ? AND is_active = true AND id <> ?", barcode, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetIDsWithoutBarcode возвращает варианты товара без штрих-кода
+func (repo *ProductVariantRepository) GetIDsWithoutBarcode(productID uint) ([]uint, error) {
+	var ids []uint
+	err := repo.Database.DB.Model(&ProductVariant{}).
+		Where("product_id = ? AND (barcode IS NULL OR barcode = '')", productID).
+		Order("id").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// AssignBarcodes выдаёт вариантам без штрих-кода коды, которые вернёт generate по очередному номеру.
+// Счётчик номеров блокируется на время транзакции, коды, уже встречающиеся в БД, пропускаются
+func (repo *ProductVariantRepository) AssignBarcodes(prefix string, variantIDs []uint, generate func(reference uint64) (string, error)) (map[uint]string, error) {
+	assigned := make(map[uint]string, len(variantIDs))
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&BarcodeSequence{Prefix: prefix}).Error; err != nil {
+			return err
+		}
+		var sequence BarcodeSequence
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&sequence, "prefix = ?", prefix).Error; err != nil {
+			return err
+		}
+
+		var variants []ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND (barcode IS NULL OR barcode = '')", variantIDs).
+			Order("id").
+			Find(&variants).Error; err != nil {
+			return err
+		}
+
+		for _, v := range variants {
+			for {
+				code, err := generate(sequence.NextReference)
+				if err != nil {
+					return err
+				}
+				sequence.NextReference++
+
+				var count int64
+				if err := tx.Unscoped().Model(&ProductVariant{}).Where("barcode = ?", code).Count(&count).Error; err != nil {
+					return err
+				}
+				if count > 0 { // код введён вручную раньше
+					continue
+				}
+				if err := tx.Model(&ProductVariant{}).Where("id = ?", v.ID).Update("barcode", code).Error; err != nil {
+					return err
+				}
+				assigned[v.ID] = code
+				break
+			}
+		}
+
+		return tx.Model(&BarcodeSequence{}).
+			Where("prefix = ?", prefix).
+			Update("next_reference", sequence.NextReference).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assigned, nil
+}
+
+// IsBarcodeConflict распознаёт нарушение уникального индекса штрих-кодов
+func IsBarcodeConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_product_variants_barcode_active"
 }
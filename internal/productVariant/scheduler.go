@@ -0,0 +1,52 @@
+package productVariant
+
+import (
+	"admin/pkg/logger"
+	"context"
+	"time"
+)
+
+const priceSchedulerBatch = 100
+
+// PriceScheduler периодически применяет запланированные изменения цен
+type PriceScheduler struct {
+	ProductVariantRepository *ProductVariantRepository
+}
+
+func NewPriceScheduler(productVariantRepository *ProductVariantRepository) *PriceScheduler {
+	return &PriceScheduler{
+		ProductVariantRepository: productVariantRepository,
+	}
+}
+
+// Run блокируется до отмены ctx, проверяя расписание раз в interval
+func (s *PriceScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.applyDue()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *PriceScheduler) applyDue() {
+	for {
+		applied, err := s.ProductVariantRepository.ApplyDuePriceChanges(time.Now(), priceSchedulerBatch)
+		if err != nil {
+			logger.Errorf("PriceScheduler error: %v", err)
+			return
+		}
+		if applied > 0 {
+			logger.Infof("PriceScheduler: applied %d price changes", applied)
+		}
+		// неполная пачка — больше применять нечего
+		if applied < priceSchedulerBatch {
+			return
+		}
+	}
+}
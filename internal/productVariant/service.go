@@ -3,12 +3,14 @@ package productVariant
 import (
 	"admin/pkg/logger"
 	"admin/pkg/money"
+	"admin/pkg/validation"
 	"context"
 	"errors"
 	"fmt"
 	"time"
 
 	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+	"github.com/shopspring/decimal"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -19,6 +21,15 @@ type VariantService struct {
 	pb.UnimplementedProductVariantServiceServer
 	ProductVariantRepository *ProductVariantRepository
 	validator                *ProductVariantValidator
+	attributes               VariantAttributes
+}
+
+// VariantAttributes проверяет атрибуты нового варианта по схеме категории товара.
+// Реализуется пакетом attribute, который сам импортирует productVariant
+type VariantAttributes interface {
+	// PrepareVariantValues проверяет значения, включая обязательные атрибуты, и возвращает
+	// функцию, которая сохраняет их в транзакции создания варианта. Ошибки — gRPC status
+	PrepareVariantValues(productID uint, input map[string][]string) (func(tx *gorm.DB, variantID uint) error, error)
 }
 
 var (
@@ -29,31 +40,78 @@ var (
 // 	Validate(variant *ProductVariant) error
 // }
 
-func NewVariantService(productVariantRepository *ProductVariantRepository, validator *ProductVariantValidator) *VariantService {
+func NewVariantService(productVariantRepository *ProductVariantRepository, validator *ProductVariantValidator, attributes VariantAttributes) *VariantService {
+	if validator == nil {
+		validator = NewProductVariantValidator()
+	}
 	return &VariantService{
 		ProductVariantRepository: productVariantRepository,
 		validator:                validator,
+		attributes:               attributes,
 	}
 }
 
+// CreateVariant создаёт вариант без атрибутов: если в схеме категории товара
+// есть обязательные атрибуты, нужен CreateVariantWithAttributes
 func (s *VariantService) CreateVariant(ctx context.Context, req *pb.ProductVariant) (*pb.VariantResponse, error) {
+	return s.createVariant(req, nil)
+}
+
+// CreateVariantWithAttributes создаёт вариант вместе со значениями атрибутов в одной транзакции
+func (s *VariantService) CreateVariantWithAttributes(ctx context.Context, req *CreateVariantWithAttributesRequest) (*pb.VariantResponse, error) {
+	if req.Variant == nil {
+		logger.Error("CreateVariantWithAttributes error: variant is required")
+		return nil, status.Error(codes.InvalidArgument, "variant is required")
+	}
+	input := make(map[string][]string, len(req.Attributes))
+	for _, a := range req.Attributes {
+		input[a.Code] = append(input[a.Code], a.Values...)
+	}
+	return s.createVariant(req.Variant, input)
+}
+
+func (s *VariantService) createVariant(req *pb.ProductVariant, attributes map[string][]string) (*pb.VariantResponse, error) {
 	dbVariant := ConvertProtoToDB(req)
+	dbVariant.Rating = 0 // рейтинг считается только по отзывам
 
 	if err := s.validator.Validate(dbVariant); err != nil {
 		logger.Errorf("Validation error: %v", err)
-		return nil, status.Errorf(codes.Internal, err.Error())
+		return nil, validation.ToStatus(err)
+	}
+
+	if err := s.checkBarcode(dbVariant); err != nil {
+		return nil, err
 	}
 
-	createdVariant, err := s.ProductVariantRepository.Create(dbVariant)
+	var saveAttributes func(tx *gorm.DB, variantID uint) error
+	if s.attributes != nil {
+		save, err := s.attributes.PrepareVariantValues(dbVariant.ProductID, attributes)
+		if err != nil {
+			logger.Errorf("Attribute validation error: %v", err)
+			return nil, err
+		}
+		saveAttributes = save
+	}
+
+	err := s.ProductVariantRepository.CreateBatch(dbVariant.ProductID, []*ProductVariant{dbVariant}, func(tx *gorm.DB, _ int, v *ProductVariant) error {
+		if saveAttributes == nil {
+			return nil
+		}
+		return saveAttributes(tx, v.ID)
+	})
 	if err != nil {
 		wrappedErr := fmt.Errorf("create variant failed: %w", err)
 		logger.Error(wrappedErr)
+		if IsBarcodeConflict(err) {
+			return nil, status.Error(codes.AlreadyExists, ErrBarcodeTaken.Error())
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	return &pb.VariantResponse{Variant: ConvertDBToProto(createdVariant)}, nil
+	return &pb.VariantResponse{Variant: ConvertDBToProto(dbVariant)}, nil
 }
 
+// UpdateVariant обновляет вариант. Stock и ReservedStock из запроса игнорируются: остатки меняются через ManageStock
 func (s *VariantService) UpdateVariant(ctx context.Context, req *pb.ProductVariant) (*pb.VariantResponse, error) {
 	if req.GetModel().Id == 0 {
 		wrappedErr := fmt.Errorf("variant ID is required")
@@ -66,19 +124,44 @@ func (s *VariantService) UpdateVariant(ctx context.Context, req *pb.ProductVaria
 	if err := s.validator.Validate(dbVariant); err != nil {
 		wrappedErr := fmt.Errorf("validation failed: %v", err)
 		logger.Error(wrappedErr)
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, validation.ToStatus(err)
+	}
+
+	if err := s.checkBarcode(dbVariant); err != nil {
+		return nil, err
 	}
 
 	updatedVariant, err := s.ProductVariantRepository.Update(dbVariant)
 	if err != nil {
 		wrappedErr := fmt.Errorf("update failed: %v", err)
 		logger.Error(wrappedErr)
+		if IsBarcodeConflict(err) {
+			return nil, status.Error(codes.AlreadyExists, ErrBarcodeTaken.Error())
+		}
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	return &pb.VariantResponse{Variant: ConvertDBToProto(updatedVariant)}, nil
 }
 
+// checkBarcode заранее проверяет уникальность штрих-кода активного варианта,
+// чтобы вернуть понятную ошибку. Гонку закрывает уникальный индекс
+func (s *VariantService) checkBarcode(variant *ProductVariant) error {
+	if !variant.IsActive || variant.Barcode == "" {
+		return nil
+	}
+	inUse, err := s.ProductVariantRepository.BarcodeInUse(variant.Barcode, variant.ID)
+	if err != nil {
+		logger.Errorf("Barcode check failed: %v", err)
+		return status.Error(codes.Internal, err.Error())
+	}
+	if inUse {
+		logger.Errorf("Barcode %s is already in use", variant.Barcode)
+		return status.Error(codes.AlreadyExists, ErrBarcodeTaken.Error())
+	}
+	return nil
+}
+
 func (s *VariantService) GetVariant(ctx context.Context, req *pb.VariantRequest) (*pb.VariantResponse, error) {
 	switch {
 	case req.GetSku() != "":
@@ -127,8 +210,9 @@ func (s *VariantService) ListVariants(ctx context.Context, req *pb.VariantListRe
 	}
 
 	if req.GetPriceRange() != nil {
-		filters["min_price"] = req.GetPriceRange().GetMin()
-		filters["max_price"] = req.GetPriceRange().GetMax()
+		// в запросе копейки, в БД — decimal
+		filters["min_price"] = money.CentsToDecimal(int64(req.GetPriceRange().GetMin()))
+		filters["max_price"] = money.CentsToDecimal(int64(req.GetPriceRange().GetMax()))
 	}
 
 	variants, err := s.ProductVariantRepository.GetByFilters(filters, int(req.GetLimit()), int(req.GetOffset()))
@@ -173,6 +257,10 @@ func (s *VariantService) reserveStock(req *pb.StockRequest) (*pb.Error, error) {
 
 func (s *VariantService) releaseStock(req *pb.StockRequest) (*pb.Error, error) {
 	if err := s.ProductVariantRepository.ReleaseStock(uint(req.GetVariantId()), uint32(req.GetQuantity())); err != nil {
+		if errors.Is(err, ErrStockConflict) {
+			logger.Errorf("release failed: %v", err)
+			return nil, status.Error(codes.FailedPrecondition, "cannot release more than reserved")
+		}
 		wrappedErr := fmt.Errorf("release failed: %v", err)
 		logger.Error(wrappedErr)
 		return nil, status.Error(codes.Internal, wrappedErr.Error())
@@ -213,6 +301,146 @@ func (s *VariantService) DeleteVariant(ctx context.Context, req *pb.DeleteVarian
 	return &pb.Error{}, nil
 }
 
+// omnibusPeriodDays — период, за который показывается минимальная цена по директиве Omnibus
+const omnibusPeriodDays = 30
+
+// SchedulePriceChange планирует смену цены варианта, её применит PriceScheduler
+func (s *VariantService) SchedulePriceChange(ctx context.Context, req *SchedulePriceChangeRequest) (*ScheduledPriceChangeMessage, error) {
+	if req.VariantId == 0 {
+		logger.Error("SchedulePriceChange error: variant ID is required")
+		return nil, status.Error(codes.InvalidArgument, "variant ID is required")
+	}
+	price, discount, err := parsePrice(req.Price, req.Discount)
+	if err == nil {
+		err = validatePrice(price, discount)
+	}
+	if err != nil {
+		logger.Errorf("SchedulePriceChange error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	effectiveAt := time.Unix(req.EffectiveAt, 0)
+	if !effectiveAt.After(time.Now()) {
+		logger.Error("SchedulePriceChange error: effective time must be in the future")
+		return nil, status.Error(codes.InvalidArgument, "effective time must be in the future")
+	}
+
+	change, err := s.ProductVariantRepository.SchedulePriceChange(&ScheduledPriceChange{
+		VariantID:   uint(req.VariantId),
+		Price:       price,
+		Discount:    discount,
+		EffectiveAt: effectiveAt,
+	})
+	if err != nil {
+		logger.Errorf("SchedulePriceChange error: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "variant not found")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return convertScheduledChangeToPayload(change), nil
+}
+
+// CancelPriceChange отменяет запланированное изменение, если оно ещё не применено
+func (s *VariantService) CancelPriceChange(ctx context.Context, req *CancelPriceChangeRequest) (*pb.Error, error) {
+	if err := s.ProductVariantRepository.CancelScheduledPriceChange(uint(req.Id)); err != nil {
+		logger.Errorf("CancelPriceChange error: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "pending price change not found")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.Error{}, nil
+}
+
+func (s *VariantService) ListScheduledPriceChanges(ctx context.Context, req *ListScheduledPriceChangesRequest) (*ListScheduledPriceChangesResponse, error) {
+	changes, err := s.ProductVariantRepository.GetScheduledPriceChanges(uint(req.VariantId), req.PendingOnly)
+	if err != nil {
+		logger.Errorf("ListScheduledPriceChanges error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &ListScheduledPriceChangesResponse{Changes: make([]*ScheduledPriceChangeMessage, 0, len(changes))}
+	for i := range changes {
+		resp.Changes = append(resp.Changes, convertScheduledChangeToPayload(&changes[i]))
+	}
+	return resp, nil
+}
+
+func (s *VariantService) GetPriceHistory(ctx context.Context, req *GetPriceHistoryRequest) (*GetPriceHistoryResponse, error) {
+	history, err := s.ProductVariantRepository.GetPriceHistory(uint(req.VariantId), time.Unix(req.Since, 0))
+	if err != nil {
+		logger.Errorf("GetPriceHistory error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &GetPriceHistoryResponse{Entries: make([]*PriceHistoryEntry, 0, len(history))}
+	for _, h := range history {
+		resp.Entries = append(resp.Entries, &PriceHistoryEntry{
+			Price:         h.Price.String(),
+			Discount:      h.Discount.String(),
+			EffectiveFrom: h.EffectiveFrom.Unix(),
+			Source:        h.Source,
+		})
+	}
+	return resp, nil
+}
+
+// GetLowestPrice возвращает минимальную цену с учётом скидки за последние Days дней
+func (s *VariantService) GetLowestPrice(ctx context.Context, req *GetLowestPriceRequest) (*GetLowestPriceResponse, error) {
+	if req.VariantId == 0 {
+		logger.Error("GetLowestPrice error: variant ID is required")
+		return nil, status.Error(codes.InvalidArgument, "variant ID is required")
+	}
+	days := req.Days
+	if days == 0 {
+		days = omnibusPeriodDays
+	}
+	since := time.Now().AddDate(0, 0, -int(days))
+
+	lowest, err := s.ProductVariantRepository.GetLowestPriceSince(uint(req.VariantId), since)
+	if err != nil {
+		logger.Errorf("GetLowestPrice error: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "no price history for variant")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &GetLowestPriceResponse{
+		VariantId:     req.VariantId,
+		Price:         money.FinalPrice(lowest.Price, lowest.Discount).String(),
+		EffectiveFrom: lowest.EffectiveFrom.Unix(),
+		Since:         since.Unix(),
+	}, nil
+}
+
+// parsePrice разбирает цену и скидку из десятичных строк; пустая скидка — ноль
+func parsePrice(price, discount string) (decimal.Decimal, decimal.Decimal, error) {
+	p, err := decimal.NewFromString(price)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("price must be a decimal number: %q", price)
+	}
+	d := decimal.Zero
+	if discount != "" {
+		if d, err = decimal.NewFromString(discount); err != nil {
+			return decimal.Zero, decimal.Zero, fmt.Errorf("discount must be a decimal number: %q", discount)
+		}
+	}
+	return p, d, nil
+}
+
+func convertScheduledChangeToPayload(c *ScheduledPriceChange) *ScheduledPriceChangeMessage {
+	msg := &ScheduledPriceChangeMessage{
+		Id:          uint32(c.ID),
+		VariantId:   uint32(c.VariantID),
+		Price:       c.Price.String(),
+		Discount:    c.Discount.String(),
+		EffectiveAt: c.EffectiveAt.Unix(),
+		Status:      c.Status,
+	}
+	if c.AppliedAt != nil {
+		msg.AppliedAt = c.AppliedAt.Unix()
+	}
+	return msg
+}
+
 // Конвертационные функции
 func ConvertDBToProto(v *ProductVariant) *pb.ProductVariant {
 	if v == nil {
@@ -233,8 +461,8 @@ func ConvertDBToProto(v *ProductVariant) *pb.ProductVariant {
 		},
 		ProductId:  uint32(v.ProductID),
 		Sku:        v.SKU,
-		Price:      uint32(money.DecimalToCents(v.Price)),
-		Discount:   uint32(money.DecimalToCents(v.Discount)),
+		Price:      protoCents(v.ID, "price", v.Price),
+		Discount:   protoCents(v.ID, "discount", v.Discount),
 		Stock:      v.Stock,
 		Reserved:   v.ReservedStock,
 		Rating:     uint32(v.Rating),
@@ -289,6 +517,17 @@ func ConvertProtoToDB(p *pb.ProductVariant) *ProductVariant {
 }
 
 // Вспомогательные функции
+
+// protoCents переводит сумму в копейки для uint32-полей admin-proto.
+// Валидатор не пропускает такие цены, но старые записи могут переполнять поле
+func protoCents(variantID uint, field string, d decimal.Decimal) uint32 {
+	cents, ok := money.DecimalToUint32Cents(d)
+	if !ok {
+		logger.Errorf("variant %d: %s %s does not fit uint32 cents, sent as %d", variantID, field, d, cents)
+	}
+	return cents
+}
+
 func safeGetUint32Slice(s []uint32) []uint32 {
 	if s != nil {
 		return s
@@ -1,28 +1,65 @@
 package productVariant
 
 import (
-	"errors"
+	"admin/pkg/money"
+	"admin/pkg/validation"
+	"regexp"
 
 	"github.com/shopspring/decimal"
 )
 
-type ProductVariantValidator struct{}
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$`)
 
-func (v *ProductVariantValidator) Validate(variant *ProductVariant) error {
-	if variant.SKU == "" {
-		return errors.New("SKU is required")
-	}
-	if variant.Price == decimal.Zero {
-		return errors.New("price must be positive")
+// DefaultRules — правила, которые проверяются для любого варианта
+func DefaultRules() []validation.Rule[*ProductVariant] {
+	return []validation.Rule[*ProductVariant]{
+		validation.Required("sku", func(v *ProductVariant) string { return v.SKU }),
+		validation.Pattern("sku", skuPattern, "must be up to 100 latin letters, digits, dots, dashes or underscores",
+			func(v *ProductVariant) string { return v.SKU }),
+		validation.Check("product_id", "is required", func(v *ProductVariant) bool { return v.ProductID != 0 }),
+		validation.Check("price", "must be positive", func(v *ProductVariant) bool { return v.Price.IsPositive() }),
+		validation.Check("price", "exceeds maximum supported value", func(v *ProductVariant) bool {
+			return !v.Price.GreaterThan(money.MaxUint32Cents)
+		}),
+		validation.Check("discount", "must be non-negative and less than price", func(v *ProductVariant) bool {
+			return !v.Discount.IsNegative() && v.Discount.LessThan(v.Price)
+		}),
+		validation.Check("reserved", "must not exceed stock", func(v *ProductVariant) bool { return v.ReservedStock <= v.Stock }),
+		// вариант без остатка допустим, иначе минимальный заказ должен быть выполним.
+		// MinOrder == 0 — не задан, в БД подставится 1
+		validation.Check("min_order", "must not exceed stock", func(v *ProductVariant) bool {
+			return v.Stock == 0 || uint64(v.MinOrder) <= uint64(v.Stock)
+		}),
+		validation.Barcode("barcode", func(v *ProductVariant) string { return v.Barcode }),
+		validation.ImageURLs("images", func(v *ProductVariant) []string { return v.Images }),
 	}
-	if variant.ProductID == 0 {
-		return errors.New("product ID is required")
+}
+
+type ProductVariantValidator struct {
+	rules *validation.Validator[*ProductVariant]
+}
+
+// NewProductVariantValidator создаёт валидатор с DefaultRules и дополнительными правилами
+func NewProductVariantValidator(extra ...validation.Rule[*ProductVariant]) *ProductVariantValidator {
+	return &ProductVariantValidator{
+		rules: validation.New(DefaultRules()...).Add(extra...),
 	}
-	if variant.Stock < 0 {
-		return errors.New("stock cannot be negative")
+}
+
+// Validate возвращает validation.Errors со всеми нарушениями.
+// Валидатор создаётся только через NewProductVariantValidator и дальше не меняется,
+// поэтому его можно вызывать из параллельных RPC
+func (v *ProductVariantValidator) Validate(variant *ProductVariant) error {
+	return v.rules.Validate(variant)
+}
+
+// validatePrice проверяет цену варианта, включая предел uint32-копеек в admin-proto
+func validatePrice(price, discount decimal.Decimal) error {
+	if err := money.ValidatePrice(price, discount); err != nil {
+		return err
 	}
-	if variant.ReservedStock > variant.Stock {
-		return errors.New("reserved stock exceeds available stock")
+	if price.GreaterThan(money.MaxUint32Cents) {
+		return money.ErrPriceTooLarge
 	}
 	return nil
 }
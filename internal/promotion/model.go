@@ -0,0 +1,63 @@
+package promotion
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	ScopeAll      = "all"
+	ScopeCategory = "category"
+	ScopeBrand    = "brand"
+	ScopeProduct  = "product"
+	ScopeVariant  = "variant"
+)
+
+const (
+	DiscountPercentage = "percentage"  // Value — процент от цены (0, 100]
+	DiscountFixed      = "fixed"       // Value — сумма скидки с единицы товара
+	DiscountBuyXGetY   = "buy_x_get_y" // из каждых BuyQuantity+GetQuantity единиц GetQuantity бесплатно
+)
+
+type Promotion struct {
+	gorm.Model
+	Name         string          `gorm:"type:varchar(255);not null" json:"name"`
+	Description  string          `gorm:"type:text" json:"description"`
+	StartsAt     time.Time       `gorm:"not null;index" json:"starts_at"`
+	EndsAt       *time.Time      `gorm:"index" json:"ends_at"` // NULL — бессрочная акция
+	IsActive     bool            `gorm:"not null" json:"is_active"`
+	Priority     int             `gorm:"default:0" json:"priority"`      // больше — применяется раньше
+	Stackable    bool            `gorm:"default:false" json:"stackable"` // суммируется с другими суммируемыми акциями
+	ScopeType    string          `gorm:"type:varchar(20);not null" json:"scope_type"`
+	ScopeIDs     []uint          `gorm:"type:json;serializer:json" json:"scope_ids"` // ID категорий/брендов/товаров/вариантов
+	DiscountType string          `gorm:"type:varchar(20);not null" json:"discount_type"`
+	Value        decimal.Decimal `gorm:"type:decimal(10,2);default:0" json:"value"`
+	BuyQuantity  uint            `gorm:"default:0" json:"buy_quantity"`
+	GetQuantity  uint            `gorm:"default:0" json:"get_quantity"`
+}
+
+// VariantContext — данные варианта, нужные для подбора акций
+type VariantContext struct {
+	VariantID  uint
+	ProductID  uint
+	CategoryID uint
+	BrandID    uint
+	Price      decimal.Decimal
+}
+
+// AppliedPromotion — акция, участвовавшая в итоговой цене
+type AppliedPromotion struct {
+	PromotionID uint
+	Name        string
+	Amount      decimal.Decimal // скидка с единицы товара
+}
+
+// PriceResolution — итоговая цена единицы варианта с учётом акций
+type PriceResolution struct {
+	VariantID  uint
+	BasePrice  decimal.Decimal
+	FinalPrice decimal.Decimal
+	Applied    []AppliedPromotion
+}
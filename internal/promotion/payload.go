@@ -0,0 +1,68 @@
+package promotion
+
+import "google.golang.org/protobuf/types/known/timestamppb"
+
+// Сообщения PromotionService, которых пока нет в admin-proto
+
+type PromotionMessage struct {
+	Id           uint32                 `json:"id"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	StartsAt     *timestamppb.Timestamp `json:"starts_at"`
+	EndsAt       *timestamppb.Timestamp `json:"ends_at"` // nil — бессрочная
+	IsActive     bool                   `json:"is_active"`
+	Priority     int32                  `json:"priority"`
+	Stackable    bool                   `json:"stackable"`
+	ScopeType    string                 `json:"scope_type"`
+	ScopeIds     []uint32               `json:"scope_ids"`
+	DiscountType string                 `json:"discount_type"`
+	Value        string                 `json:"value"` // десятичная строка: процент или сумма скидки
+	BuyQuantity  uint32                 `json:"buy_quantity"`
+	GetQuantity  uint32                 `json:"get_quantity"`
+}
+
+type PromotionResponse struct {
+	Promotion *PromotionMessage `json:"promotion"`
+}
+
+type GetPromotionRequest struct {
+	Id uint32 `json:"id"`
+}
+
+type DeletePromotionRequest struct {
+	Id       uint32 `json:"id"`
+	Unscoped bool   `json:"unscoped"`
+}
+
+type ListPromotionsRequest struct {
+	Limit           uint32 `json:"limit"`
+	Offset          uint32 `json:"offset"`
+	IncludeInactive bool   `json:"include_inactive"`
+	ActiveNow       bool   `json:"active_now"` // только идущие сейчас
+}
+
+type PromotionListResponse struct {
+	Promotions []*PromotionMessage `json:"promotions"`
+}
+
+type ResolvePricesRequest struct {
+	VariantIds []uint32 `json:"variant_ids"`
+	Quantity   uint32   `json:"quantity"` // для buy-X-get-Y, по умолчанию 1
+}
+
+type AppliedPromotionMessage struct {
+	PromotionId uint32 `json:"promotion_id"`
+	Name        string `json:"name"`
+	Amount      int64  `json:"amount"` // скидка с единицы, в копейках
+}
+
+type VariantPrice struct {
+	VariantId  uint32                     `json:"variant_id"`
+	BasePrice  int64                      `json:"base_price"` // в копейках
+	FinalPrice int64                      `json:"final_price"`
+	Applied    []*AppliedPromotionMessage `json:"applied"`
+}
+
+type ResolvePricesResponse struct {
+	Prices []*VariantPrice `json:"prices"`
+}
@@ -0,0 +1,90 @@
+package promotion
+
+import (
+	"admin/internal/productVariant"
+	"admin/pkg/db"
+	"time"
+)
+
+type PromotionRepository struct {
+	Database *db.Db
+}
+
+func NewPromotionRepository(database *db.Db) *PromotionRepository {
+	return &PromotionRepository{
+		Database: database,
+	}
+}
+
+func (repo *PromotionRepository) Create(promotion *Promotion) (*Promotion, error) {
+	result := repo.Database.DB.Create(promotion)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return promotion, nil
+}
+
+func (repo *PromotionRepository) GetByID(id uint) (*Promotion, error) {
+	var promotion Promotion
+	result := repo.Database.DB.First(&promotion, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &promotion, nil
+}
+
+func (repo *PromotionRepository) Update(promotion *Promotion) (*Promotion, error) {
+	result := repo.Database.DB.Save(promotion)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return promotion, nil
+}
+
+func (repo *PromotionRepository) Delete(id uint, unscoped bool) error {
+	query := repo.Database.DB
+	if unscoped {
+		query = query.Unscoped()
+	}
+	return query.Delete(&Promotion{}, id).Error
+}
+
+// List возвращает акции по убыванию даты начала
+func (repo *PromotionRepository) List(limit, offset int, includeInactive bool) ([]Promotion, error) {
+	var promotions []Promotion
+	query := repo.Database.DB.Order("starts_at DESC")
+	if !includeInactive {
+		query = query.Where("is_active = true")
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	result := query.Offset(offset).Find(&promotions)
+	return promotions, result.Error
+}
+
+// GetActivePromotions возвращает акции, идущие в момент now. amount <= 0 — без ограничения
+func (repo *PromotionRepository) GetActivePromotions(now time.Time, amount int) ([]Promotion, error) {
+	var promotions []Promotion
+	query := repo.Database.DB.
+		Where("is_active = true AND starts_at <= ?", now).
+		Where("ends_at IS NULL OR ends_at > ?", now).
+		Order("priority DESC, id")
+	if amount > 0 {
+		query = query.Limit(amount)
+	}
+	result := query.Find(&promotions)
+	return promotions, result.Error
+}
+
+// GetVariantContexts загружает цену, товар, категорию и бренд вариантов для подбора акций.
+// Акции применяются к итоговой цене варианта, то есть после его собственной скидки
+func (repo *PromotionRepository) GetVariantContexts(variantIDs []uint) ([]VariantContext, error) {
+	var contexts []VariantContext
+	result := repo.Database.DB.Model(&productVariant.ProductVariant{}).
+		Select("product_variants.id AS variant_id, product_variants.product_id, products.category_id, products.brand_id, GREATEST(product_variants.price - product_variants.discount, 0) AS price").
+		Joins("JOIN products ON products.id = product_variants.product_id AND products.deleted_at IS NULL").
+		Where("product_variants.id IN ?", variantIDs).
+		Scan(&contexts)
+	return contexts, result.Error
+}
@@ -0,0 +1,123 @@
+package promotion
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// IsRunning — акция включена и now попадает в её расписание
+func (p *Promotion) IsRunning(now time.Time) bool {
+	if !p.IsActive || now.Before(p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || now.Before(*p.EndsAt)
+}
+
+// Matches — акция распространяется на вариант
+func (p *Promotion) Matches(v VariantContext) bool {
+	var target uint
+	switch p.ScopeType {
+	case ScopeAll:
+		return true
+	case ScopeCategory:
+		target = v.CategoryID
+	case ScopeBrand:
+		target = v.BrandID
+	case ScopeProduct:
+		target = v.ProductID
+	case ScopeVariant:
+		target = v.VariantID
+	default:
+		return false
+	}
+	for _, id := range p.ScopeIDs {
+		if id == target {
+			return true
+		}
+	}
+	return false
+}
+
+// UnitDiscount возвращает скидку с единицы товара при цене price и покупке quantity единиц
+func (p *Promotion) UnitDiscount(price decimal.Decimal, quantity uint) decimal.Decimal {
+	var discount decimal.Decimal
+	switch p.DiscountType {
+	case DiscountPercentage:
+		discount = price.Mul(p.Value).Div(hundred)
+	case DiscountFixed:
+		discount = p.Value
+	case DiscountBuyXGetY:
+		group := p.BuyQuantity + p.GetQuantity
+		if group == 0 || quantity < group {
+			return decimal.Zero
+		}
+		free := (quantity / group) * p.GetQuantity
+		// бесплатные единицы распределяются по всем купленным
+		discount = price.Mul(decimal.NewFromInt(int64(free))).Div(decimal.NewFromInt(int64(quantity)))
+	}
+
+	if discount.GreaterThan(price) {
+		discount = price
+	}
+	if discount.IsNegative() {
+		return decimal.Zero
+	}
+	return discount.Round(2)
+}
+
+// ResolvePrice считает цену единицы варианта с учётом акций.
+// Правила суммирования: несуммируемые акции не сочетаются ни с чем, из них берётся самая выгодная;
+// суммируемые применяются последовательно по убыванию приоритета, каждая к уже сниженной цене.
+// Покупатель получает лучший из двух вариантов
+func ResolvePrice(v VariantContext, quantity uint, promotions []Promotion, now time.Time) PriceResolution {
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	candidates := make([]Promotion, 0, len(promotions))
+	for _, p := range promotions {
+		if p.IsRunning(now) && p.Matches(v) {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Priority != candidates[j].Priority {
+			return candidates[i].Priority > candidates[j].Priority
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+
+	var exclusive *AppliedPromotion
+	var stacked []AppliedPromotion
+	stackedPrice := v.Price
+	for _, p := range candidates {
+		if !p.Stackable {
+			amount := p.UnitDiscount(v.Price, quantity)
+			if amount.IsPositive() && (exclusive == nil || amount.GreaterThan(exclusive.Amount)) {
+				exclusive = &AppliedPromotion{PromotionID: p.ID, Name: p.Name, Amount: amount}
+			}
+			continue
+		}
+		amount := p.UnitDiscount(stackedPrice, quantity)
+		if amount.IsPositive() {
+			stackedPrice = stackedPrice.Sub(amount)
+			stacked = append(stacked, AppliedPromotion{PromotionID: p.ID, Name: p.Name, Amount: amount})
+		}
+	}
+
+	resolution := PriceResolution{
+		VariantID:  v.VariantID,
+		BasePrice:  v.Price,
+		FinalPrice: stackedPrice,
+		Applied:    stacked,
+	}
+	if exclusive != nil && v.Price.Sub(exclusive.Amount).LessThan(stackedPrice) {
+		resolution.FinalPrice = v.Price.Sub(exclusive.Amount)
+		resolution.Applied = []AppliedPromotion{*exclusive}
+	}
+	return resolution
+}
@@ -0,0 +1,245 @@
+package promotion
+
+import (
+	"admin/pkg/logger"
+	"admin/pkg/money"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+type PromotionService struct {
+	PromotionRepository *PromotionRepository
+	validator           *PromotionValidator
+}
+
+func NewPromotionService(promotionRepository *PromotionRepository, validator *PromotionValidator) *PromotionService {
+	return &PromotionService{
+		PromotionRepository: promotionRepository,
+		validator:           validator,
+	}
+}
+
+func (s *PromotionService) CreatePromotion(ctx context.Context, req *PromotionMessage) (*PromotionResponse, error) {
+	promotion, err := ConvertPayloadToDB(req)
+	if err != nil {
+		logger.Errorf("CreatePromotion error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.validator.Validate(promotion); err != nil {
+		logger.Errorf("CreatePromotion validation error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	created, err := s.PromotionRepository.Create(promotion)
+	if err != nil {
+		logger.Errorf("CreatePromotion error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &PromotionResponse{Promotion: ConvertDBToPayload(created)}, nil
+}
+
+func (s *PromotionService) UpdatePromotion(ctx context.Context, req *PromotionMessage) (*PromotionResponse, error) {
+	if req.Id == 0 {
+		logger.Error("UpdatePromotion error: promotion ID is required")
+		return nil, status.Error(codes.InvalidArgument, "promotion ID is required")
+	}
+	existing, err := s.PromotionRepository.GetByID(uint(req.Id))
+	if err != nil {
+		logger.Errorf("UpdatePromotion error: promotion %d not found: %v", req.Id, err)
+		return nil, status.Error(codes.NotFound, "promotion not found")
+	}
+
+	promotion, err := ConvertPayloadToDB(req)
+	if err != nil {
+		logger.Errorf("UpdatePromotion error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	promotion.Model = existing.Model
+	if err := s.validator.Validate(promotion); err != nil {
+		logger.Errorf("UpdatePromotion validation error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	updated, err := s.PromotionRepository.Update(promotion)
+	if err != nil {
+		logger.Errorf("UpdatePromotion error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &PromotionResponse{Promotion: ConvertDBToPayload(updated)}, nil
+}
+
+func (s *PromotionService) GetPromotion(ctx context.Context, req *GetPromotionRequest) (*PromotionResponse, error) {
+	if req.Id == 0 {
+		logger.Error("GetPromotion error: promotion ID is required")
+		return nil, status.Error(codes.InvalidArgument, "promotion ID is required")
+	}
+	promotion, err := s.PromotionRepository.GetByID(uint(req.Id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Errorf("GetPromotion error: promotion %d not found", req.Id)
+		return nil, status.Error(codes.NotFound, "promotion not found")
+	}
+	if err != nil {
+		logger.Errorf("GetPromotion error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &PromotionResponse{Promotion: ConvertDBToPayload(promotion)}, nil
+}
+
+func (s *PromotionService) DeletePromotion(ctx context.Context, req *DeletePromotionRequest) (*PromotionResponse, error) {
+	if req.Id == 0 {
+		logger.Error("DeletePromotion error: promotion ID is required")
+		return nil, status.Error(codes.InvalidArgument, "promotion ID is required")
+	}
+	if err := s.PromotionRepository.Delete(uint(req.Id), req.Unscoped); err != nil {
+		logger.Errorf("DeletePromotion error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &PromotionResponse{}, nil
+}
+
+func (s *PromotionService) ListPromotions(ctx context.Context, req *ListPromotionsRequest) (*PromotionListResponse, error) {
+	var promotions []Promotion
+	var err error
+	if req.ActiveNow {
+		promotions, err = s.PromotionRepository.GetActivePromotions(time.Now(), int(req.Limit))
+	} else {
+		promotions, err = s.PromotionRepository.List(int(req.Limit), int(req.Offset), req.IncludeInactive)
+	}
+	if err != nil {
+		logger.Errorf("ListPromotions error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &PromotionListResponse{Promotions: ConvertDBListToPayload(promotions)}, nil
+}
+
+// ResolvePrices возвращает итоговые цены вариантов с учётом идущих акций
+func (s *PromotionService) ResolvePrices(ctx context.Context, req *ResolvePricesRequest) (*ResolvePricesResponse, error) {
+	if len(req.VariantIds) == 0 {
+		logger.Error("ResolvePrices error: variant IDs are required")
+		return nil, status.Error(codes.InvalidArgument, "variant IDs are required")
+	}
+
+	ids := make([]uint, 0, len(req.VariantIds))
+	for _, id := range req.VariantIds {
+		ids = append(ids, uint(id))
+	}
+	variants, err := s.PromotionRepository.GetVariantContexts(ids)
+	if err != nil {
+		logger.Errorf("ResolvePrices error: load variants: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	now := time.Now()
+	promotions, err := s.PromotionRepository.GetActivePromotions(now, 0)
+	if err != nil {
+		logger.Errorf("ResolvePrices error: load promotions: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &ResolvePricesResponse{Prices: make([]*VariantPrice, 0, len(variants))}
+	for _, v := range variants {
+		resolution := ResolvePrice(v, uint(req.Quantity), promotions, now)
+		resp.Prices = append(resp.Prices, convertResolutionToPayload(resolution))
+	}
+	return resp, nil
+}
+
+func convertResolutionToPayload(r PriceResolution) *VariantPrice {
+	applied := make([]*AppliedPromotionMessage, 0, len(r.Applied))
+	for _, a := range r.Applied {
+		applied = append(applied, &AppliedPromotionMessage{
+			PromotionId: uint32(a.PromotionID),
+			Name:        a.Name,
+			Amount:      money.DecimalToCents(a.Amount),
+		})
+	}
+	return &VariantPrice{
+		VariantId:  uint32(r.VariantID),
+		BasePrice:  money.DecimalToCents(r.BasePrice),
+		FinalPrice: money.DecimalToCents(r.FinalPrice),
+		Applied:    applied,
+	}
+}
+
+func ConvertDBToPayload(p *Promotion) *PromotionMessage {
+	if p == nil {
+		return nil
+	}
+	scopeIDs := make([]uint32, 0, len(p.ScopeIDs))
+	for _, id := range p.ScopeIDs {
+		scopeIDs = append(scopeIDs, uint32(id))
+	}
+	var endsAt *timestamppb.Timestamp
+	if p.EndsAt != nil {
+		endsAt = timestamppb.New(*p.EndsAt)
+	}
+	return &PromotionMessage{
+		Id:           uint32(p.ID),
+		Name:         p.Name,
+		Description:  p.Description,
+		StartsAt:     timestamppb.New(p.StartsAt),
+		EndsAt:       endsAt,
+		IsActive:     p.IsActive,
+		Priority:     int32(p.Priority),
+		Stackable:    p.Stackable,
+		ScopeType:    p.ScopeType,
+		ScopeIds:     scopeIDs,
+		DiscountType: p.DiscountType,
+		Value:        p.Value.String(),
+		BuyQuantity:  uint32(p.BuyQuantity),
+		GetQuantity:  uint32(p.GetQuantity),
+	}
+}
+
+func ConvertDBListToPayload(promotions []Promotion) []*PromotionMessage {
+	result := make([]*PromotionMessage, 0, len(promotions))
+	for _, p := range promotions {
+		result = append(result, ConvertDBToPayload(&p))
+	}
+	return result
+}
+
+func ConvertPayloadToDB(m *PromotionMessage) (*Promotion, error) {
+	value := decimal.Zero
+	if m.Value != "" {
+		var err error
+		if value, err = decimal.NewFromString(m.Value); err != nil {
+			return nil, errors.New("invalid discount value")
+		}
+	}
+	scopeIDs := make([]uint, 0, len(m.ScopeIds))
+	for _, id := range m.ScopeIds {
+		scopeIDs = append(scopeIDs, uint(id))
+	}
+	var startsAt time.Time
+	if m.StartsAt != nil {
+		startsAt = m.StartsAt.AsTime()
+	}
+	var endsAt *time.Time
+	if m.EndsAt != nil {
+		t := m.EndsAt.AsTime()
+		endsAt = &t
+	}
+	return &Promotion{
+		Name:         m.Name,
+		Description:  m.Description,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		IsActive:     m.IsActive,
+		Priority:     int(m.Priority),
+		Stackable:    m.Stackable,
+		ScopeType:    m.ScopeType,
+		ScopeIDs:     scopeIDs,
+		DiscountType: m.DiscountType,
+		Value:        value,
+		BuyQuantity:  uint(m.BuyQuantity),
+		GetQuantity:  uint(m.GetQuantity),
+	}, nil
+}
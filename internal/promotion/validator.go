@@ -0,0 +1,50 @@
+package promotion
+
+import (
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+type PromotionValidator struct{}
+
+func (v *PromotionValidator) Validate(p *Promotion) error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if p.StartsAt.IsZero() {
+		return errors.New("start time is required")
+	}
+	if p.EndsAt != nil && !p.EndsAt.After(p.StartsAt) {
+		return errors.New("promotion must end after it starts")
+	}
+
+	switch p.ScopeType {
+	case ScopeAll:
+	case ScopeCategory, ScopeBrand, ScopeProduct, ScopeVariant:
+		if len(p.ScopeIDs) == 0 {
+			return errors.New("scope IDs are required for scope " + p.ScopeType)
+		}
+	default:
+		return errors.New("unknown scope type")
+	}
+
+	switch p.DiscountType {
+	case DiscountPercentage:
+		if !p.Value.IsPositive() || p.Value.GreaterThan(hundred) {
+			return errors.New("percentage must be in (0, 100]")
+		}
+	case DiscountFixed:
+		if !p.Value.IsPositive() {
+			return errors.New("fixed discount must be positive")
+		}
+	case DiscountBuyXGetY:
+		if p.BuyQuantity == 0 || p.GetQuantity == 0 {
+			return errors.New("buy and get quantities are required")
+		}
+		p.Value = decimal.Zero
+	default:
+		return errors.New("unknown discount type")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package rendition
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	OwnerProduct = "product"
+	OwnerVariant = "variant"
+)
+
+const (
+	JobPending    = "pending"
+	JobProcessing = "processing"
+	JobDone       = "done"
+	JobFailed     = "failed" // попытки исчерпаны
+)
+
+const (
+	DefaultMaxAttempts = 5
+	MaxSourceSize      = 20 << 20 // 20 МБ
+	// DefaultStaleGrace — сколько хранятся производные мягко удалённой сущности,
+	// чтобы её можно было восстановить вместе с ними
+	DefaultStaleGrace = 7 * 24 * time.Hour
+)
+
+var ErrUnknownOwner = errors.New("unknown owner type")
+
+// Spec — размер производного изображения. Каждое сохраняется в JPEG и WebP,
+// WebP-версия получает имя с суффиксом "_webp"
+type Spec struct {
+	Name      string
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Specs — производные изображения для витрины: сетка, карточка товара, увеличение
+var Specs = []Spec{
+	{Name: "thumbnail", MaxWidth: 240, MaxHeight: 240},
+	{Name: "card", MaxWidth: 600, MaxHeight: 800},
+	{Name: "zoom", MaxWidth: 1600, MaxHeight: 1600},
+}
+
+// Job — задание на построение производных одного изображения товара или варианта.
+// Неудачные задания повторяются с растущей задержкой до MaxAttempts
+type Job struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	OwnerType   string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_rendition_jobs_source" json:"owner_type"`
+	OwnerID     uint      `gorm:"not null;uniqueIndex:idx_rendition_jobs_source" json:"owner_id"`
+	SourceURL   string    `gorm:"type:varchar(1024);not null;uniqueIndex:idx_rendition_jobs_source" json:"source_url"`
+	Status      string    `gorm:"type:varchar(20);not null;index:idx_rendition_jobs_due" json:"status"`
+	Attempts    int       `gorm:"not null" json:"attempts"`
+	MaxAttempts int       `gorm:"not null" json:"max_attempts"`
+	NextRunAt   time.Time `gorm:"not null;index:idx_rendition_jobs_due" json:"next_run_at"`
+	// задание, не завершённое к этому времени, берётся снова
+	LockedUntil *time.Time `json:"locked_until"`
+	LastError   string     `gorm:"type:text" json:"last_error"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (Job) TableName() string {
+	return "rendition_jobs"
+}
+
+// ownerTables — таблицы сущностей, изображения которых обрабатываются
+var ownerTables = map[string]string{
+	OwnerProduct: "products",
+	OwnerVariant: "product_variants",
+}
+
+// backoff — задержка перед следующей попыткой: 1, 2, 4... минут, не больше часа
+func backoff(attempts int) time.Duration {
+	delay := time.Minute << min(attempts-1, 6)
+	return min(delay, time.Hour)
+}
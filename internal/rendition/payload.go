@@ -0,0 +1,26 @@
+package rendition
+
+// Сообщения RenditionService. Когда RPC появятся в admin-proto,
+// эти типы заменяются сгенерированными.
+
+type RenditionJobsRequest struct {
+	OwnerType string `json:"owner_type"` // product или variant
+	OwnerId   uint32 `json:"owner_id"`
+}
+
+type RenditionJobMessage struct {
+	Id        uint32 `json:"id"`
+	SourceUrl string `json:"source_url"`
+	Status    string `json:"status"`
+	Attempts  int32  `json:"attempts"`
+	NextRunAt int64  `json:"next_run_at"`
+	LastError string `json:"last_error"`
+}
+
+type RenditionJobsResponse struct {
+	Jobs []*RenditionJobMessage `json:"jobs"`
+}
+
+type RequeueRenditionsResponse struct {
+	Requeued int64 `json:"requeued"`
+}
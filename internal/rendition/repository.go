@@ -0,0 +1,198 @@
+package rendition
+
+import (
+	"admin/pkg/db"
+	"admin/pkg/imaging"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RenditionRepository struct {
+	Database *db.Db
+}
+
+func NewRenditionRepository(database *db.Db) *RenditionRepository {
+	return &RenditionRepository{
+		Database: database,
+	}
+}
+
+// EnqueueMissing ставит задания для изображений товаров и вариантов, у которых
+// нет производных и нет незавершённого задания. Выполненное задание перезапускается,
+// если производные пропали: например, изображение убрали из сущности и вернули.
+// Возвращает число поставленных заданий, не больше limit на таблицу
+func (repo *RenditionRepository) EnqueueMissing(limit int) (int, error) {
+	total := 0
+	for ownerType, table := range ownerTables {
+		result := repo.Database.DB.Exec(`
+			INSERT INTO rendition_jobs (owner_type, owner_id, source_url, status, attempts, max_attempts, next_run_at, created_at, updated_at)
+			SELECT ?, missing.id, missing.url, ?, 0, ?, NOW(), NOW(), NOW()
+			FROM (
+				SELECT DISTINCT o.id, src.url
+				FROM `+table+` o
+				CROSS JOIN LATERAL json_array_elements_text(o.images::json) AS src(url)
+				WHERE o.deleted_at IS NULL
+				  AND json_typeof(o.images::json) = 'array'
+				  AND src.url <> ''
+				  AND (o.renditions -> src.url) IS NULL
+				  AND NOT EXISTS (
+					SELECT 1 FROM rendition_jobs j
+					WHERE j.owner_type = ? AND j.owner_id = o.id AND j.source_url = src.url AND j.status <> ?)
+				LIMIT ?
+			) AS missing
+			ON CONFLICT (owner_type, owner_id, source_url) DO UPDATE SET
+				status = EXCLUDED.status, attempts = 0, next_run_at = EXCLUDED.next_run_at,
+				locked_until = NULL, last_error = '', updated_at = EXCLUDED.updated_at`,
+			ownerType, JobPending, DefaultMaxAttempts, ownerType, JobDone, limit)
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += int(result.RowsAffected)
+	}
+	return total, nil
+}
+
+// Requeue заново ставит все задания сущности, включая выполненные и исчерпавшие попытки
+func (repo *RenditionRepository) Requeue(ownerType string, ownerID uint) (int64, error) {
+	result := repo.Database.DB.Model(&Job{}).
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Updates(map[string]interface{}{
+			"status":       JobPending,
+			"attempts":     0,
+			"next_run_at":  time.Now(),
+			"locked_until": nil,
+			"last_error":   "",
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Claim забирает готовые к выполнению задания. Задания, у которых истекла
+// блокировка (воркер упал), забираются повторно
+func (repo *RenditionRepository) Claim(now time.Time, lease time.Duration, limit int) ([]Job, error) {
+	var jobs []Job
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_run_at <= ?) OR (status = ? AND locked_until < ?)", JobPending, now, JobProcessing, now).
+			Order("next_run_at").
+			Limit(limit).
+			Find(&jobs).Error; err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(jobs))
+		lockedUntil := now.Add(lease)
+		for i := range jobs {
+			ids[i] = jobs[i].ID
+			jobs[i].Status = JobProcessing
+			jobs[i].Attempts++
+			jobs[i].LockedUntil = &lockedUntil
+		}
+		return tx.Model(&Job{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":       JobProcessing,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"locked_until": lockedUntil,
+			"updated_at":   now,
+		}).Error
+	})
+	return jobs, err
+}
+
+// Complete сохраняет ссылки на производные в сущности и закрывает задание.
+// Из сущности заодно убираются производные изображений, которых в ней больше нет;
+// их файлы удаляет Worker по заданиям из FindStale
+func (repo *RenditionRepository) Complete(job *Job, renditions imaging.Renditions) error {
+	encoded, err := json.Marshal(renditions)
+	if err != nil {
+		return err
+	}
+	table := ownerTables[job.OwnerType]
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			UPDATE `+table+` SET renditions = (
+				SELECT COALESCE(jsonb_object_agg(r.key, r.value), '{}'::jsonb)
+				FROM jsonb_each(
+					CASE WHEN jsonb_typeof(renditions) = 'object' THEN renditions ELSE '{}'::jsonb END
+					|| jsonb_build_object(?::text, ?::jsonb)) AS r
+				WHERE json_typeof(images::json) = 'array'
+				  AND r.key IN (SELECT json_array_elements_text(images::json)))
+			WHERE id = ?`,
+			job.SourceURL, string(encoded), job.OwnerID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":       JobDone,
+			"locked_until": nil,
+			"last_error":   "",
+		}).Error
+	})
+}
+
+// Fail откладывает задание на следующую попытку или помечает его проваленным
+func (repo *RenditionRepository) Fail(job *Job, cause error, now time.Time) error {
+	updates := map[string]interface{}{
+		"status":       JobPending,
+		"next_run_at":  now.Add(backoff(job.Attempts)),
+		"locked_until": nil,
+		"last_error":   cause.Error(),
+	}
+	if job.Attempts >= job.MaxAttempts {
+		updates["status"] = JobFailed
+	}
+	return repo.Database.DB.Model(&Job{}).Where("id = ?", job.ID).Updates(updates).Error
+}
+
+// Discard закрывает задание, изображение которого больше не принадлежит сущности,
+// и убирает из сущности ссылки на его производные: файлы к этому моменту удалены
+func (repo *RenditionRepository) Discard(job *Job) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE `+ownerTables[job.OwnerType]+` SET renditions = renditions - ?::text
+			WHERE id = ? AND jsonb_typeof(renditions) = 'object'`, job.SourceURL, job.OwnerID).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", job.ID).Delete(&Job{}).Error
+	})
+}
+
+// FindStale возвращает задания, изображения которых больше не принадлежат живой
+// сущности: убраны из images, сущность удалена физически или мягко удалена раньше
+// deletedBefore. Задания, которые сейчас выполняет воркер, не возвращаются
+func (repo *RenditionRepository) FindStale(now, deletedBefore time.Time, limit int) ([]Job, error) {
+	var jobs []Job
+	query := repo.Database.DB.Where("1 = 0")
+	for ownerType, table := range ownerTables {
+		query = query.Or(`owner_type = ? AND NOT EXISTS (
+			SELECT 1 FROM `+table+` o WHERE o.id = rendition_jobs.owner_id AND (
+				o.deleted_at >= ? OR (o.deleted_at IS NULL AND json_typeof(o.images::json) = 'array'
+					AND EXISTS (SELECT 1 FROM json_array_elements_text(o.images::json) AS src(url) WHERE src.url = rendition_jobs.source_url))))`,
+			ownerType, deletedBefore)
+	}
+	result := repo.Database.DB.
+		Where(query).
+		Where("NOT (status = ? AND locked_until >= ?)", JobProcessing, now).
+		Order("id").Limit(limit).Find(&jobs)
+	return jobs, result.Error
+}
+
+// StillReferenced проверяет, что сущность жива и её изображения всё ещё содержат ссылку
+func (repo *RenditionRepository) StillReferenced(job *Job) (bool, error) {
+	var count int64
+	err := repo.Database.DB.Table(ownerTables[job.OwnerType]).
+		Where("id = ? AND deleted_at IS NULL", job.OwnerID).
+		Where("json_typeof(images::json) = 'array' AND EXISTS (SELECT 1 FROM json_array_elements_text(images::json) AS src(url) WHERE src.url = ?)", job.SourceURL).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (repo *RenditionRepository) ListByOwner(ownerType string, ownerID uint) ([]Job, error) {
+	var jobs []Job
+	result := repo.Database.DB.
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Order("id").
+		Find(&jobs)
+	return jobs, result.Error
+}
@@ -0,0 +1,69 @@
+package rendition
+
+import (
+	"admin/pkg/logger"
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type RenditionService struct {
+	RenditionRepository *RenditionRepository
+}
+
+func NewRenditionService(renditionRepository *RenditionRepository) *RenditionService {
+	return &RenditionService{
+		RenditionRepository: renditionRepository,
+	}
+}
+
+// ListRenditionJobs показывает состояние обработки изображений товара или варианта
+func (s *RenditionService) ListRenditionJobs(ctx context.Context, req *RenditionJobsRequest) (*RenditionJobsResponse, error) {
+	if err := checkOwner(req); err != nil {
+		logger.Errorf("ListRenditionJobs error: %v", err)
+		return nil, err
+	}
+	jobs, err := s.RenditionRepository.ListByOwner(req.OwnerType, uint(req.OwnerId))
+	if err != nil {
+		logger.Errorf("ListRenditionJobs error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	result := make([]*RenditionJobMessage, 0, len(jobs))
+	for _, job := range jobs {
+		result = append(result, &RenditionJobMessage{
+			Id:        uint32(job.ID),
+			SourceUrl: job.SourceURL,
+			Status:    job.Status,
+			Attempts:  int32(job.Attempts),
+			NextRunAt: job.NextRunAt.Unix(),
+			LastError: job.LastError,
+		})
+	}
+	return &RenditionJobsResponse{Jobs: result}, nil
+}
+
+// RequeueRenditions перестраивает производные всех изображений сущности,
+// в том числе после исчерпания попыток
+func (s *RenditionService) RequeueRenditions(ctx context.Context, req *RenditionJobsRequest) (*RequeueRenditionsResponse, error) {
+	if err := checkOwner(req); err != nil {
+		logger.Errorf("RequeueRenditions error: %v", err)
+		return nil, err
+	}
+	requeued, err := s.RenditionRepository.Requeue(req.OwnerType, uint(req.OwnerId))
+	if err != nil {
+		logger.Errorf("RequeueRenditions error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &RequeueRenditionsResponse{Requeued: requeued}, nil
+}
+
+func checkOwner(req *RenditionJobsRequest) error {
+	if _, ok := ownerTables[req.OwnerType]; !ok {
+		return status.Errorf(codes.InvalidArgument, "owner_type must be %s or %s", OwnerProduct, OwnerVariant)
+	}
+	if req.OwnerId == 0 {
+		return status.Error(codes.InvalidArgument, "owner ID required")
+	}
+	return nil
+}
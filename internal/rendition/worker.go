@@ -0,0 +1,278 @@
+package rendition
+
+import (
+	"admin/pkg/imaging"
+	"admin/pkg/logger"
+	"admin/pkg/storage"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	workerBatch   = 10
+	enqueueBatch  = 500
+	cleanupBatch  = 100
+	jobLease      = 5 * time.Minute // время на одно задание до повторной выдачи
+	fetchTimeout  = 30 * time.Second
+	webpSuffix    = "_webp"
+	jpegExtension = ".jpg"
+	webpExtension = ".webp"
+)
+
+var (
+	ErrSourceTooLarge  = errors.New("source image is too large")
+	ErrSourceForbidden = errors.New("source host is not allowed")
+)
+
+// Worker строит производные изображений товаров и вариантов: находит изображения
+// без производных, ставит задания в очередь rendition_jobs и выполняет их.
+// Файлы производных удалённых изображений и владельцев он же и удаляет
+type Worker struct {
+	RenditionRepository *RenditionRepository
+	Storage             storage.Storage
+	Client              *http.Client
+	// внешние изображения скачиваются только с этих хостов, остальные берутся из Storage
+	AllowedHosts []string
+	Grace        time.Duration // сколько хранятся производные мягко удалённого владельца
+}
+
+func NewWorker(renditionRepository *RenditionRepository, storage storage.Storage, allowedHosts []string) *Worker {
+	return &Worker{
+		RenditionRepository: renditionRepository,
+		Storage:             storage,
+		Client: &http.Client{
+			Timeout: fetchTimeout,
+			// редирект мог бы увести запрос с разрешённого хоста
+			CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		AllowedHosts: allowedHosts,
+		Grace:        DefaultStaleGrace,
+	}
+}
+
+// Run блокируется до отмены ctx, проверяя очередь раз в interval
+func (w *Worker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.enqueue()
+		w.processDue(ctx)
+		w.cleanup(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) enqueue() {
+	queued, err := w.RenditionRepository.EnqueueMissing(enqueueBatch)
+	if err != nil {
+		logger.Errorf("Rendition worker error: enqueue: %v", err)
+		return
+	}
+	if queued > 0 {
+		logger.Infof("Rendition worker: queued %d images", queued)
+	}
+}
+
+func (w *Worker) processDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		jobs, err := w.RenditionRepository.Claim(time.Now(), jobLease, workerBatch)
+		if err != nil {
+			logger.Errorf("Rendition worker error: claim: %v", err)
+			return
+		}
+		for i := range jobs {
+			w.process(ctx, &jobs[i])
+		}
+		if len(jobs) < workerBatch {
+			return
+		}
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job *Job) {
+	referenced, err := w.RenditionRepository.StillReferenced(job)
+	if err != nil {
+		logger.Errorf("Rendition worker error: job %d: %v", job.ID, err)
+		return // блокировка истечёт, задание возьмут снова
+	}
+	if !referenced {
+		// производные могли остаться от прошлого выполнения задания
+		if err := w.deleteFiles(ctx, job); err != nil {
+			logger.Errorf("Rendition worker error: job %d: %v", job.ID, err)
+			return
+		}
+		if err := w.RenditionRepository.Discard(job); err != nil {
+			logger.Errorf("Rendition worker error: discarding job %d: %v", job.ID, err)
+		}
+		return
+	}
+
+	renditions, err := w.render(ctx, job)
+	if err == nil {
+		err = w.RenditionRepository.Complete(job, renditions)
+	}
+	if err != nil {
+		if errors.Is(err, ErrSourceForbidden) {
+			job.Attempts = job.MaxAttempts // повтор ничего не изменит
+		}
+		logger.Errorf("Rendition worker error: job %d (%s), attempt %d/%d: %v", job.ID, job.SourceURL, job.Attempts, job.MaxAttempts, err)
+		if failErr := w.RenditionRepository.Fail(job, err, time.Now()); failErr != nil {
+			logger.Errorf("Rendition worker error: job %d: %v", job.ID, failErr)
+		}
+	}
+}
+
+// render строит все размеры из Specs в JPEG и WebP и сохраняет их в хранилище.
+// Ключи детерминированы, поэтому повторная попытка перезаписывает файлы, а не плодит их
+func (w *Worker) render(ctx context.Context, job *Job) (imaging.Renditions, error) {
+	data, err := w.fetch(ctx, job.SourceURL)
+	if err != nil {
+		return nil, err
+	}
+	src, err := imaging.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	prefix := keyPrefix(job)
+	renditions := make(imaging.Renditions, len(Specs)*2)
+	for _, spec := range Specs {
+		img := imaging.Fit(src, spec.MaxWidth, spec.MaxHeight)
+
+		var jpegBuf, webpBuf bytes.Buffer
+		if err := imaging.EncodeJPEG(&jpegBuf, img, imaging.DefaultJPEGQuality); err != nil {
+			return nil, fmt.Errorf("%s: jpeg: %w", spec.Name, err)
+		}
+		if err := imaging.EncodeWebP(&webpBuf, img); err != nil {
+			return nil, fmt.Errorf("%s: webp: %w", spec.Name, err)
+		}
+
+		jpegKey := prefix + spec.Name + jpegExtension
+		webpKey := prefix + spec.Name + webpExtension
+		if err := w.Storage.Put(ctx, jpegKey, &jpegBuf); err != nil {
+			return nil, fmt.Errorf("store %s: %w", jpegKey, err)
+		}
+		if err := w.Storage.Put(ctx, webpKey, &webpBuf); err != nil {
+			return nil, fmt.Errorf("store %s: %w", webpKey, err)
+		}
+		renditions[spec.Name] = w.Storage.URL(jpegKey)
+		renditions[spec.Name+webpSuffix] = w.Storage.URL(webpKey)
+	}
+	return renditions, nil
+}
+
+// fetch читает исходное изображение: из своего хранилища напрямую, внешнее — по HTTP
+// и только с AllowedHosts, чтобы ссылки из сущностей не открывали доступ к внутренней сети
+func (w *Worker) fetch(ctx context.Context, source string) ([]byte, error) {
+	var body io.ReadCloser
+	if key, ok := w.Storage.KeyOf(source); ok {
+		file, err := w.Storage.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		body = file
+	} else {
+		if !w.allowed(source) {
+			return nil, fmt.Errorf("fetch %s: %w", source, ErrSourceForbidden)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := w.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("fetch %s: status %d", source, resp.StatusCode)
+		}
+		body = resp.Body
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, MaxSourceSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxSourceSize {
+		return nil, ErrSourceTooLarge
+	}
+	return data, nil
+}
+
+func (w *Worker) allowed(source string) bool {
+	parsed, err := url.Parse(source)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.User != nil {
+		return false
+	}
+	return slices.ContainsFunc(w.AllowedHosts, func(host string) bool {
+		return strings.EqualFold(host, parsed.Host)
+	})
+}
+
+// cleanup удаляет файлы производных изображений, которых больше нет в сущностях,
+// и удалённых сущностей, а затем их задания
+func (w *Worker) cleanup(ctx context.Context) {
+	for ctx.Err() == nil {
+		jobs, err := w.RenditionRepository.FindStale(time.Now(), time.Now().Add(-w.Grace), cleanupBatch)
+		if err != nil {
+			logger.Errorf("Rendition worker error: find stale: %v", err)
+			return
+		}
+		removed := 0
+		for i := range jobs {
+			if err := w.deleteFiles(ctx, &jobs[i]); err != nil {
+				logger.Errorf("Rendition worker error: job %d: %v", jobs[i].ID, err)
+				continue
+			}
+			if err := w.RenditionRepository.Discard(&jobs[i]); err != nil {
+				logger.Errorf("Rendition worker error: discarding job %d: %v", jobs[i].ID, err)
+				continue
+			}
+			removed++
+		}
+		if removed > 0 {
+			logger.Infof("Rendition worker: removed renditions of %d images", removed)
+		}
+		// неполная пачка или файлы не удаляются — повторим на следующем тике
+		if len(jobs) < cleanupBatch || removed < len(jobs) {
+			return
+		}
+	}
+}
+
+// deleteFiles удаляет все файлы производных задания; отсутствие файла не ошибка
+func (w *Worker) deleteFiles(ctx context.Context, job *Job) error {
+	prefix := keyPrefix(job)
+	for _, spec := range Specs {
+		for _, key := range []string{prefix + spec.Name + jpegExtension, prefix + spec.Name + webpExtension} {
+			if err := w.Storage.Delete(ctx, key); err != nil {
+				return fmt.Errorf("delete %s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// keyPrefix — каталог производных одного изображения. Ключи детерминированы,
+// поэтому файлы находятся по заданию без отдельного учёта
+func keyPrefix(job *Job) string {
+	sum := sha1.Sum([]byte(job.SourceURL))
+	return fmt.Sprintf("renditions/%s/%d/%s/", job.OwnerType, job.OwnerID, hex.EncodeToString(sum[:8]))
+}
@@ -0,0 +1,85 @@
+package reservation
+
+import (
+	"admin/pkg/consumer"
+	"admin/pkg/dlq"
+	"admin/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Consumer переводит события заказов в операции с остатками: order.created — бронь,
+// order.paid и order.shipped — списание, order.cancelled — снятие брони, order.returned —
+// возврат на склад. Ответы stock.* публикуются через outbox. Непригодные события уходят в DLQ
+type Consumer struct {
+	*consumer.Consumer
+	*ReservationRepository
+	Validator *OrderEventValidator
+
+	producers *dlq.TopicProducers
+	dlqTopic  string
+}
+
+func NewConsumer(repo *ReservationRepository, brokers []string, topic, dlqTopic string) *Consumer {
+	if topic == "" {
+		topic = DefaultTopic
+	}
+	c := &Consumer{
+		ReservationRepository: repo,
+		Validator:             NewOrderEventValidator(),
+		producers:             dlq.NewTopicProducers(brokers),
+		dlqTopic:              dlqTopic,
+	}
+	c.Consumer = consumer.New("Order stock", brokers, topic, ConsumerGroup, c.Handle)
+	return c
+}
+
+// Stop останавливает чтение и закрывает продюсер DLQ
+func (c *Consumer) Stop(ctx context.Context) error {
+	return errors.Join(c.Consumer.Stop(ctx), c.producers.Close())
+}
+
+// Handle обрабатывает одно событие. Ошибка означает, что событие нужно повторить;
+// бизнес-отказы ошибкой не считаются, на них уходит ответ stock.*_failed
+func (c *Consumer) Handle(ctx context.Context, msg kafka.Message) error {
+	var evt OrderEvent
+	if err := json.Unmarshal(msg.Value, &evt); err != nil {
+		return c.reject(ctx, msg, "unparseable: "+err.Error())
+	}
+	if err := c.Validator.Validate(&evt); err != nil {
+		return c.reject(ctx, msg, err.Error())
+	}
+
+	outcome, err := c.Apply(&evt)
+	if err != nil {
+		return err
+	}
+	switch {
+	case outcome.Duplicate:
+		logger.Infof("Order stock: event %s already processed, skipped", evt.EventID)
+	case outcome.Data.Reason != "":
+		logger.Warnf("Order stock: %s for order %d: %s", evt.Type, evt.OrderID, outcome.Data.Reason)
+	default:
+		logger.Infof("Order stock: %s for order %d -> %s", evt.Type, evt.OrderID, outcome.Result)
+	}
+	return nil
+}
+
+// reject отправляет непригодное событие в DLQ: повтор его не исправит
+func (c *Consumer) reject(ctx context.Context, msg kafka.Message, reason string) error {
+	logger.Errorf("Order stock: partition %d offset %d rejected: %s", msg.Partition, msg.Offset, reason)
+	return c.producers.For(c.dlqTopic).Produce(ctx, c.dlqTopic, string(msg.Key), dlq.Notification{
+		ID:       fmt.Sprintf("%s-%d-%d", msg.Topic, msg.Partition, msg.Offset),
+		Category: DLQCategory,
+		Subtype:  SubtypeInvalid,
+		Payload: map[string]interface{}{
+			"reason": reason,
+			"topic":  msg.Topic,
+			"value":  string(msg.Value),
+		},
+	})
+}
@@ -0,0 +1,81 @@
+package reservation
+
+import (
+	"admin/pkg/event"
+	"time"
+)
+
+// События жизненного цикла заказа
+const (
+	OrderCreated   = "order.created"   // бронь позиций
+	OrderPaid      = "order.paid"      // списание брони с остатка
+	OrderShipped   = "order.shipped"   // списание, если заказ не оплачивался заранее
+	OrderCancelled = "order.cancelled" // снятие брони или возврат списанного на склад
+	OrderReturned  = "order.returned"  // возврат отгруженных позиций на склад
+)
+
+// Состояния брони позиции заказа
+const (
+	StatusReserved  = "reserved"
+	StatusCommitted = "committed" // списано с остатка, ещё не отгружено
+	StatusShipped   = "shipped"
+	StatusReleased  = "released" // снята при отмене
+	StatusReturned  = "returned" // возвращена целиком
+)
+
+const (
+	DefaultTopic   = "order.events"
+	ConsumerGroup  = "admin-order-stock"
+	MaxLines       = 1000
+	DLQCategory    = "STOCK"
+	SubtypeInvalid = "INVALID_ORDER_EVENT"
+)
+
+// OrderEvent — событие сервиса заказов. Lines обязательны для order.created;
+// для order.returned — возвращаемые позиции, пусто означает весь заказ
+type OrderEvent struct {
+	EventID    string            `json:"event_id"`
+	Type       string            `json:"type"`
+	OrderID    uint              `json:"order_id"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	Lines      []event.StockLine `json:"lines"`
+}
+
+// Reservation — бронь варианта под заказ. Хранится, пока заказ не завершён,
+// чтобы оплата, отмена и возврат знали, что именно было забронировано и списано
+type Reservation struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	OrderID   uint      `gorm:"not null;uniqueIndex:idx_order_stock_reservations_line" json:"order_id"`
+	VariantID uint      `gorm:"not null;uniqueIndex:idx_order_stock_reservations_line;index" json:"variant_id"`
+	Quantity  uint32    `gorm:"not null" json:"quantity"`
+	Returned  uint32    `gorm:"not null;default:0" json:"returned"`
+	Status    string    `gorm:"type:varchar(20);not null" json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Reservation) TableName() string {
+	return "order_stock_reservations"
+}
+
+// ProcessedEvent — обработанное событие заказа. Пишется в транзакции обработки,
+// поэтому повтор события с тем же ID ничего не меняет
+type ProcessedEvent struct {
+	EventID     string    `gorm:"type:varchar(100);primaryKey" json:"event_id"`
+	OrderID     uint      `gorm:"not null;index" json:"order_id"`
+	Type        string    `gorm:"type:varchar(30);not null" json:"type"`
+	Result      string    `gorm:"type:varchar(40);not null" json:"result"`
+	Reason      string    `gorm:"type:text" json:"reason"`
+	ProcessedAt time.Time `gorm:"not null" json:"processed_at"`
+}
+
+func (ProcessedEvent) TableName() string {
+	return "order_processed_events"
+}
+
+// Outcome — итог обработки события: тип ответного события и его данные
+type Outcome struct {
+	Duplicate bool
+	Result    string
+	Data      event.StockResultData
+}
@@ -0,0 +1,309 @@
+package reservation
+
+import (
+	"admin/internal/outbox"
+	"admin/internal/productVariant"
+	"admin/pkg/db"
+	"admin/pkg/event"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// rejection — отказ по бизнес-причине (нет остатка, заказ уже отменён и т. п.).
+// Изменения события откатываются, но событие считается обработанным и получает ответ-отказ
+type rejection struct {
+	reason string
+}
+
+func (r *rejection) Error() string {
+	return r.reason
+}
+
+func reject(format string, args ...any) error {
+	return &rejection{reason: fmt.Sprintf(format, args...)}
+}
+
+type ReservationRepository struct {
+	Database *db.Db
+}
+
+func NewReservationRepository(database *db.Db) *ReservationRepository {
+	return &ReservationRepository{
+		Database: database,
+	}
+}
+
+// Apply обрабатывает событие заказа в одной транзакции: меняет остатки и брони,
+// отмечает событие обработанным и пишет ответное событие в outbox.
+// Событие с уже обработанным ID пропускается (Outcome.Duplicate)
+func (repo *ReservationRepository) Apply(evt *OrderEvent) (*Outcome, error) {
+	outcome := &Outcome{Data: event.StockResultData{OrderID: evt.OrderID, SourceEvent: evt.EventID}}
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		processed := &ProcessedEvent{
+			EventID:     evt.EventID,
+			OrderID:     evt.OrderID,
+			Type:        evt.Type,
+			ProcessedAt: time.Now(),
+		}
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(processed)
+		if created.Error != nil {
+			return created.Error
+		}
+		if created.RowsAffected == 0 {
+			outcome.Duplicate = true
+			return nil
+		}
+
+		if err := tx.SavePoint("order_event").Error; err != nil {
+			return err
+		}
+		result, lines, err := apply(tx, evt)
+		var rej *rejection
+		if errors.As(err, &rej) {
+			if err := tx.RollbackTo("order_event").Error; err != nil {
+				return err
+			}
+			result, lines = StockFailure(evt.Type), evt.Lines
+			outcome.Data.Reason = rej.reason
+		} else if err != nil {
+			return err
+		}
+		outcome.Result, outcome.Data.Lines = result, lines
+
+		if err := tx.Model(processed).Updates(map[string]interface{}{
+			"result": result,
+			"reason": outcome.Data.Reason,
+		}).Error; err != nil {
+			return err
+		}
+		return outbox.Add(tx, event.AggregateOrder, evt.OrderID, result, outcome.Data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
+
+// StockFailure — тип ответа-отказа на событие заказа
+func StockFailure(eventType string) string {
+	if eventType == OrderCreated {
+		return event.StockReservationFailed
+	}
+	return event.StockOperationFailed
+}
+
+func apply(tx *gorm.DB, evt *OrderEvent) (string, []event.StockLine, error) {
+	switch evt.Type {
+	case OrderCreated:
+		return reserve(tx, evt)
+	case OrderPaid:
+		return commit(tx, evt, StatusCommitted)
+	case OrderShipped:
+		return commit(tx, evt, StatusShipped)
+	case OrderCancelled:
+		return release(tx, evt)
+	case OrderReturned:
+		return restock(tx, evt)
+	}
+	return "", nil, reject("unsupported event type %q", evt.Type)
+}
+
+// reserve бронирует позиции заказа. Повторное order.created с другим ID не бронирует дважды
+func reserve(tx *gorm.DB, evt *OrderEvent) (string, []event.StockLine, error) {
+	existing, err := lockReservations(tx, evt.OrderID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(existing) > 0 {
+		if existing[0].Status == StatusReleased {
+			return "", nil, reject("order %d is cancelled", evt.OrderID)
+		}
+		return event.StockReserved, linesOf(existing), nil
+	}
+
+	lines := mergeLines(evt.Lines)
+	for _, line := range lines {
+		if err := adjust(tx, line.VariantID, 0, int64(line.Quantity)); err != nil {
+			return "", nil, err
+		}
+		if err := tx.Create(&Reservation{
+			OrderID:   evt.OrderID,
+			VariantID: line.VariantID,
+			Quantity:  line.Quantity,
+			Status:    StatusReserved,
+		}).Error; err != nil {
+			return "", nil, err
+		}
+	}
+	return event.StockReserved, lines, nil
+}
+
+// commit списывает забронированное с остатка; status — committed при оплате, shipped при отгрузке
+func commit(tx *gorm.DB, evt *OrderEvent, status string) (string, []event.StockLine, error) {
+	reservations, err := lockReservations(tx, evt.OrderID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(reservations) == 0 {
+		return "", nil, reject("order %d has no reservation", evt.OrderID)
+	}
+	for i := range reservations {
+		r := &reservations[i]
+		switch r.Status {
+		case StatusReserved:
+			q := int64(r.Quantity)
+			if err := adjust(tx, r.VariantID, -q, -q); err != nil {
+				return "", nil, err
+			}
+		case StatusCommitted:
+			if status == StatusCommitted {
+				continue
+			}
+		case StatusShipped:
+			continue
+		default:
+			return "", nil, reject("order %d is %s", evt.OrderID, r.Status)
+		}
+		if err := setStatus(tx, r, status); err != nil {
+			return "", nil, err
+		}
+	}
+	return event.StockCommitted, linesOf(reservations), nil
+}
+
+// release снимает бронь, а уже списанное, но не отгруженное возвращает на склад
+func release(tx *gorm.DB, evt *OrderEvent) (string, []event.StockLine, error) {
+	reservations, err := lockReservations(tx, evt.OrderID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(reservations) == 0 {
+		return "", nil, reject("order %d has no reservation", evt.OrderID)
+	}
+	for i := range reservations {
+		r := &reservations[i]
+		q := int64(r.Quantity)
+		switch r.Status {
+		case StatusReserved:
+			err = adjust(tx, r.VariantID, 0, -q)
+		case StatusCommitted:
+			err = adjust(tx, r.VariantID, q, 0)
+		case StatusShipped:
+			return "", nil, reject("order %d is already shipped, expected order.returned", evt.OrderID)
+		default:
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if err := setStatus(tx, r, StatusReleased); err != nil {
+			return "", nil, err
+		}
+	}
+	return event.StockReleased, linesOf(reservations), nil
+}
+
+// restock возвращает на склад отгруженные позиции; без Lines — всё, что ещё не возвращено
+func restock(tx *gorm.DB, evt *OrderEvent) (string, []event.StockLine, error) {
+	reservations, err := lockReservations(tx, evt.OrderID)
+	if err != nil {
+		return "", nil, err
+	}
+	byVariant := make(map[uint]*Reservation, len(reservations))
+	for i := range reservations {
+		byVariant[reservations[i].VariantID] = &reservations[i]
+	}
+
+	lines := mergeLines(evt.Lines)
+	if len(lines) == 0 {
+		for _, r := range reservations {
+			if r.Status == StatusShipped && r.Returned < r.Quantity {
+				lines = append(lines, event.StockLine{VariantID: r.VariantID, Quantity: r.Quantity - r.Returned})
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return "", nil, reject("order %d has nothing to return", evt.OrderID)
+	}
+
+	for _, line := range lines {
+		r, ok := byVariant[line.VariantID]
+		// оплаченный, но не отгруженный товар со склада не уходил: возвращать нечего
+		if !ok || r.Status != StatusShipped {
+			return "", nil, reject("variant %d was not shipped in order %d", line.VariantID, evt.OrderID)
+		}
+		if line.Quantity > r.Quantity-r.Returned {
+			return "", nil, reject("variant %d: return of %d exceeds %d shipped", line.VariantID, line.Quantity, r.Quantity-r.Returned)
+		}
+		if err := adjust(tx, r.VariantID, int64(line.Quantity), 0); err != nil {
+			return "", nil, err
+		}
+		r.Returned += line.Quantity
+		status := r.Status
+		if r.Returned == r.Quantity {
+			status = StatusReturned
+		}
+		if err := tx.Model(r).Updates(map[string]interface{}{"returned": r.Returned, "status": status}).Error; err != nil {
+			return "", nil, err
+		}
+	}
+	return event.StockRestocked, lines, nil
+}
+
+// adjust меняет остаток варианта, превращая нарушения в отказ
+func adjust(tx *gorm.DB, variantID uint, stockDelta, reservedDelta int64) error {
+	err := productVariant.AdjustStock(tx, variantID, stockDelta, reservedDelta)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return reject("variant %d not found", variantID)
+	case errors.Is(err, productVariant.ErrStockConflict):
+		if reservedDelta > 0 {
+			return reject("insufficient stock for variant %d", variantID)
+		}
+		return reject("stock of variant %d is inconsistent with the order", variantID)
+	}
+	return err
+}
+
+// lockReservations блокирует брони заказа; порядок по варианту совпадает с порядком
+// блокировки вариантов в reserve, что исключает взаимные блокировки
+func lockReservations(tx *gorm.DB, orderID uint) ([]Reservation, error) {
+	var reservations []Reservation
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ?", orderID).
+		Order("variant_id").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+func setStatus(tx *gorm.DB, r *Reservation, status string) error {
+	r.Status = status
+	return tx.Model(r).Update("status", status).Error
+}
+
+// mergeLines складывает повторяющиеся варианты и сортирует позиции по варианту
+func mergeLines(lines []event.StockLine) []event.StockLine {
+	quantities := make(map[uint]uint32, len(lines))
+	for _, line := range lines {
+		quantities[line.VariantID] += line.Quantity
+	}
+	merged := make([]event.StockLine, 0, len(quantities))
+	for variantID, quantity := range quantities {
+		merged = append(merged, event.StockLine{VariantID: variantID, Quantity: quantity})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].VariantID < merged[j].VariantID })
+	return merged
+}
+
+func linesOf(reservations []Reservation) []event.StockLine {
+	lines := make([]event.StockLine, len(reservations))
+	for i, r := range reservations {
+		lines[i] = event.StockLine{VariantID: r.VariantID, Quantity: r.Quantity}
+	}
+	return lines
+}
@@ -0,0 +1,55 @@
+package reservation
+
+import (
+	"admin/pkg/validation"
+	"fmt"
+)
+
+// DefaultRules — проверки события заказа до обработки
+func DefaultRules() []validation.Rule[*OrderEvent] {
+	return []validation.Rule[*OrderEvent]{
+		validation.Required("event_id", func(e *OrderEvent) string { return e.EventID }),
+		validation.MaxLength("event_id", 100, func(e *OrderEvent) string { return e.EventID }),
+		validation.Check("order_id", "is required", func(e *OrderEvent) bool { return e.OrderID != 0 }),
+		validation.Check("type", "is not an order lifecycle event", func(e *OrderEvent) bool {
+			switch e.Type {
+			case OrderCreated, OrderPaid, OrderShipped, OrderCancelled, OrderReturned:
+				return true
+			}
+			return false
+		}),
+		validation.Check("lines", "are required for order.created", func(e *OrderEvent) bool {
+			return e.Type != OrderCreated || len(e.Lines) > 0
+		}),
+		validation.Check("lines", fmt.Sprintf("must not exceed %d", MaxLines), func(e *OrderEvent) bool {
+			return len(e.Lines) <= MaxLines
+		}),
+		func(e *OrderEvent) []validation.FieldError {
+			var errs []validation.FieldError
+			for i, line := range e.Lines {
+				if line.VariantID == 0 || line.Quantity == 0 {
+					errs = append(errs, validation.FieldError{
+						Field:       fmt.Sprintf("lines[%d]", i),
+						Description: "variant_id and positive quantity are required",
+					})
+				}
+			}
+			return errs
+		},
+	}
+}
+
+type OrderEventValidator struct {
+	rules *validation.Validator[*OrderEvent]
+}
+
+func NewOrderEventValidator(extra ...validation.Rule[*OrderEvent]) *OrderEventValidator {
+	return &OrderEventValidator{
+		rules: validation.New(DefaultRules()...).Add(extra...),
+	}
+}
+
+// Validate возвращает validation.Errors со всеми нарушениями
+func (v *OrderEventValidator) Validate(e *OrderEvent) error {
+	return v.rules.Validate(e)
+}
@@ -0,0 +1,81 @@
+package review
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+const (
+	OwnerProduct = "product"
+	OwnerVariant = "variant"
+)
+
+const (
+	MinRating     = 1
+	MaxRating     = 5
+	MaxTextLength = 5000
+	MaxBulkSize   = 100
+)
+
+var (
+	ErrAlreadyReviewed = errors.New("user has already reviewed this product")
+	ErrVariantMismatch = errors.New("variant does not belong to the product")
+	ErrNotAuthor       = errors.New("review belongs to another user")
+)
+
+// Review — отзыв пользователя о товаре, при необходимости о конкретном варианте.
+// В рейтинги попадают только одобренные отзывы
+type Review struct {
+	gorm.Model
+	UserID           uint       `gorm:"not null;uniqueIndex:idx_reviews_user_product,where:deleted_at IS NULL" json:"user_id"`
+	ProductID        uint       `gorm:"not null;index;uniqueIndex:idx_reviews_user_product,where:deleted_at IS NULL" json:"product_id"`
+	VariantID        *uint      `gorm:"index" json:"variant_id"`
+	Rating           uint8      `gorm:"not null" json:"rating"`
+	Text             string     `gorm:"type:text" json:"text"`
+	Status           string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	VerifiedPurchase bool       `gorm:"not null" json:"verified_purchase"`
+	RejectReason     string     `gorm:"type:varchar(500)" json:"reject_reason"`
+	ModeratorID      uint       `json:"moderator_id"`
+	ModeratedAt      *time.Time `json:"moderated_at"`
+}
+
+// RatingSummary — агрегат одобренных отзывов товара или варианта.
+// Обновляется инкрементально при смене статуса отзыва, отзыв о варианте
+// учитывается и в товаре
+type RatingSummary struct {
+	OwnerType string `gorm:"type:varchar(20);primaryKey" json:"owner_type"`
+	OwnerID   uint   `gorm:"primaryKey" json:"owner_id"`
+	Count     int64  `gorm:"not null" json:"count"`
+	Sum       int64  `gorm:"not null" json:"sum"`
+	Stars1    int64  `gorm:"column:stars_1;not null" json:"stars_1"`
+	Stars2    int64  `gorm:"column:stars_2;not null" json:"stars_2"`
+	Stars3    int64  `gorm:"column:stars_3;not null" json:"stars_3"`
+	Stars4    int64  `gorm:"column:stars_4;not null" json:"stars_4"`
+	Stars5    int64  `gorm:"column:stars_5;not null" json:"stars_5"`
+	UpdatedAt time.Time
+}
+
+func (RatingSummary) TableName() string {
+	return "review_rating_summaries"
+}
+
+// Average — средняя оценка, 0 при отсутствии отзывов
+func (s *RatingSummary) Average() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Sum) / float64(s.Count)
+}
+
+// Histogram — число отзывов по оценкам, индекс 0 соответствует одной звезде
+func (s *RatingSummary) Histogram() [MaxRating]int64 {
+	return [MaxRating]int64{s.Stars1, s.Stars2, s.Stars3, s.Stars4, s.Stars5}
+}
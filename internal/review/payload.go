@@ -0,0 +1,94 @@
+package review
+
+// Сообщения ReviewService. Когда RPC появятся в admin-proto,
+// эти типы заменяются сгенерированными.
+
+type CreateReviewRequest struct {
+	UserId    uint32 `json:"user_id"`
+	ProductId uint32 `json:"product_id"`
+	VariantId uint32 `json:"variant_id"` // 0 — отзыв о товаре в целом
+	Rating    uint32 `json:"rating"`     // 1..5
+	Text      string `json:"text"`
+	// проставляет сервис заказов, который знает о покупке
+	VerifiedPurchase bool `json:"verified_purchase"`
+}
+
+type UpdateReviewRequest struct {
+	Id     uint32 `json:"id"`
+	UserId uint32 `json:"user_id"` // автор отзыва
+	Rating uint32 `json:"rating"`
+	Text   string `json:"text"`
+}
+
+type DeleteReviewRequest struct {
+	Id     uint32 `json:"id"`
+	UserId uint32 `json:"user_id"` // 0 — удаление модератором
+}
+
+type GetReviewRequest struct {
+	Id uint32 `json:"id"`
+}
+
+type ReviewMessage struct {
+	Id               uint32 `json:"id"`
+	UserId           uint32 `json:"user_id"`
+	ProductId        uint32 `json:"product_id"`
+	VariantId        uint32 `json:"variant_id"`
+	Rating           uint32 `json:"rating"`
+	Text             string `json:"text"`
+	Status           string `json:"status"`
+	VerifiedPurchase bool   `json:"verified_purchase"`
+	RejectReason     string `json:"reject_reason"`
+	ModeratorId      uint32 `json:"moderator_id"`
+	ModeratedAt      int64  `json:"moderated_at"`
+	CreatedAt        int64  `json:"created_at"`
+}
+
+type ListReviewsRequest struct {
+	ProductId uint32 `json:"product_id"`
+	VariantId uint32 `json:"variant_id"`
+	UserId    uint32 `json:"user_id"`
+	Status    string `json:"status"` // пусто — любой
+	Limit     int32  `json:"limit"`
+	Offset    int32  `json:"offset"`
+}
+
+type ListReviewsResponse struct {
+	Reviews []*ReviewMessage `json:"reviews"`
+	Total   int64            `json:"total"`
+}
+
+type ModerateReviewRequest struct {
+	Id          uint32 `json:"id"`
+	ModeratorId uint32 `json:"moderator_id"`
+	Reason      string `json:"reason"` // для отклонения
+}
+
+type BulkModerateRequest struct {
+	Ids         []uint32 `json:"ids"`
+	Action      string   `json:"action"` // approve или reject
+	ModeratorId uint32   `json:"moderator_id"`
+	Reason      string   `json:"reason"`
+}
+
+type BulkModerateResponse struct {
+	Reviews    []*ReviewMessage `json:"reviews"`
+	MissingIds []uint32         `json:"missing_ids"`
+}
+
+type RatingSummaryRequest struct {
+	OwnerType string `json:"owner_type"` // product или variant
+	OwnerId   uint32 `json:"owner_id"`
+}
+
+type RatingSummaryMessage struct {
+	OwnerType string  `json:"owner_type"`
+	OwnerId   uint32  `json:"owner_id"`
+	Count     int64   `json:"count"`
+	Average   float64 `json:"average"`
+	Histogram []int64 `json:"histogram"` // число отзывов с 1..5 звёздами
+}
+
+type RecalculateRatingsRequest struct {
+	ProductId uint32 `json:"product_id"`
+}
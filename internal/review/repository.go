@@ -0,0 +1,306 @@
+package review
+
+import (
+	"admin/pkg/db"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReviewRepository struct {
+	Database *db.Db
+}
+
+func NewReviewRepository(database *db.Db) *ReviewRepository {
+	return &ReviewRepository{
+		Database: database,
+	}
+}
+
+// ListFilter — условия выборки отзывов, нулевые поля не учитываются
+type ListFilter struct {
+	ProductID uint
+	VariantID uint
+	UserID    uint
+	Status    string
+}
+
+// Create сохраняет отзыв на модерацию. Вариант, если указан, должен принадлежать товару
+func (repo *ReviewRepository) Create(review *Review) (*Review, error) {
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var products int64
+		if err := tx.Table("products").Where("id = ? AND deleted_at IS NULL", review.ProductID).Count(&products).Error; err != nil {
+			return err
+		}
+		if products == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if review.VariantID != nil {
+			var variants int64
+			if err := tx.Table("product_variants").
+				Where("id = ? AND product_id = ? AND deleted_at IS NULL", *review.VariantID, review.ProductID).
+				Count(&variants).Error; err != nil {
+				return err
+			}
+			if variants == 0 {
+				return ErrVariantMismatch
+			}
+		}
+		review.Status = StatusPending
+		if err := tx.Create(review).Error; err != nil {
+			if isUniqueViolation(err) {
+				return ErrAlreadyReviewed
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (repo *ReviewRepository) GetByID(id uint) (*Review, error) {
+	var review Review
+	if err := repo.Database.DB.First(&review, id).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// List возвращает страницу отзывов, новые первыми, и общее число подходящих отзывов
+func (repo *ReviewRepository) List(filter ListFilter, limit, offset int) ([]Review, int64, error) {
+	query := repo.Database.DB.Model(&Review{})
+	if filter.ProductID != 0 {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+	if filter.VariantID != 0 {
+		query = query.Where("variant_id = ?", filter.VariantID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var reviews []Review
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&reviews).Error
+	return reviews, total, err
+}
+
+// UpdateContent меняет оценку и текст отзыва автором. Изменённый отзыв
+// снова уходит на модерацию и до неё не учитывается в рейтинге
+func (repo *ReviewRepository) UpdateContent(id, userID uint, rating uint8, text string) (*Review, error) {
+	var review Review
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, id).Error; err != nil {
+			return err
+		}
+		if review.UserID != userID {
+			return ErrNotAuthor
+		}
+		if review.Status == StatusApproved {
+			if err := applyRating(tx, &review, -1); err != nil {
+				return err
+			}
+		}
+		review.Rating, review.Text = rating, text
+		review.Status, review.RejectReason = StatusPending, ""
+		review.ModeratorID, review.ModeratedAt = 0, nil
+		return tx.Model(&review).Select("rating", "text", "status", "reject_reason", "moderator_id", "moderated_at").Updates(&review).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// Delete удаляет отзыв. userID == 0 — удаление модератором без проверки автора
+func (repo *ReviewRepository) Delete(id, userID uint) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var review Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, id).Error; err != nil {
+			return err
+		}
+		if userID != 0 && review.UserID != userID {
+			return ErrNotAuthor
+		}
+		if review.Status == StatusApproved {
+			if err := applyRating(tx, &review, -1); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&review).Error
+	})
+}
+
+// Moderate переводит отзывы в статус approved или rejected и поправляет рейтинги.
+// Возвращает изменённые отзывы и ID, которых нет
+func (repo *ReviewRepository) Moderate(ids []uint, status, reason string, moderatorID uint, now time.Time) ([]Review, []uint, error) {
+	var reviews []Review
+	var missing []uint
+	err := repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		// строки блокируются в порядке ID, чтобы параллельные пакеты не взаимоблокировались
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", ids).Order("id").Find(&reviews).Error; err != nil {
+			return err
+		}
+		found := make(map[uint]bool, len(reviews))
+		for i := range reviews {
+			review := &reviews[i]
+			found[review.ID] = true
+
+			switch {
+			case review.Status != StatusApproved && status == StatusApproved:
+				if err := applyRating(tx, review, 1); err != nil {
+					return err
+				}
+			case review.Status == StatusApproved && status != StatusApproved:
+				if err := applyRating(tx, review, -1); err != nil {
+					return err
+				}
+			}
+
+			review.Status, review.ModeratorID, review.ModeratedAt = status, moderatorID, &now
+			review.RejectReason = ""
+			if status == StatusRejected {
+				review.RejectReason = reason
+			}
+			if err := tx.Model(review).Select("status", "reject_reason", "moderator_id", "moderated_at").Updates(review).Error; err != nil {
+				return err
+			}
+		}
+		for _, id := range ids {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return reviews, missing, nil
+}
+
+// GetSummary возвращает агрегат товара или варианта, пустой при отсутствии отзывов
+func (repo *ReviewRepository) GetSummary(ownerType string, ownerID uint) (*RatingSummary, error) {
+	summary := RatingSummary{OwnerType: ownerType, OwnerID: ownerID}
+	err := repo.Database.DB.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).First(&summary).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// Recalculate пересчитывает агрегаты товара и его вариантов по одобренным отзывам.
+// Нужен для исправления расхождений, в обычной работе агрегаты инкрементальные
+func (repo *ReviewRepository) Recalculate(productID uint) error {
+	return repo.Database.DB.Transaction(func(tx *gorm.DB) error {
+		var variantIDs []uint
+		if err := tx.Unscoped().Table("product_variants").Where("product_id = ?", productID).Pluck("id", &variantIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("owner_type = ? AND owner_id = ?", OwnerProduct, productID).Delete(&RatingSummary{}).Error; err != nil {
+			return err
+		}
+		if len(variantIDs) > 0 {
+			if err := tx.Where("owner_type = ? AND owner_id IN ?", OwnerVariant, variantIDs).Delete(&RatingSummary{}).Error; err != nil {
+				return err
+			}
+		}
+
+		var reviews []Review
+		if err := tx.Where("product_id = ? AND status = ?", productID, StatusApproved).Find(&reviews).Error; err != nil {
+			return err
+		}
+		for i := range reviews {
+			if err := addToSummaries(tx, &reviews[i], 1); err != nil {
+				return err
+			}
+		}
+
+		if err := refreshProductRating(tx, productID); err != nil {
+			return err
+		}
+		for _, variantID := range variantIDs {
+			if err := refreshVariantRating(tx, variantID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// applyRating добавляет (delta = 1) или убирает (delta = -1) оценку отзыва
+// из агрегатов товара и варианта и обновляет их поля Rating
+func applyRating(tx *gorm.DB, review *Review, delta int64) error {
+	if err := addToSummaries(tx, review, delta); err != nil {
+		return err
+	}
+	if err := refreshProductRating(tx, review.ProductID); err != nil {
+		return err
+	}
+	if review.VariantID != nil {
+		return refreshVariantRating(tx, *review.VariantID)
+	}
+	return nil
+}
+
+func addToSummaries(tx *gorm.DB, review *Review, delta int64) error {
+	if err := addToSummary(tx, OwnerProduct, review.ProductID, review.Rating, delta); err != nil {
+		return err
+	}
+	if review.VariantID != nil {
+		return addToSummary(tx, OwnerVariant, *review.VariantID, review.Rating, delta)
+	}
+	return nil
+}
+
+func addToSummary(tx *gorm.DB, ownerType string, ownerID uint, rating uint8, delta int64) error {
+	var stars [MaxRating]int64
+	stars[rating-1] = delta
+	return tx.Exec(`
+		INSERT INTO review_rating_summaries (owner_type, owner_id, count, sum, stars_1, stars_2, stars_3, stars_4, stars_5, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
+		ON CONFLICT (owner_type, owner_id) DO UPDATE SET
+			count = review_rating_summaries.count + EXCLUDED.count,
+			sum = review_rating_summaries.sum + EXCLUDED.sum,
+			stars_1 = review_rating_summaries.stars_1 + EXCLUDED.stars_1,
+			stars_2 = review_rating_summaries.stars_2 + EXCLUDED.stars_2,
+			stars_3 = review_rating_summaries.stars_3 + EXCLUDED.stars_3,
+			stars_4 = review_rating_summaries.stars_4 + EXCLUDED.stars_4,
+			stars_5 = review_rating_summaries.stars_5 + EXCLUDED.stars_5,
+			updated_at = EXCLUDED.updated_at`,
+		ownerType, ownerID, delta, delta*int64(rating), stars[0], stars[1], stars[2], stars[3], stars[4]).Error
+}
+
+// ratingExpr — средняя оценка в сотых долях (4.35 -> 435), как её хранят Rating товара и варианта
+const ratingExpr = `COALESCE((SELECT ROUND(s.sum * 100.0 / s.count) FROM review_rating_summaries s
+	WHERE s.owner_type = ? AND s.owner_id = ? AND s.count > 0), 0)`
+
+func refreshProductRating(tx *gorm.DB, productID uint) error {
+	return tx.Exec(`UPDATE products SET rating = `+ratingExpr+`,
+		reviews_count = COALESCE((SELECT s.count FROM review_rating_summaries s WHERE s.owner_type = ? AND s.owner_id = ?), 0)
+		WHERE id = ?`,
+		OwnerProduct, productID, OwnerProduct, productID, productID).Error
+}
+
+func refreshVariantRating(tx *gorm.DB, variantID uint) error {
+	return tx.Exec(`UPDATE product_variants SET rating = `+ratingExpr+` WHERE id = ?`,
+		OwnerVariant, variantID, variantID).Error
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,290 @@
+package review
+
+import (
+	"admin/pkg/logger"
+	"admin/pkg/validation"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	ActionApprove = "approve"
+	ActionReject  = "reject"
+
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+type ReviewService struct {
+	ReviewRepository *ReviewRepository
+	validator        *ReviewValidator
+}
+
+func NewReviewService(reviewRepository *ReviewRepository, validator *ReviewValidator) *ReviewService {
+	if validator == nil {
+		validator = NewReviewValidator()
+	}
+	return &ReviewService{
+		ReviewRepository: reviewRepository,
+		validator:        validator,
+	}
+}
+
+// CreateReview сохраняет отзыв со статусом pending, в рейтинг он попадёт после одобрения
+func (s *ReviewService) CreateReview(ctx context.Context, req *CreateReviewRequest) (*ReviewMessage, error) {
+	review := &Review{
+		UserID:           uint(req.UserId),
+		ProductID:        uint(req.ProductId),
+		Rating:           clampRating(req.Rating),
+		Text:             strings.TrimSpace(req.Text),
+		VerifiedPurchase: req.VerifiedPurchase,
+	}
+	if req.VariantId != 0 {
+		variantID := uint(req.VariantId)
+		review.VariantID = &variantID
+	}
+	if err := s.validator.Validate(review); err != nil {
+		logger.Errorf("CreateReview validation error: %v", err)
+		return nil, validation.ToStatus(err)
+	}
+
+	created, err := s.ReviewRepository.Create(review)
+	if err != nil {
+		logger.Errorf("CreateReview error: %v", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "product %d not found", req.ProductId)
+		}
+		return nil, writeErrorToStatus(err)
+	}
+	return ConvertDBToPayload(created), nil
+}
+
+// UpdateReview меняет отзыв автором, после чего отзыв снова проходит модерацию
+func (s *ReviewService) UpdateReview(ctx context.Context, req *UpdateReviewRequest) (*ReviewMessage, error) {
+	if req.Id == 0 || req.UserId == 0 {
+		logger.Error("UpdateReview error: review ID and user ID required")
+		return nil, status.Error(codes.InvalidArgument, "review ID and user ID required")
+	}
+	existing, err := s.ReviewRepository.GetByID(uint(req.Id))
+	if err != nil {
+		logger.Errorf("UpdateReview error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	existing.Rating, existing.Text = clampRating(req.Rating), strings.TrimSpace(req.Text)
+	if err := s.validator.Validate(existing); err != nil {
+		logger.Errorf("UpdateReview validation error: %v", err)
+		return nil, validation.ToStatus(err)
+	}
+
+	updated, err := s.ReviewRepository.UpdateContent(uint(req.Id), uint(req.UserId), existing.Rating, existing.Text)
+	if err != nil {
+		logger.Errorf("UpdateReview error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	return ConvertDBToPayload(updated), nil
+}
+
+func (s *ReviewService) DeleteReview(ctx context.Context, req *DeleteReviewRequest) (*pb.Error, error) {
+	if req.Id == 0 {
+		logger.Error("DeleteReview error: review ID required")
+		return nil, status.Error(codes.InvalidArgument, "review ID required")
+	}
+	if err := s.ReviewRepository.Delete(uint(req.Id), uint(req.UserId)); err != nil {
+		logger.Errorf("DeleteReview error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	return &pb.Error{}, nil
+}
+
+func (s *ReviewService) GetReview(ctx context.Context, req *GetReviewRequest) (*ReviewMessage, error) {
+	review, err := s.ReviewRepository.GetByID(uint(req.Id))
+	if err != nil {
+		logger.Errorf("GetReview error: %v", err)
+		return nil, writeErrorToStatus(err)
+	}
+	return ConvertDBToPayload(review), nil
+}
+
+// ListReviews — витрина запрашивает approved, модерация — pending
+func (s *ReviewService) ListReviews(ctx context.Context, req *ListReviewsRequest) (*ListReviewsResponse, error) {
+	if req.Status != "" && !validStatus(req.Status) {
+		logger.Errorf("ListReviews error: unknown status %q", req.Status)
+		return nil, status.Errorf(codes.InvalidArgument, "unknown status %q", req.Status)
+	}
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+
+	reviews, total, err := s.ReviewRepository.List(ListFilter{
+		ProductID: uint(req.ProductId),
+		VariantID: uint(req.VariantId),
+		UserID:    uint(req.UserId),
+		Status:    req.Status,
+	}, limit, max(int(req.Offset), 0))
+	if err != nil {
+		logger.Errorf("ListReviews error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	result := make([]*ReviewMessage, 0, len(reviews))
+	for i := range reviews {
+		result = append(result, ConvertDBToPayload(&reviews[i]))
+	}
+	return &ListReviewsResponse{Reviews: result, Total: total}, nil
+}
+
+func (s *ReviewService) ApproveReview(ctx context.Context, req *ModerateReviewRequest) (*ReviewMessage, error) {
+	return s.moderateOne(req, StatusApproved)
+}
+
+func (s *ReviewService) RejectReview(ctx context.Context, req *ModerateReviewRequest) (*ReviewMessage, error) {
+	return s.moderateOne(req, StatusRejected)
+}
+
+func (s *ReviewService) moderateOne(req *ModerateReviewRequest, newStatus string) (*ReviewMessage, error) {
+	if req.Id == 0 {
+		logger.Error("Moderate review error: review ID required")
+		return nil, status.Error(codes.InvalidArgument, "review ID required")
+	}
+	reviews, missing, err := s.ReviewRepository.Moderate([]uint{uint(req.Id)}, newStatus, strings.TrimSpace(req.Reason), uint(req.ModeratorId), time.Now())
+	if err != nil {
+		logger.Errorf("Moderate review %d error: %v", req.Id, err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if len(missing) > 0 {
+		logger.Errorf("Moderate review error: review %d not found", req.Id)
+		return nil, status.Errorf(codes.NotFound, "review %d not found", req.Id)
+	}
+	return ConvertDBToPayload(&reviews[0]), nil
+}
+
+// BulkModerate одобряет или отклоняет пачку отзывов в одной транзакции.
+// Отсутствующие ID не прерывают операцию и возвращаются в MissingIds
+func (s *ReviewService) BulkModerate(ctx context.Context, req *BulkModerateRequest) (*BulkModerateResponse, error) {
+	var newStatus string
+	switch req.Action {
+	case ActionApprove:
+		newStatus = StatusApproved
+	case ActionReject:
+		newStatus = StatusRejected
+	default:
+		logger.Errorf("BulkModerate error: unknown action %q", req.Action)
+		return nil, status.Errorf(codes.InvalidArgument, "action must be %s or %s", ActionApprove, ActionReject)
+	}
+	if len(req.Ids) == 0 || len(req.Ids) > MaxBulkSize {
+		logger.Errorf("BulkModerate error: %d ids", len(req.Ids))
+		return nil, status.Errorf(codes.InvalidArgument, "from 1 to %d review IDs required", MaxBulkSize)
+	}
+
+	ids := make([]uint, len(req.Ids))
+	for i, id := range req.Ids {
+		ids[i] = uint(id)
+	}
+	reviews, missing, err := s.ReviewRepository.Moderate(ids, newStatus, strings.TrimSpace(req.Reason), uint(req.ModeratorId), time.Now())
+	if err != nil {
+		logger.Errorf("BulkModerate error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &BulkModerateResponse{
+		Reviews:    make([]*ReviewMessage, 0, len(reviews)),
+		MissingIds: make([]uint32, 0, len(missing)),
+	}
+	for i := range reviews {
+		response.Reviews = append(response.Reviews, ConvertDBToPayload(&reviews[i]))
+	}
+	for _, id := range missing {
+		response.MissingIds = append(response.MissingIds, uint32(id))
+	}
+	return response, nil
+}
+
+func (s *ReviewService) GetRatingSummary(ctx context.Context, req *RatingSummaryRequest) (*RatingSummaryMessage, error) {
+	if req.OwnerType != OwnerProduct && req.OwnerType != OwnerVariant {
+		logger.Errorf("GetRatingSummary error: owner type %q", req.OwnerType)
+		return nil, status.Errorf(codes.InvalidArgument, "owner_type must be %s or %s", OwnerProduct, OwnerVariant)
+	}
+	summary, err := s.ReviewRepository.GetSummary(req.OwnerType, uint(req.OwnerId))
+	if err != nil {
+		logger.Errorf("GetRatingSummary error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	histogram := summary.Histogram()
+	return &RatingSummaryMessage{
+		OwnerType: summary.OwnerType,
+		OwnerId:   uint32(summary.OwnerID),
+		Count:     summary.Count,
+		Average:   summary.Average(),
+		Histogram: histogram[:],
+	}, nil
+}
+
+// RecalculateRatings пересобирает агрегаты товара с нуля
+func (s *ReviewService) RecalculateRatings(ctx context.Context, req *RecalculateRatingsRequest) (*RatingSummaryMessage, error) {
+	if req.ProductId == 0 {
+		logger.Error("RecalculateRatings error: product ID required")
+		return nil, status.Error(codes.InvalidArgument, "product ID required")
+	}
+	if err := s.ReviewRepository.Recalculate(uint(req.ProductId)); err != nil {
+		logger.Errorf("RecalculateRatings error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return s.GetRatingSummary(ctx, &RatingSummaryRequest{OwnerType: OwnerProduct, OwnerId: req.ProductId})
+}
+
+func writeErrorToStatus(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "review not found")
+	case errors.Is(err, ErrAlreadyReviewed):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrVariantMismatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrNotAuthor):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func validStatus(s string) bool {
+	return s == StatusPending || s == StatusApproved || s == StatusRejected
+}
+
+// clampRating не даёт uint32 из запроса переполнить uint8: 256 не должно стать 0
+func clampRating(rating uint32) uint8 {
+	if rating > MaxRating {
+		return MaxRating + 1
+	}
+	return uint8(rating)
+}
+
+func ConvertDBToPayload(review *Review) *ReviewMessage {
+	msg := &ReviewMessage{
+		Id:               uint32(review.ID),
+		UserId:           uint32(review.UserID),
+		ProductId:        uint32(review.ProductID),
+		Rating:           uint32(review.Rating),
+		Text:             review.Text,
+		Status:           review.Status,
+		VerifiedPurchase: review.VerifiedPurchase,
+		RejectReason:     review.RejectReason,
+		ModeratorId:      uint32(review.ModeratorID),
+		CreatedAt:        review.CreatedAt.Unix(),
+	}
+	if review.VariantID != nil {
+		msg.VariantId = uint32(*review.VariantID)
+	}
+	if review.ModeratedAt != nil {
+		msg.ModeratedAt = review.ModeratedAt.Unix()
+	}
+	return msg
+}
@@ -0,0 +1,32 @@
+package review
+
+import "admin/pkg/validation"
+
+// DefaultRules — правила, которые проверяются для любого отзыва
+func DefaultRules() []validation.Rule[*Review] {
+	return []validation.Rule[*Review]{
+		validation.Check("user_id", "is required", func(r *Review) bool { return r.UserID != 0 }),
+		validation.Check("product_id", "is required", func(r *Review) bool { return r.ProductID != 0 }),
+		validation.Check("rating", "must be from 1 to 5", func(r *Review) bool {
+			return r.Rating >= MinRating && r.Rating <= MaxRating
+		}),
+		validation.MaxLength("text", MaxTextLength, func(r *Review) string { return r.Text }),
+	}
+}
+
+type ReviewValidator struct {
+	rules *validation.Validator[*Review]
+}
+
+// NewReviewValidator создаёт валидатор с DefaultRules и дополнительными правилами,
+// например фильтром запрещённых слов
+func NewReviewValidator(extra ...validation.Rule[*Review]) *ReviewValidator {
+	return &ReviewValidator{
+		rules: validation.New(DefaultRules()...).Add(extra...),
+	}
+}
+
+// Validate возвращает validation.Errors со всеми нарушениями
+func (v *ReviewValidator) Validate(review *Review) error {
+	return v.rules.Validate(review)
+}
@@ -0,0 +1,21 @@
+package slug
+
+import "gorm.io/gorm"
+
+const (
+	EntityBrand    = "brand"
+	EntityCategory = "category"
+)
+
+// Redirect хранит устаревший слаг сущности. Ссылается на ID, а не на новый слаг,
+// поэтому цепочка переименований всегда ведёт на актуальный адрес
+type Redirect struct {
+	gorm.Model
+	Entity   string `gorm:"type:varchar(50);not null;uniqueIndex:idx_slug_redirects_entity_slug"`
+	OldSlug  string `gorm:"type:varchar(255);not null;uniqueIndex:idx_slug_redirects_entity_slug"`
+	EntityID uint   `gorm:"not null;index"`
+}
+
+func (Redirect) TableName() string {
+	return "slug_redirects"
+}
@@ -0,0 +1,163 @@
+package slug
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Функции принимают *gorm.DB, чтобы вызываться внутри транзакций репозиториев брендов и категорий
+
+// maxAttempts — сколько раз Assign подбирает слаг заново, если параллельная запись заняла кандидата
+const maxAttempts = 5
+
+// Create подбирает слаг новой сущности из запрошенного значения или, если оно пустое, из имени
+// и вызывает write с ним. write должен записать строку с этим слагом
+func Create(tx *gorm.DB, table, entity, requested, name string, write func(tx *gorm.DB, slug string) error) (string, error) {
+	base := Make(requested)
+	if base == "" {
+		base = Make(name)
+	}
+	return Assign(tx, table, entity, base, 0, write)
+}
+
+// Change подбирает слаг при обновлении сущности id и вызывает write с ним.
+// Пустой requested оставляет текущий слаг, name нужен сущностям, созданным до появления слагов.
+// При смене слага старый сохраняется для редиректа
+func Change(tx *gorm.DB, table, entity string, id uint, requested, name string, write func(tx *gorm.DB, slug string) error) (string, error) {
+	var current string
+	if err := tx.Table(table).Where("id = ?", id).Pluck("slug", &current).Error; err != nil {
+		return "", err
+	}
+
+	newSlug := Make(requested)
+	if newSlug == "" {
+		newSlug = current
+	}
+	if newSlug == "" {
+		newSlug = Make(name)
+	}
+	if newSlug == current {
+		return current, write(tx, current)
+	}
+
+	return Assign(tx, table, entity, newSlug, id, func(tx *gorm.DB, slug string) error {
+		if err := write(tx, slug); err != nil {
+			return err
+		}
+		if err := Record(tx, entity, current, id); err != nil {
+			return err
+		}
+		return Release(tx, entity, slug)
+	})
+}
+
+// Assign выбирает свободный слаг через Unique и вызывает write внутри savepoint.
+// Unique не защищает от параллельной записи того же слага: её отсекает уникальный индекс
+// idx_<table>_slug, savepoint откатывается, и слаг подбирается заново
+func Assign(tx *gorm.DB, table, entity, base string, excludeID uint, write func(tx *gorm.DB, slug string) error) (string, error) {
+	for attempt := 1; ; attempt++ {
+		candidate, err := Unique(tx, table, entity, base, excludeID)
+		if err != nil {
+			return "", err
+		}
+		err = tx.Transaction(func(tx *gorm.DB) error {
+			return write(tx, candidate)
+		})
+		if err == nil {
+			return candidate, nil
+		}
+		if !IsConflict(err, table) || attempt == maxAttempts {
+			return "", err
+		}
+	}
+}
+
+// IsConflict сообщает, что запись нарушила уникальный индекс слага таблицы
+func IsConflict(err error, table string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_"+table+"_slug"
+}
+
+// Unique возвращает base или base-N, не занятый другими строками таблицы (включая мягко удалённые)
+// и не принадлежащий истории слагов другой сущности, чтобы старые ссылки не уводили на чужую страницу
+func Unique(tx *gorm.DB, table, entity, base string, excludeID uint) (string, error) {
+	if base == "" { // имя целиком из символов, которые не попадают в слаг
+		base = entity
+	}
+
+	candidate := base
+	for i := 2; ; i++ {
+		var count int64
+		err := tx.Table(table).
+			Where("slug = ? AND id <> ?", candidate, excludeID).
+			Count(&count).Error
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			err = tx.Model(&Redirect{}).
+				Where("entity = ? AND old_slug = ? AND entity_id <> ?", entity, candidate, excludeID).
+				Count(&count).Error
+			if err != nil {
+				return "", err
+			}
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// Record сохраняет старый слаг сущности для редиректа
+func Record(tx *gorm.DB, entity, oldSlug string, entityID uint) error {
+	if oldSlug == "" {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity"}, {Name: "old_slug"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"entity_id": entityID, "deleted_at": nil}),
+	}).Create(&Redirect{Entity: entity, OldSlug: oldSlug, EntityID: entityID}).Error
+}
+
+// Release удаляет редирект, когда слаг снова становится актуальным
+func Release(tx *gorm.DB, entity, slug string) error {
+	return tx.Unscoped().
+		Where("entity = ? AND old_slug = ?", entity, slug).
+		Delete(&Redirect{}).Error
+}
+
+// Resolve возвращает ID сущности, которой раньше принадлежал слаг
+func Resolve(tx *gorm.DB, entity, oldSlug string) (uint, error) {
+	var redirect Redirect
+	result := tx.First(&redirect, "entity = ? AND old_slug = ?", entity, oldSlug)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return redirect.EntityID, nil
+}
+
+// Backfill проставляет слаги строкам таблицы, созданным до появления слагов
+func Backfill(tx *gorm.DB, table, entity string) error {
+	var rows []struct {
+		ID   uint
+		Name string
+	}
+	if err := tx.Table(table).Select("id, name").Where("slug IS NULL OR slug = ''").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		_, err := Assign(tx, table, entity, Make(row.Name), row.ID, func(tx *gorm.DB, slug string) error {
+			return tx.Table(table).Where("id = ?", row.ID).Update("slug", slug).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
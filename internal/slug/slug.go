@@ -0,0 +1,50 @@
+package slug
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxLength = 200
+
+// транслитерация русского, казахского и белорусского алфавитов
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+	'ә': "a", 'ғ': "g", 'қ': "k", 'ң': "n", 'ө': "o", 'ұ': "u", 'ү': "u", 'һ': "h", 'і': "i",
+	'ў': "u",
+}
+
+// Make строит URL-слаг: нижний регистр, латиница, цифры и дефисы
+// Example: "Кроссовки Nike Air" -> "krossovki-nike-air"
+func Make(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case translit[r] != "":
+			b.WriteString(translit[r])
+			dash = false
+		default:
+			if _, skip := translit[r]; skip {
+				continue // ъ и ь не разрывают слово
+			}
+			if !dash && b.Len() > 0 {
+				b.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+
+	result := strings.Trim(b.String(), "-")
+	if len(result) > maxLength {
+		result = strings.TrimRight(result[:maxLength], "-")
+	}
+	return result
+}
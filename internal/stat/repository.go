@@ -1,6 +1,7 @@
 package stat
 
 import (
+	"context"
 	"time"
 
 	"admin/pkg/db"
@@ -35,6 +36,24 @@ func (repo *StatRepository) AddClick(linkId uint) {
 	}
 }
 
+// RecordClick учитывает клик в транзакции из ctx (db.WithTx), если она есть
+func (repo *StatRepository) RecordClick(ctx context.Context, linkId uint, at time.Time) error {
+	tx := repo.Db.Tx(ctx)
+	var stat Stat
+	currentDate := datatypes.Date(at)
+	if err := tx.Where("link_id = ? and date = ?", linkId, currentDate).Limit(1).Find(&stat).Error; err != nil {
+		return err
+	}
+	if stat.ID == 0 {
+		return tx.Create(&Stat{
+			LinkId: linkId,
+			Clicks: 1,
+			Date:   currentDate,
+		}).Error
+	}
+	return tx.Model(&stat).Update("clicks", gorm.Expr("clicks + 1")).Error
+}
+
 // TODO Приходит ответ период как структура выбора всего из таблицы!
 func (repo *StatRepository) GetStats(by string, from, to time.Time) []GetStatResponse { //почему слайс???
 	var stats []GetStatResponse
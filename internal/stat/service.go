@@ -1,10 +1,16 @@
 package stat
 
 import (
+	"admin/pkg/db"
+	"admin/pkg/event"
 	"admin/pkg/logger"
 	"context"
+	"time"
 
 	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
 )
 
 const (
@@ -15,27 +21,58 @@ const (
 // StatServiceDeps содержит зависимости сервиса статистики
 type StatServiceDeps struct {
 	StatRepository *StatRepository
+	Bus            *event.Bus // если задана, клики учитываются обработчиком event.LinkVisited
 }
 
 // StatService реализует StatServiceServer (из protobuf)
 type StatService struct {
 	pb.UnimplementedStatServiceServer // Встраиваем, чтобы обеспечить forward compatibility
 	StatRepository                    *StatRepository
+	Bus                               *event.Bus
 }
 
 // NewStatService создаёт новый сервис статистики
 func NewStatService(deps *StatServiceDeps) *StatService {
 	return &StatService{
 		StatRepository: deps.StatRepository,
+		Bus:            deps.Bus,
 	}
 }
 
+// Subscribe подписывает сервис на переходы по ссылкам. Клик учитывается синхронным
+// обработчиком в транзакции публикующего: если записать его не удалось, публикация
+// возвращает ошибку и клик не теряется молча
+func (s *StatService) Subscribe(bus *event.Bus) {
+	event.Hook(bus, event.LinkVisited, "stat.clicks", func(ctx context.Context, visit event.LinkVisitedData) error {
+		return s.StatRepository.RecordClick(ctx, visit.LinkID, visit.VisitedAt)
+	})
+}
+
 // AddClick обрабатывает gRPC-запрос на добавление клика
-// обработка ошибок фиктивна потому что иначе grpc не хочет
 func (s *StatService) AddClick(ctx context.Context, req *pb.ClickRequest) (*pb.ClickResponse, error) {
 	linkId := req.LinkId
 	logger.Infof("Обрабатываем клик по ссылке ID %d", linkId)
-	s.StatRepository.AddClick(uint(linkId))
+	visit := event.LinkVisitedData{LinkID: uint(linkId), VisitedAt: time.Now()}
+	if s.Bus == nil {
+		if err := s.StatRepository.RecordClick(ctx, visit.LinkID, visit.VisitedAt); err != nil {
+			logger.Errorf("AddClick error: %v", err)
+			return nil, status.Error(codes.Internal, "failed to record click")
+		}
+		return &pb.ClickResponse{}, nil
+	}
 
+	// клик — это переход по ссылке: его видят все подписчики, а не только статистика.
+	// Синхронные обработчики выполняются в транзакции, асинхронные узнают о клике после фиксации
+	err := s.StatRepository.DB.Transaction(func(tx *gorm.DB) error {
+		return event.Dispatch(db.WithTx(ctx, tx), s.Bus, event.LinkVisited, visit)
+	})
+	if err != nil {
+		logger.Errorf("AddClick error: %v", err)
+		return nil, status.Error(codes.Internal, "failed to record click")
+	}
+	if err := event.Notify(ctx, s.Bus, event.LinkVisited, visit); err != nil {
+		// клик уже учтён, не дошло только уведомление подписчиков
+		logger.Errorf("AddClick error: notifying subscribers: %v", err)
+	}
 	return &pb.ClickResponse{}, nil
 }
@@ -0,0 +1,88 @@
+package variantMatrix
+
+import (
+	"admin/internal/slug"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Cartesian возвращает все сочетания значений осей в порядке осей
+func Cartesian(axes []Axis) ([]Combination, error) {
+	if len(axes) == 0 {
+		return nil, ErrNoAxes
+	}
+	total := 1
+	for _, axis := range axes {
+		if len(axis.Values) == 0 {
+			return nil, fmt.Errorf("axis %s has no values", axis.Name)
+		}
+		total *= len(axis.Values)
+		if total > MaxCombinations {
+			return nil, fmt.Errorf("%w: more than %d", ErrTooManyCombinations, MaxCombinations)
+		}
+	}
+
+	combinations := make([]Combination, 0, total)
+	indexes := make([]int, len(axes))
+	for {
+		values := make([]string, len(axes))
+		for i, axis := range axes {
+			values[i] = axis.Values[indexes[i]]
+		}
+		combinations = append(combinations, Combination{Values: values})
+
+		// увеличиваем «счётчик» с последней оси, как в одометре
+		i := len(axes) - 1
+		for ; i >= 0; i-- {
+			indexes[i]++
+			if indexes[i] < len(axes[i].Values) {
+				break
+			}
+			indexes[i] = 0
+		}
+		if i < 0 {
+			return combinations, nil
+		}
+	}
+}
+
+// BuildSKU подставляет значения в шаблон артикула. Поддерживаются
+// {product} — ID товара, {axes} — значения всех осей через дефис,
+// {n} — порядковый номер сочетания с 1 и {<имя оси>} — значение оси
+func BuildSKU(pattern string, productID uint, axes []Axis, values []string, n int) (string, error) {
+	if pattern == "" {
+		pattern = DefaultSKUPattern
+	}
+
+	parts := make([]string, len(values))
+	replacements := []string{
+		"{product}", strconv.FormatUint(uint64(productID), 10),
+		"{n}", strconv.Itoa(n),
+	}
+	for i, axis := range axes {
+		parts[i] = skuPart(values[i])
+		replacements = append(replacements, "{"+axis.Name+"}", parts[i])
+	}
+	replacements = append(replacements, "{axes}", strings.Join(parts, "-"))
+
+	sku := strings.NewReplacer(replacements...).Replace(pattern)
+	if sku == "" || len(sku) > maxSKULength || strings.ContainsAny(sku, "{}") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidSKU, sku)
+	}
+	return sku, nil
+}
+
+// skuPart приводит значение оси к виду для артикула: латиница в верхнем регистре, цифры и дефисы
+func skuPart(value string) string {
+	return strings.ToUpper(slug.Make(value))
+}
+
+// Key — ключ сочетания для поиска уже существующих вариантов
+func Key(values []string) string {
+	normalized := make([]string, len(values))
+	for i, v := range values {
+		normalized[i] = strings.ToLower(strings.TrimSpace(v))
+	}
+	return strings.Join(normalized, "\x1f")
+}
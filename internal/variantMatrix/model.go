@@ -0,0 +1,43 @@
+package variantMatrix
+
+import (
+	"admin/internal/attribute"
+	"errors"
+)
+
+const (
+	// MaxCombinations ограничивает размер матрицы за один вызов
+	MaxCombinations = 1000
+	// DefaultSKUPattern — артикул из ID товара и значений всех осей по порядку
+	DefaultSKUPattern = "{product}-{axes}"
+	maxSKULength      = 100
+)
+
+// Оси, которые пишутся в старые поля варианта, если в схеме категории нет атрибута с таким кодом
+const (
+	AxisSize     = "size"
+	AxisColor    = "color"
+	AxisMaterial = "material"
+)
+
+var (
+	ErrNoAxes              = errors.New("at least one option axis is required")
+	ErrTooManyCombinations = errors.New("too many combinations")
+	ErrUnknownAxis         = errors.New("axis is neither a category attribute nor a variant field")
+	ErrDuplicateSKU        = errors.New("SKU pattern produces duplicate SKUs")
+	ErrInvalidSKU          = errors.New("generated SKU is empty or too long")
+)
+
+// Axis — ось опций, например размер или цвет
+type Axis struct {
+	Name   string
+	Values []string
+	// Attribute заполнен, если ось — атрибут из схемы категории, иначе ось пишется в поле варианта
+	Attribute *attribute.Definition
+}
+
+// Combination — одно сочетание значений осей, Values[i] относится к Axes[i]
+type Combination struct {
+	Values []string
+	SKU    string
+}
@@ -0,0 +1,40 @@
+package variantMatrix
+
+import (
+	"admin/internal/attribute"
+
+	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+)
+
+// Сообщения GenerateVariants. Когда RPC появится в admin-proto,
+// эти типы заменяются сгенерированными.
+
+type OptionAxisMessage struct {
+	Name   string   `json:"name"` // код атрибута категории или size, color, material
+	Values []string `json:"values"`
+}
+
+type GenerateVariantsRequest struct {
+	ProductId       uint32               `json:"product_id"`
+	Axes            []*OptionAxisMessage `json:"axes"`
+	SkuPattern      string               `json:"sku_pattern"`      // по умолчанию "{product}-{axes}", см. BuildSKU
+	DefaultPrice    int64                `json:"default_price"`    // в копейках
+	DefaultDiscount int64                `json:"default_discount"` // в копейках
+	DefaultStock    uint32               `json:"default_stock"`
+	Inactive        bool                 `json:"inactive"` // создать варианты выключенными
+	DryRun          bool                 `json:"dry_run"`  // только показать, что будет создано
+	// общие для всех вариантов значения атрибутов, не являющихся осями.
+	// Обязательные атрибуты схемы задаются осью или здесь
+	Attributes []*attribute.AttributeValueMessage `json:"attributes"`
+}
+
+type SkippedCombinationMessage struct {
+	Sku     string            `json:"sku"`
+	Options map[string]string `json:"options"`
+	Reason  string            `json:"reason"`
+}
+
+type GenerateVariantsResponse struct {
+	Created []*pb.ProductVariant         `json:"created"` // при dry_run — варианты без ID
+	Skipped []*SkippedCombinationMessage `json:"skipped"`
+}
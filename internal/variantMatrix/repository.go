@@ -0,0 +1,120 @@
+package variantMatrix
+
+import (
+	"admin/internal/attribute"
+	"admin/internal/productVariant"
+	"admin/pkg/db"
+	"strconv"
+)
+
+type VariantMatrixRepository struct {
+	Database *db.Db
+}
+
+func NewVariantMatrixRepository(database *db.Db) *VariantMatrixRepository {
+	return &VariantMatrixRepository{
+		Database: database,
+	}
+}
+
+// GetProductCategory возвращает категорию товара
+func (repo *VariantMatrixRepository) GetProductCategory(productID uint) (uint, error) {
+	var row struct {
+		CategoryID uint
+	}
+	result := repo.Database.DB.Table("products").
+		Select("category_id").
+		Where("id = ? AND deleted_at IS NULL", productID).
+		Take(&row)
+	return row.CategoryID, result.Error
+}
+
+// ExistingKeys возвращает ключи сочетаний осей у существующих вариантов товара.
+// Вариант без значения хотя бы одной оси не участвует: с ним нельзя однозначно сравнить
+func (repo *VariantMatrixRepository) ExistingKeys(productID uint, axes []Axis) (map[string]bool, error) {
+	var variants []productVariant.ProductVariant
+	if err := repo.Database.DB.Where("product_id = ?", productID).Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool, len(variants))
+	if len(variants) == 0 {
+		return keys, nil
+	}
+
+	variantIDs := make([]uint, 0, len(variants))
+	for _, v := range variants {
+		variantIDs = append(variantIDs, v.ID)
+	}
+	var definitionIDs []uint
+	for _, axis := range axes {
+		if axis.Attribute != nil {
+			definitionIDs = append(definitionIDs, axis.Attribute.ID)
+		}
+	}
+	// variantID -> definitionID -> значения
+	attributeValues := make(map[uint]map[uint][]string)
+	if len(definitionIDs) > 0 {
+		var values []attribute.Value
+		if err := repo.Database.DB.
+			Where("variant_id IN ? AND definition_id IN ?", variantIDs, definitionIDs).
+			Find(&values).Error; err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			if attributeValues[v.VariantID] == nil {
+				attributeValues[v.VariantID] = make(map[uint][]string)
+			}
+			attributeValues[v.VariantID][v.DefinitionID] = append(attributeValues[v.VariantID][v.DefinitionID], valueString(v))
+		}
+	}
+
+	for _, v := range variants {
+		combination := make([]string, 0, len(axes))
+		for _, axis := range axes {
+			value, ok := axisValue(v, axis, attributeValues[v.ID])
+			if !ok {
+				break
+			}
+			combination = append(combination, value)
+		}
+		if len(combination) == len(axes) {
+			keys[Key(combination)] = true
+		}
+	}
+	return keys, nil
+}
+
+func axisValue(v productVariant.ProductVariant, axis Axis, values map[uint][]string) (string, bool) {
+	if axis.Attribute != nil {
+		if found := values[axis.Attribute.ID]; len(found) == 1 {
+			return found[0], true
+		}
+		return "", false
+	}
+	switch axis.Name {
+	case AxisSize:
+		if len(v.Sizes) == 1 {
+			return strconv.FormatUint(uint64(v.Sizes[0]), 10), true
+		}
+	case AxisColor:
+		if len(v.Colors) == 1 {
+			return v.Colors[0], true
+		}
+	case AxisMaterial:
+		if v.Material != "" {
+			return v.Material, true
+		}
+	}
+	return "", false
+}
+
+func valueString(v attribute.Value) string {
+	switch {
+	case v.NumberValue.Valid:
+		return v.NumberValue.Decimal.String()
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	default:
+		return v.StringValue
+	}
+}
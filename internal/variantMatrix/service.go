@@ -0,0 +1,287 @@
+package variantMatrix
+
+import (
+	"admin/internal/attribute"
+	"admin/internal/productVariant"
+	"admin/pkg/logger"
+	"admin/pkg/money"
+	"admin/pkg/validation"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "github.com/ShopOnGO/admin-proto/pkg/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	SkipReasonExists   = "combination already exists"
+	SkipReasonSKUTaken = "SKU is already taken"
+)
+
+// зарезервированные плейсхолдеры шаблона артикула
+var reservedAxisNames = map[string]bool{"product": true, "axes": true, "n": true}
+
+type VariantMatrixService struct {
+	VariantMatrixRepository  *VariantMatrixRepository
+	ProductVariantRepository *productVariant.ProductVariantRepository
+	AttributeRepository      *attribute.AttributeRepository
+	variantValidator         *productVariant.ProductVariantValidator
+	attributeValidator       *attribute.AttributeValidator
+}
+
+func NewVariantMatrixService(
+	variantMatrixRepository *VariantMatrixRepository,
+	productVariantRepository *productVariant.ProductVariantRepository,
+	attributeRepository *attribute.AttributeRepository,
+	variantValidator *productVariant.ProductVariantValidator,
+	attributeValidator *attribute.AttributeValidator,
+) *VariantMatrixService {
+	return &VariantMatrixService{
+		VariantMatrixRepository:  variantMatrixRepository,
+		ProductVariantRepository: productVariantRepository,
+		AttributeRepository:      attributeRepository,
+		variantValidator:         variantValidator,
+		attributeValidator:       attributeValidator,
+	}
+}
+
+// GenerateVariants создаёт варианты товара для всех сочетаний осей опций.
+// Сочетания, которые уже есть у товара, и занятые артикулы пропускаются
+func (s *VariantMatrixService) GenerateVariants(ctx context.Context, req *GenerateVariantsRequest) (*GenerateVariantsResponse, error) {
+	if req.ProductId == 0 {
+		logger.Error("GenerateVariants error: product ID is required")
+		return nil, status.Error(codes.InvalidArgument, "product ID is required")
+	}
+	productID := uint(req.ProductId)
+
+	categoryID, err := s.VariantMatrixRepository.GetProductCategory(productID)
+	if err != nil {
+		logger.Errorf("GenerateVariants error: product %d: %v", productID, err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "product not found")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	schema, err := s.AttributeRepository.GetCategorySchema(categoryID)
+	if err != nil {
+		logger.Errorf("GenerateVariants error: category schema %d: %v", categoryID, err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	axes, err := s.buildAxes(schema, req.Axes)
+	if err != nil {
+		logger.Errorf("GenerateVariants error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	shared := attribute.ValuesInput(req.Attributes)
+	for _, axis := range axes {
+		if _, ok := shared[axis.Name]; ok {
+			logger.Errorf("GenerateVariants error: attribute %s is both an axis and a shared value", axis.Name)
+			return nil, status.Errorf(codes.InvalidArgument, "attribute %s is both an axis and a shared value", axis.Name)
+		}
+	}
+	combinations, err := Cartesian(axes)
+	if err != nil {
+		logger.Errorf("GenerateVariants error: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	skus := make([]string, 0, len(combinations))
+	seen := make(map[string]bool, len(combinations))
+	for i := range combinations {
+		sku, err := BuildSKU(req.SkuPattern, productID, axes, combinations[i].Values, i+1)
+		if err != nil {
+			logger.Errorf("GenerateVariants error: %v", err)
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if seen[sku] {
+			logger.Errorf("GenerateVariants error: %v: %s", ErrDuplicateSKU, sku)
+			return nil, status.Errorf(codes.InvalidArgument, "%v: %s", ErrDuplicateSKU, sku)
+		}
+		seen[sku] = true
+		combinations[i].SKU = sku
+		skus = append(skus, sku)
+	}
+
+	existing, err := s.VariantMatrixRepository.ExistingKeys(productID, axes)
+	if err != nil {
+		logger.Errorf("GenerateVariants error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	taken, err := s.ProductVariantRepository.SKUsExist(skus)
+	if err != nil {
+		logger.Errorf("GenerateVariants error: %v", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &GenerateVariantsResponse{}
+	var variants []*productVariant.ProductVariant
+	var attributeValues [][]attribute.Value
+	for _, c := range combinations {
+		reason := ""
+		switch {
+		case existing[Key(c.Values)]:
+			reason = SkipReasonExists
+		case taken[c.SKU]:
+			reason = SkipReasonSKUTaken
+		}
+		if reason != "" {
+			resp.Skipped = append(resp.Skipped, &SkippedCombinationMessage{
+				Sku:     c.SKU,
+				Options: options(axes, c.Values),
+				Reason:  reason,
+			})
+			continue
+		}
+
+		variant, values, err := s.buildVariant(req, productID, schema, shared, axes, c)
+		if err != nil {
+			logger.Errorf("GenerateVariants attribute validation error: %v", err)
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err := s.variantValidator.Validate(variant); err != nil {
+			logger.Errorf("GenerateVariants validation error: %v", err)
+			return nil, validation.ToStatus(err)
+		}
+		variants = append(variants, variant)
+		attributeValues = append(attributeValues, values)
+	}
+
+	if !req.DryRun && len(variants) > 0 {
+		err := s.ProductVariantRepository.CreateBatch(productID, variants, func(tx *gorm.DB, i int, v *productVariant.ProductVariant) error {
+			return attribute.CreateValues(tx, v.ID, attributeValues[i])
+		})
+		if err != nil {
+			logger.Errorf("GenerateVariants error: %v", err)
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		logger.Infof("Generated %d variants for product %d, skipped %d", len(variants), productID, len(resp.Skipped))
+	}
+
+	resp.Created = make([]*pb.ProductVariant, 0, len(variants))
+	for _, v := range variants {
+		resp.Created = append(resp.Created, productVariant.ConvertDBToProto(v))
+	}
+	return resp, nil
+}
+
+// buildAxes сопоставляет оси с атрибутами категории или полями варианта и нормализует значения
+func (s *VariantMatrixService) buildAxes(schema []attribute.Definition, messages []*OptionAxisMessage) ([]Axis, error) {
+	byCode := make(map[string]attribute.Definition, len(schema))
+	for _, d := range schema {
+		byCode[d.Code] = d
+	}
+
+	axes := make([]Axis, 0, len(messages))
+	names := make(map[string]bool, len(messages))
+	for _, m := range messages {
+		name := strings.ToLower(strings.TrimSpace(m.Name))
+		if name == "" || reservedAxisNames[name] || names[name] {
+			return nil, fmt.Errorf("invalid or duplicate axis name %q", m.Name)
+		}
+		names[name] = true
+
+		axis := Axis{Name: name}
+		if d, ok := byCode[name]; ok {
+			d := d
+			axis.Attribute = &d
+		} else if name != AxisSize && name != AxisColor && name != AxisMaterial {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAxis, name)
+		}
+
+		unique := make(map[string]bool, len(m.Values))
+		for _, raw := range m.Values {
+			value, err := s.normalizeValue(axis, strings.TrimSpace(raw))
+			if err != nil {
+				return nil, err
+			}
+			if value == "" || unique[Key([]string{value})] {
+				continue
+			}
+			unique[Key([]string{value})] = true
+			axis.Values = append(axis.Values, value)
+		}
+		axes = append(axes, axis)
+	}
+	return axes, nil
+}
+
+func (s *VariantMatrixService) normalizeValue(axis Axis, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if axis.Attribute != nil {
+		single := *axis.Attribute
+		single.Required = false
+		values, err := s.attributeValidator.ValidateValues([]attribute.Definition{single}, map[string][]string{single.Code: {value}})
+		if err != nil {
+			return "", err
+		}
+		return valueString(values[0]), nil
+	}
+	if axis.Name == AxisSize {
+		size, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("size must be a positive integer: %q", value)
+		}
+		return strconv.FormatUint(size, 10), nil
+	}
+	return value, nil
+}
+
+// buildVariant собирает вариант сочетания. Значения атрибутов — оси и общие значения запроса —
+// проверяются по всей схеме категории, как при создании одного варианта
+func (s *VariantMatrixService) buildVariant(req *GenerateVariantsRequest, productID uint, schema []attribute.Definition, shared map[string][]string, axes []Axis, c Combination) (*productVariant.ProductVariant, []attribute.Value, error) {
+	variant := &productVariant.ProductVariant{
+		ProductID: productID,
+		SKU:       c.SKU,
+		Price:     money.CentsToDecimal(req.DefaultPrice),
+		Discount:  money.CentsToDecimal(req.DefaultDiscount),
+		Stock:     req.DefaultStock,
+		IsActive:  !req.Inactive,
+		MinOrder:  1,
+		Sizes:     []uint32{},
+		Colors:    []string{},
+		Images:    []string{},
+	}
+
+	input := make(map[string][]string, len(shared)+len(axes))
+	for code, values := range shared {
+		input[code] = values
+	}
+	for i, axis := range axes {
+		value := c.Values[i]
+		if axis.Attribute != nil {
+			input[axis.Attribute.Code] = []string{value}
+			continue
+		}
+		switch axis.Name {
+		case AxisSize:
+			size, _ := strconv.ParseUint(value, 10, 32)
+			variant.Sizes = []uint32{uint32(size)}
+		case AxisColor:
+			variant.Colors = []string{value}
+		case AxisMaterial:
+			variant.Material = value
+		}
+	}
+
+	values, err := s.attributeValidator.ValidateValues(schema, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return variant, values, nil
+}
+
+func options(axes []Axis, values []string) map[string]string {
+	result := make(map[string]string, len(axes))
+	for i, axis := range axes {
+		result[axis.Name] = values[i]
+	}
+	return result
+}
@@ -1,13 +1,26 @@
 package migrations
 
 import (
+	"fmt"
 	"os"
 
+	"admin/internal/attribute"
 	"admin/internal/brand"
 	"admin/internal/category"
+	"admin/internal/coupon"
+	"admin/internal/currency"
+	"admin/internal/deadletter"
+	"admin/internal/inventory"
 	"admin/internal/link"
+	"admin/internal/media"
+	"admin/internal/outbox"
 	"admin/internal/product"
 	"admin/internal/productVariant"
+	"admin/internal/promotion"
+	"admin/internal/rendition"
+	"admin/internal/reservation"
+	"admin/internal/review"
+	"admin/internal/slug"
 	"admin/internal/stat"
 	"admin/internal/user"
 	"admin/pkg/logger"
@@ -23,6 +36,7 @@ func CheckForMigrations() error {
 		logger.Info("🚀 Starting migrations...")
 		if err := RunMigrations(); err != nil {
 			logger.Errorf("Error processing migrations: %v", err)
+			return err
 		}
 		return nil
 	}
@@ -42,11 +56,185 @@ func RunMigrations() error {
 		panic(err)
 	}
 
-	err = db.AutoMigrate(&link.Link{}, &user.User{}, &stat.Stat{}, &product.Product{}, &category.Category{}, &brand.Brand{}, &productVariant.ProductVariant{})
+	if err := convertProductPrices(db); err != nil {
+		return err
+	}
+	if err := checkDuplicateBarcodes(db); err != nil {
+		return err
+	}
+	if err := dropCascadeForeignKeys(db); err != nil {
+		return err
+	}
+	if err := checkDuplicateBrandNames(db); err != nil {
+		return err
+	}
+	if err := checkOrphanVariants(db); err != nil {
+		return err
+	}
+
+	err = db.AutoMigrate(&link.Link{}, &user.User{}, &stat.Stat{}, &product.Product{}, &category.Category{}, &brand.Brand{}, &productVariant.ProductVariant{},
+		&productVariant.PriceHistory{}, &productVariant.ScheduledPriceChange{}, &productVariant.BarcodeSequence{},
+		&slug.Redirect{}, &promotion.Promotion{},
+		&coupon.Coupon{}, &coupon.Redemption{},
+		&currency.ExchangeRate{}, &currency.VariantPriceOverride{},
+		&attribute.Definition{}, &attribute.Value{},
+		&media.Image{}, &rendition.Job{},
+		&review.Review{}, &review.RatingSummary{},
+		&deadletter.Message{},
+		&outbox.Event{},
+		&inventory.ProcessedMessage{}, &inventory.Mismatch{}, &inventory.WarehouseStock{},
+		&reservation.Reservation{}, &reservation.ProcessedEvent{})
 	if err != nil {
 		return err
 	}
 
+	if err := slug.Backfill(db, "brands", slug.EntityBrand); err != nil {
+		return err
+	}
+	if err := slug.Backfill(db, "categories", slug.EntityCategory); err != nil {
+		return err
+	}
+	if err := media.ImportLegacy(db); err != nil {
+		return err
+	}
+	if err := normalizeCouponCodes(db); err != nil {
+		return err
+	}
+
 	logger.Info("✅")
 	return nil
 }
+
+// convertProductPrices переводит products.price/discount из bigint-копеек в decimal.
+// AutoMigrate поменял бы тип колонки без деления на 100
+func convertProductPrices(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&product.Product{}) {
+		return nil
+	}
+	columns, err := db.Migrator().ColumnTypes(&product.Product{})
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if column.Name() != "price" && column.Name() != "discount" {
+			continue
+		}
+		if column.DatabaseTypeName() != "int8" {
+			continue
+		}
+		logger.Infof("Converting products.%s from cents to decimal", column.Name())
+		if err := db.Exec("ALTER TABLE products ALTER COLUMN " + column.Name() +
+			" TYPE decimal(12,2) USING " + column.Name() + " / 100.0").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restrictedForeignKeys — внешние ключи, у которых ON DELETE CASCADE заменён на RESTRICT
+var restrictedForeignKeys = []struct{ table, column string }{
+	{"products", "category_id"},
+	{"products", "brand_id"},
+	{"categories", "parent_category_id"},
+}
+
+// dropCascadeForeignKeys удаляет старые каскадные ограничения restrictedForeignKeys.
+// AutoMigrate не меняет существующие ограничения, а отсутствующие создаёт по тегам
+// моделей — уже с RESTRICT
+func dropCascadeForeignKeys(db *gorm.DB) error {
+	for _, fk := range restrictedForeignKeys {
+		if !db.Migrator().HasTable(fk.table) {
+			continue
+		}
+		var names []string
+		if err := db.Raw(`SELECT c.conname FROM pg_constraint c
+			JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = ANY (c.conkey)
+			WHERE c.contype = 'f' AND c.confdeltype = 'c' AND c.conrelid = ?::regclass AND a.attname = ?`,
+			fk.table, fk.column).Scan(&names).Error; err != nil {
+			return err
+		}
+		for _, name := range names {
+			logger.Infof("Dropping ON DELETE CASCADE constraint %s on %s.%s", name, fk.table, fk.column)
+			if err := db.Exec(fmt.Sprintf(`ALTER TABLE %q DROP CONSTRAINT %q`, fk.table, name)).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkDuplicateBrandNames не даёт создать уникальный индекс имён брендов поверх дублей:
+// какой из брендов переименовать или объединить, решает человек
+func checkDuplicateBrandNames(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&brand.Brand{}) {
+		return nil
+	}
+	var duplicates []string
+	if err := db.Model(&brand.Brand{}).
+		Group("name").
+		Having("COUNT(*) > 1").
+		Pluck("name", &duplicates).Error; err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("brands share names %v: rename or merge duplicates before migrating", duplicates)
+	}
+	return nil
+}
+
+// checkOrphanVariants не даёт создать внешний ключ product_variants.product_id поверх вариантов
+// несуществующих товаров, включая мягко удалённые варианты: удалить их или привязать
+// к товару решает человек
+func checkOrphanVariants(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&productVariant.ProductVariant{}) || !db.Migrator().HasTable(&product.Product{}) {
+		return nil
+	}
+	var orphans []uint
+	if err := db.Model(&productVariant.ProductVariant{}).Unscoped().
+		Where("NOT EXISTS (SELECT 1 FROM products WHERE products.id = product_variants.product_id)").
+		Order("id").
+		Pluck("id", &orphans).Error; err != nil {
+		return err
+	}
+	if len(orphans) > 0 {
+		return fmt.Errorf("variants %v reference missing products: delete or reassign them before migrating", orphans)
+	}
+	return nil
+}
+
+// checkDuplicateBarcodes не даёт создать уникальный индекс штрих-кодов поверх дублей:
+// какой из вариантов оставить с кодом, решает человек
+func checkDuplicateBarcodes(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&productVariant.ProductVariant{}) {
+		return nil
+	}
+	var duplicates []string
+	if err := db.Model(&productVariant.ProductVariant{}).
+		Where("barcode <> '' AND is_active = true AND deleted_at IS NULL").
+		Group("barcode").
+		Having("COUNT(*) > 1").
+		Pluck("barcode", &duplicates).Error; err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("active variants share barcodes %v: clear or deactivate duplicates before migrating", duplicates)
+	}
+	return nil
+}
+
+// normalizeCouponCodes переводит коды купонов в верхний регистр: коды сравниваются
+// без учёта регистра (coupon.NormalizeCode). Если два кода различаются только регистром,
+// миграция прерывается, чтобы один из них переименовали вручную
+func normalizeCouponCodes(db *gorm.DB) error {
+	var duplicates []string
+	if err := db.Model(&coupon.Coupon{}).Unscoped().
+		Group("UPPER(code)").
+		Having("COUNT(*) > 1").
+		Pluck("UPPER(code)", &duplicates).Error; err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("coupon codes differ only by case, rename them before migrating: %v", duplicates)
+	}
+	return db.Exec("UPDATE coupons SET code = UPPER(code) WHERE code <> UPPER(code)").Error
+}
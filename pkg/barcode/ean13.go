@@ -0,0 +1,131 @@
+package barcode
+
+import (
+	"admin/pkg/validation"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidCode   = errors.New("code must be a valid EAN-13, UPC-A or EAN-8")
+	ErrInvalidPrefix = errors.New("GS1 company prefix must be 6 to 11 digits")
+	ErrPrefixFull    = errors.New("item reference range of the GS1 company prefix is exhausted")
+)
+
+// Ширина штрихкода в модулях: EAN-13 — 3 + 6*7 + 5 + 6*7 + 3, EAN-8 — 3 + 4*7 + 5 + 4*7 + 3
+const (
+	ean13Modules = 95
+	ean8Modules  = 67
+)
+
+var (
+	lCodes = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	gCodes = [10]string{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}
+	rCodes = [10]string{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}
+	// кодировка левой половины зависит от первой цифры, сама она штрихами не кодируется
+	parity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// Normalize приводит UPC-A к EAN-13 (ведущий ноль) и проверяет контрольную цифру.
+// EAN-8 остаётся восьмизначным: у него своя символика
+func Normalize(code string) (string, error) {
+	if len(code) == 12 {
+		code = "0" + code
+	}
+	if (len(code) != 13 && len(code) != 8) || !validation.ValidGTIN(code) {
+		return "", ErrInvalidCode
+	}
+	return code, nil
+}
+
+// EAN13 собирает код из префикса компании GS1 и номера товара, дописывая контрольную цифру
+func EAN13(companyPrefix string, itemReference uint64) (string, error) {
+	if len(companyPrefix) < 6 || len(companyPrefix) > 11 || !digitsOnly(companyPrefix) {
+		return "", ErrInvalidPrefix
+	}
+	width := 12 - len(companyPrefix)
+	if itemReference >= pow10(width) {
+		return "", ErrPrefixFull
+	}
+	body := fmt.Sprintf("%s%0*d", companyPrefix, width, itemReference)
+	return body + string(validation.GTINCheckDigit(body)), nil
+}
+
+// Capacity — сколько номеров товаров вмещает префикс
+func Capacity(companyPrefix string) uint64 {
+	return pow10(12 - len(companyPrefix))
+}
+
+// Modules возвращает модули штрихкода (95 у EAN-13, 67 у EAN-8): true — чёрная полоса
+func Modules(code string) ([]bool, error) {
+	code, err := Normalize(code)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 8 {
+		return ean8(code), nil
+	}
+
+	pattern := "101"
+	first := code[0] - '0'
+	for i := 1; i <= 6; i++ {
+		d := code[i] - '0'
+		if parity[first][i-1] == 'L' {
+			pattern += lCodes[d]
+		} else {
+			pattern += gCodes[d]
+		}
+	}
+	pattern += "01010"
+	for i := 7; i <= 12; i++ {
+		pattern += rCodes[code[i]-'0']
+	}
+	pattern += "101"
+
+	return toModules(pattern, ean13Modules), nil
+}
+
+// ean8 кодирует EAN-8: левая половина всегда набором L, правая — R
+func ean8(code string) []bool {
+	pattern := "101"
+	for i := 0; i < 4; i++ {
+		pattern += lCodes[code[i]-'0']
+	}
+	pattern += "01010"
+	for i := 4; i < 8; i++ {
+		pattern += rCodes[code[i]-'0']
+	}
+	pattern += "101"
+	return toModules(pattern, ean8Modules)
+}
+
+func toModules(pattern string, width int) []bool {
+	modules := make([]bool, width)
+	for i := range pattern {
+		modules[i] = pattern[i] == '1'
+	}
+	return modules
+}
+
+// isGuard — модули ограничителей, которые рисуются длиннее остальных. width — ширина штрихкода в модулях
+func isGuard(i, width int) bool {
+	center := width/2 - 2
+	return i < 3 || (i >= center && i < center+5) || i >= width-3
+}
+
+func digitsOnly(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func pow10(n int) uint64 {
+	result := uint64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
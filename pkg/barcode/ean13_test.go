@@ -0,0 +1,129 @@
+package barcode
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{code: "4006381333931", want: "4006381333931"},
+		{code: "036000291452", want: "0036000291452"}, // UPC-A
+		{code: "96385074", want: "96385074"},          // EAN-8
+		{code: "4006381333932", wantErr: true},        // неверная контрольная цифра
+		{code: "96385075", wantErr: true},
+		{code: "10012345678902", wantErr: true}, // GTIN-14 не рисуется
+		{code: "400638133393A", wantErr: true},
+		{code: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got, err := Normalize(tt.code)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCode) {
+					t.Errorf("Normalize(%q) error = %v, want ErrInvalidCode", tt.code, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Normalize(%q) = %q, %v; want %q", tt.code, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEAN13(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefix    string
+		reference uint64
+		want      string
+		wantErr   error
+	}{
+		{name: "check digit appended", prefix: "400638", reference: 133393, want: "4006381333931"},
+		{name: "reference padded", prefix: "4600000", reference: 1, want: "4600000000015"},
+		{name: "long prefix", prefix: "46000000000", reference: 9, want: "4600000000091"},
+		{name: "reference out of range", prefix: "46000000000", reference: 10, wantErr: ErrPrefixFull},
+		{name: "short prefix", prefix: "46000", wantErr: ErrInvalidPrefix},
+		{name: "too long prefix", prefix: "460000000000", wantErr: ErrInvalidPrefix},
+		{name: "non-digit prefix", prefix: "46000a", wantErr: ErrInvalidPrefix},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EAN13(tt.prefix, tt.reference)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("EAN13(%q, %d) = %q, %v; want %q", tt.prefix, tt.reference, got, err, tt.want)
+			}
+			if _, err := Normalize(got); err != nil {
+				t.Errorf("generated code %q is invalid: %v", got, err)
+			}
+		})
+	}
+}
+
+func TestModules(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{
+			code: "4006381333931",
+			want: "10100011010100111010111101111010001001011001101010100001010000101000010111010010000101100110101",
+		},
+		{
+			code: "96385074",
+			want: "1010001011010111101111010110111010101001110111001010001001011100101",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			modules, err := Modules(tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got strings.Builder
+			for _, black := range modules {
+				if black {
+					got.WriteByte('1')
+				} else {
+					got.WriteByte('0')
+				}
+			}
+			if got.String() != tt.want {
+				t.Errorf("Modules(%q) =\n%s\nwant\n%s", tt.code, got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestIsGuard(t *testing.T) {
+	tests := []struct {
+		width  int
+		guards []int
+	}{
+		{ean13Modules, []int{0, 1, 2, 45, 46, 47, 48, 49, 92, 93, 94}},
+		{ean8Modules, []int{0, 1, 2, 31, 32, 33, 34, 35, 64, 65, 66}},
+	}
+	for _, tt := range tests {
+		want := make(map[int]bool, len(tt.guards))
+		for _, i := range tt.guards {
+			want[i] = true
+		}
+		for i := 0; i < tt.width; i++ {
+			if got := isGuard(i, tt.width); got != want[i] {
+				t.Errorf("isGuard(%d, %d) = %v, want %v", i, tt.width, got, want[i])
+			}
+		}
+	}
+}
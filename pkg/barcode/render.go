@@ -0,0 +1,150 @@
+package barcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+
+	quietZone = 11 // модулей белого поля слева и справа по стандарту EAN-13, EAN-8 рисуется с тем же полем
+
+	maxScale  = 10
+	maxHeight = 1000
+)
+
+// Options — параметры отрисовки. Нулевые значения заменяются значениями по умолчанию
+type Options struct {
+	Scale  int // ширина модуля в пикселях, по умолчанию 2
+	Height int // высота полос в пикселях, по умолчанию 30*Scale
+}
+
+func (o Options) withDefaults() Options {
+	if o.Scale <= 0 {
+		o.Scale = 2
+	}
+	if o.Scale > maxScale {
+		o.Scale = maxScale
+	}
+	if o.Height <= 0 {
+		o.Height = 30 * o.Scale
+	}
+	if o.Height > maxHeight {
+		o.Height = maxHeight
+	}
+	return o
+}
+
+// ContentType возвращает MIME-тип формата
+func ContentType(format string) string {
+	if format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Render рисует штрихкод в формате png или svg
+func Render(code, format string, opts Options) ([]byte, error) {
+	switch format {
+	case FormatPNG, "":
+		return PNG(code, opts)
+	case FormatSVG:
+		return SVG(code, opts)
+	default:
+		return nil, fmt.Errorf("unsupported barcode format %q", format)
+	}
+}
+
+// PNG рисует штрихкод без подписи: для печати этикеток цифры обычно добавляет шаблон
+func PNG(code string, opts Options) ([]byte, error) {
+	modules, err := Modules(code)
+	if err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+	guardExtra := 5 * opts.Scale
+
+	width := (len(modules) + 2*quietZone) * opts.Scale
+	height := opts.Height + guardExtra
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for i, black := range modules {
+		if !black {
+			continue
+		}
+		barHeight := opts.Height
+		if isGuard(i, len(modules)) {
+			barHeight += guardExtra
+		}
+		x0 := (quietZone + i) * opts.Scale
+		for x := x0; x < x0+opts.Scale; x++ {
+			for y := 0; y < barHeight; y++ {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG рисует штрихкод с цифрами под полосами
+func SVG(code string, opts Options) ([]byte, error) {
+	modules, err := Modules(code)
+	if err != nil {
+		return nil, err
+	}
+	code, _ = Normalize(code)
+	opts = opts.withDefaults()
+	guardExtra := 5 * opts.Scale
+	fontSize := 9 * opts.Scale
+
+	width := (len(modules) + 2*quietZone) * opts.Scale
+	height := opts.Height + guardExtra + fontSize
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/>`, width, height)
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		// соседние чёрные модули рисуем одним прямоугольником
+		start := i
+		for i < len(modules) && modules[i] && isGuard(i, len(modules)) == isGuard(start, len(modules)) {
+			i++
+		}
+		barHeight := opts.Height
+		if isGuard(start, len(modules)) {
+			barHeight += guardExtra
+		}
+		fmt.Fprintf(&b, `<rect x="%d" y="0" width="%d" height="%d" fill="#000"/>`,
+			(quietZone+start)*opts.Scale, (i-start)*opts.Scale, barHeight)
+	}
+
+	textY := opts.Height + guardExtra + fontSize - opts.Scale
+	fmt.Fprintf(&b, `<g font-family="monospace" font-size="%d" fill="#000" text-anchor="middle">`, fontSize)
+	if len(code) == 8 {
+		// у EAN-8 нет цифры вне полос: по четыре цифры под каждой половиной
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`, (quietZone+17)*opts.Scale, textY, code[:4])
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`, (quietZone+50)*opts.Scale, textY, code[4:])
+	} else {
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`, (quietZone/2)*opts.Scale, textY, code[:1])
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`, (quietZone+24)*opts.Scale, textY, code[1:7])
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`, (quietZone+71)*opts.Scale, textY, code[7:])
+	}
+	b.WriteString(`</g></svg>`)
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,35 @@
+package codegen
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+const (
+	Letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// CouponAlphabet без похожих символов (0/O, 1/I/L), чтобы коды было удобно вводить вручную
+	CouponAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+)
+
+var ErrEmptyAlphabet = errors.New("alphabet is empty")
+
+// Generate возвращает случайную строку длины n из символов alphabet.
+// Использует crypto/rand: коды купонов не должны угадываться
+func Generate(alphabet string, n int) (string, error) {
+	runes := []rune(alphabet)
+	if len(runes) == 0 {
+		return "", ErrEmptyAlphabet
+	}
+
+	max := big.NewInt(int64(len(runes)))
+	b := make([]rune, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = runes[idx.Int64()]
+	}
+	return string(b), nil
+}
@@ -0,0 +1,181 @@
+package consumer
+
+import (
+	"admin/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	kafkaService "github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	connectRetry  = 10 * time.Second
+	retryDelay    = time.Second
+	retryMaxDelay = time.Minute
+	commitTimeout = 10 * time.Second
+)
+
+var (
+	ErrAlreadyStarted = errors.New("consumer already started")
+	ErrNotStarted     = errors.New("consumer is not started")
+)
+
+// Handler обрабатывает сообщение. Ошибка (или паника) означает, что сообщение нужно
+// повторить; сообщения, которые повтор не исправит, обработчик отправляет в DLQ сам
+type Handler func(ctx context.Context, msg kafka.Message) error
+
+// Consumer читает топик через kafkaService по порядку и коммитит смещение только
+// после успешной обработки: доставка at-least-once, обработчик должен быть идемпотентным.
+// Неудачное сообщение повторяется с растущей задержкой, пока не пройдёт или Consumer
+// не остановят, — следующие сообщения партиции его ждут
+type Consumer struct {
+	Name    string
+	brokers []string
+	topic   string
+	group   string
+	handler Handler
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func New(name string, brokers []string, topic, group string, handler Handler) *Consumer {
+	return &Consumer{
+		Name:    name,
+		brokers: brokers,
+		topic:   topic,
+		group:   group,
+		handler: handler,
+	}
+}
+
+// Start запускает чтение в фоне; Consumer останавливается вызовом Stop или отменой ctx
+func (c *Consumer) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		return ErrAlreadyStarted
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	go c.run(ctx)
+	return nil
+}
+
+// Stop дожидается обработки текущего сообщения и закрывает соединение
+func (c *Consumer) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	cancel, done := c.cancel, c.done
+	c.cancel = nil
+	c.mu.Unlock()
+	if cancel == nil {
+		return ErrNotStarted
+	}
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Consumer) run(ctx context.Context) {
+	defer close(c.done)
+
+	reader, err := c.connect(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			logger.Errorf("%s: failed to close consumer: %v", c.Name, err)
+		}
+	}()
+	logger.Infof("%s: consuming %s as %s", c.Name, c.topic, c.group)
+
+	for {
+		msg, err := reader.Reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Errorf("%s: fetch failed: %v", c.Name, err)
+			if sleep(ctx, retryDelay) != nil {
+				return
+			}
+			continue
+		}
+
+		delay := retryDelay
+		for {
+			err := c.handle(ctx, msg)
+			if err == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Errorf("%s: partition %d offset %d: %v", c.Name, msg.Partition, msg.Offset, err)
+			if sleep(ctx, delay) != nil {
+				return
+			}
+			delay = min(delay*2, retryMaxDelay)
+		}
+
+		// коммит не зависит от ctx: обработанное при остановке сообщение не должно повториться
+		commitCtx, cancel := context.WithTimeout(context.Background(), commitTimeout)
+		if err := reader.Reader.CommitMessages(commitCtx, msg); err != nil {
+			logger.Errorf("%s: commit partition %d offset %d: %v", c.Name, msg.Partition, msg.Offset, err)
+		}
+		cancel()
+	}
+}
+
+func (c *Consumer) handle(ctx context.Context, msg kafka.Message) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return c.handler(ctx, msg)
+}
+
+// connect повторяет подключение, пока Kafka недоступна: kafkaService в этом случае паникует
+func (c *Consumer) connect(ctx context.Context) (*kafkaService.KafkaService, error) {
+	for {
+		reader, err := c.dial()
+		if err == nil {
+			return reader, nil
+		}
+		logger.Errorf("%s: %v", c.Name, err)
+		if err := sleep(ctx, connectRetry); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Consumer) dial() (reader *kafkaService.KafkaService, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("connect: %v", p)
+		}
+	}()
+	return kafkaService.NewConsumer(c.brokers, c.topic, c.group, c.group+"-client"), nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
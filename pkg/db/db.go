@@ -2,6 +2,7 @@ package db
 
 import (
 	"admin/configs"
+	"context"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -18,3 +19,37 @@ func NewDB(conf *configs.Config) *Db {
 	}
 	return &Db{db}
 }
+
+// Ping проверяет соединение с базой
+func (db *Db) Ping(ctx context.Context) error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close закрывает пул соединений
+func (db *Db) Close() error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+type txKey struct{}
+
+// WithTx кладёт транзакцию в контекст, чтобы синхронные обработчики событий
+// выполнялись в ней же
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// Tx возвращает транзакцию из контекста или, если её нет, обычное соединение
+func (db *Db) Tx(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.DB.WithContext(ctx)
+}
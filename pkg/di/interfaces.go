@@ -5,6 +5,8 @@ import (
 	"admin/internal/category"
 	"admin/internal/product"
 	"admin/internal/productVariant"
+	"admin/internal/promotion"
+	"time"
 
 	pb "github.com/ShopOnGO/admin-proto/pkg/service"
 )
@@ -36,6 +38,7 @@ type ICategoryRepository interface {
 	FindCategoryByID(id uint) (*category.Category, error) //done
 	Update(category *category.Category) (*category.Category, error)
 	Delete(name string, unscoped bool) error
+	DeleteByID(id, version uint, unscoped bool) error
 }
 type IBrandRepository interface {
 	Create(category *brand.Brand) (*brand.Brand, error)
@@ -43,6 +46,11 @@ type IBrandRepository interface {
 	FindByName(name string) (*brand.Brand, error)
 	Update(brand *brand.Brand) (*brand.Brand, error)
 	Delete(name string, unscoped bool) error
+	DeleteByID(id, version uint, unscoped bool) error
+}
+
+type IPromotionRepository interface {
+	GetActivePromotions(now time.Time, amount int) ([]promotion.Promotion, error)
 }
 
 type ProductVariantRepositoryInterface interface {
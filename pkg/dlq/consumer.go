@@ -2,16 +2,13 @@ package dlq
 
 import (
 	"context"
-	"log"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	kafkaService "github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
 	"github.com/segmentio/kafka-go"
 )
 
+// Consumer читает DLQ без автоматического коммита: смещение фиксируется
+// вызовом Commit только после успешной обработки сообщения
 type Consumer struct {
 	kafka *kafkaService.KafkaService
 }
@@ -22,26 +19,20 @@ func NewConsumer(brokers []string, topic, groupID, clientID string) *Consumer {
 	}
 }
 
-// Start запускает чтение сообщений из Kafka с обработчиком
-func (c *Consumer) Start(ctx context.Context, handler func(key, value []byte)) {
-	c.kafka.Consume(ctx, func(m kafka.Message) error {
-		log.Printf("[DLQ] 📩 Сообщение: key=%s, value=%s", string(m.Key), string(m.Value))
-		handler(m.Key, m.Value)
-		return nil
-	})
+// Fetch блокируется до следующего сообщения или отмены ctx
+func (c *Consumer) Fetch(ctx context.Context) (kafka.Message, error) {
+	return c.kafka.Reader.FetchMessage(ctx)
 }
 
-// Запуск с graceful shutdown
-func RunWithGracefulShutdown(consumer *Consumer, handler func(key, value []byte)) {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	go consumer.Start(ctx, handler)
+func (c *Consumer) Commit(ctx context.Context, msg kafka.Message) error {
+	return c.kafka.Reader.CommitMessages(ctx, msg)
+}
 
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	<-sigCh
+// Lag — отставание от конца партиции по последней статистике читателя
+func (c *Consumer) Lag() int64 {
+	return c.kafka.Reader.Stats().Lag
+}
 
-	log.Println("[DLQ] 🔄 Завершаем...")
-	cancel()
-	time.Sleep(2 * time.Second)
+func (c *Consumer) Close() error {
+	return c.kafka.Close()
 }
@@ -0,0 +1,64 @@
+package dlq
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultDelayTiers — задержки топиков отложенных повторов
+var DefaultDelayTiers = []time.Duration{5 * time.Second, 30 * time.Second, time.Minute, 5 * time.Minute}
+
+// Delays — топики отложенных повторов, по одному на ступень задержки. Сообщение
+// откладывается в топик ближайшей ступени не меньше задержки с заголовком HeaderNotBefore,
+// а его консьюмер ждёт этого времени. Задержки в одном топике не больше ступени, поэтому
+// ожидание первого сообщения партиции почти не задерживает следующие, а DLQ не стоит
+type Delays struct {
+	Base  string // топик DLQ, от которого строятся имена
+	Tiers []time.Duration
+}
+
+func NewDelays(base string) *Delays {
+	return &Delays{
+		Base:  base,
+		Tiers: DefaultDelayTiers,
+	}
+}
+
+// Topic — топик для задержки delay. Задержки больше последней ступени идут в неё:
+// консьюмер ждёт не дольше ступени и откладывает сообщение снова (Delayer.Redelay)
+func (d *Delays) Topic(delay time.Duration) string {
+	for _, tier := range d.Tiers {
+		if delay <= tier {
+			return d.topic(tier)
+		}
+	}
+	return d.topic(d.Tiers[len(d.Tiers)-1])
+}
+
+// Tier — ступень топика задержки; ok == false, если это не топик задержки
+func (d *Delays) Tier(topic string) (tier time.Duration, ok bool) {
+	for _, tier := range d.Tiers {
+		if d.topic(tier) == topic {
+			return tier, true
+		}
+	}
+	return 0, false
+}
+
+func (d *Delays) Topics() []string {
+	topics := make([]string, len(d.Tiers))
+	for i, tier := range d.Tiers {
+		topics[i] = d.topic(tier)
+	}
+	return topics
+}
+
+// Contains сообщает, что сообщение прочитано из топика отложенных повторов
+func (d *Delays) Contains(topic string) bool {
+	return slices.Contains(d.Topics(), topic)
+}
+
+func (d *Delays) topic(tier time.Duration) string {
+	return fmt.Sprintf("%s.delay.%ds", d.Base, int(tier.Seconds()))
+}
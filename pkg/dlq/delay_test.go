@@ -0,0 +1,57 @@
+package dlq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelaysTopic(t *testing.T) {
+	d := NewDelays("dlq")
+	tests := []struct {
+		delay time.Duration
+		want  string
+	}{
+		{0, "dlq.delay.5s"},
+		{time.Second, "dlq.delay.5s"},
+		{5 * time.Second, "dlq.delay.5s"},
+		{5*time.Second + time.Millisecond, "dlq.delay.30s"},
+		{45 * time.Second, "dlq.delay.60s"},
+		{5 * time.Minute, "dlq.delay.300s"},
+		// длиннее последней ступени: ждёт в ней и откладывается снова
+		{6 * time.Minute, "dlq.delay.300s"},
+		{-time.Second, "dlq.delay.5s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.delay.String(), func(t *testing.T) {
+			if got := d.Topic(tt.delay); got != tt.want {
+				t.Errorf("Topic(%v) = %q, want %q", tt.delay, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelaysTier(t *testing.T) {
+	d := NewDelays("dlq")
+	tests := []struct {
+		topic  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{"dlq.delay.5s", 5 * time.Second, true},
+		{"dlq.delay.300s", 5 * time.Minute, true},
+		{"dlq", 0, false},
+		{"other.delay.5s", 0, false},
+		{"dlq.delay.10s", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			got, ok := d.Tier(tt.topic)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Tier(%q) = %v, %v; want %v, %v", tt.topic, got, ok, tt.want, tt.wantOk)
+			}
+			if d.Contains(tt.topic) != tt.wantOk {
+				t.Errorf("Contains(%q) = %v, want %v", tt.topic, !tt.wantOk, tt.wantOk)
+			}
+		})
+	}
+}
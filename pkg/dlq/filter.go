@@ -1,11 +1,56 @@
 package dlq
 
-var autoRetryCategories = map[string]bool{
-	"MESSAGE": true,
-	"EMAIL":   true,
-	"ORDER":   true,
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy — сколько раз и с какой задержкой повторять сообщения правила.
+// Задержка растёт экспоненциально от BaseDelay до MaxDelay, к ней добавляется
+// случайный разброс до Jitter от задержки, чтобы повторы не приходили пачкой
+type RetryPolicy struct {
+	MaxAttempts int      `json:"max_attempts" yaml:"max_attempts"`
+	BaseDelay   Duration `json:"base_delay" yaml:"base_delay"`
+	MaxDelay    Duration `json:"max_delay" yaml:"max_delay"`
+	Jitter      float64  `json:"jitter" yaml:"jitter"`
+}
+
+// DefaultRetryPolicy применяется к правилам retry и transform без своей политики
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   Duration(time.Second),
+	MaxDelay:    Duration(time.Minute),
+	Jitter:      0.2,
+}
+
+// DefaultRules — правила, действующие без файла правил: повторяются уведомления
+// MESSAGE, EMAIL и ORDER, остальные отбрасываются
+func DefaultRules() *RuleSet {
+	return &RuleSet{
+		Rules: []Rule{
+			{Name: "messages", Match: Match{Categories: []string{"MESSAGE"}}, Action: ActionRetry,
+				Retry: &RetryPolicy{MaxAttempts: 5, BaseDelay: Duration(time.Second), MaxDelay: Duration(time.Minute), Jitter: 0.2}},
+			{Name: "emails", Match: Match{Categories: []string{"EMAIL"}}, Action: ActionRetry,
+				Retry: &RetryPolicy{MaxAttempts: 5, BaseDelay: Duration(5 * time.Second), MaxDelay: Duration(5 * time.Minute), Jitter: 0.2}},
+			{Name: "orders", Match: Match{Categories: []string{"ORDER"}}, Action: ActionRetry,
+				Retry: &RetryPolicy{MaxAttempts: 10, BaseDelay: Duration(time.Second), MaxDelay: Duration(2 * time.Minute), Jitter: 0.2}},
+			// неизвестные SKU из WMS и непригодные события заказов не исправятся повтором:
+			// на стоянку и в БД для разбора
+			{Name: "stock", Match: Match{Categories: []string{"INVENTORY", "STOCK"}}, Action: ActionPark},
+		},
+		DefaultAction: ActionDrop,
+	}
 }
 
-func ShouldRetry(n Notification) bool {
-	return autoRetryCategories[n.Category]
+// Delay — задержка перед повтором номер attempt (с единицы)
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay, maxDelay := time.Duration(p.BaseDelay), time.Duration(p.MaxDelay)
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+	if p.Jitter > 0 {
+		delay += time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+	return delay
 }
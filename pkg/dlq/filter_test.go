@@ -0,0 +1,52 @@
+package dlq
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: Duration(time.Second), MaxDelay: Duration(10 * time.Second)}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second}, // 16s упирается в MaxDelay
+		{100, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			if got := policy.Delay(tt.attempt); got != tt.want {
+				t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelayJitter(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"jitter adds up to its share", RetryPolicy{BaseDelay: Duration(time.Second), MaxDelay: Duration(time.Minute), Jitter: 0.5}, 3, 4 * time.Second, 6 * time.Second},
+		{"jitter on the cap exceeds MaxDelay", RetryPolicy{BaseDelay: Duration(time.Minute), MaxDelay: Duration(5 * time.Minute), Jitter: 0.2}, 10, 5 * time.Minute, 6 * time.Minute},
+		{"base above MaxDelay is capped", RetryPolicy{BaseDelay: Duration(time.Hour), MaxDelay: Duration(time.Minute)}, 1, time.Minute, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if got := tt.policy.Delay(tt.attempt); got < tt.min || got > tt.max {
+					t.Fatalf("Delay(%d) = %v, want within [%v, %v]", tt.attempt, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package dlq
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Заголовки, которыми DLQ сопровождает повторно отправленные сообщения
+const (
+	HeaderAttempt      = "x-dlq-attempt"       // сколько раз сообщение уже повторялось
+	HeaderFirstFailed  = "x-dlq-first-failed"  // когда сообщение впервые попало в DLQ, RFC 3339
+	HeaderParkedReason = "x-dlq-parked-reason" // почему сообщение отправлено на стоянку
+	HeaderNotBefore    = "x-dlq-not-before"    // раньше этого времени отложенный повтор не выполняется, RFC 3339
+	// JSON-массив ошибок проверки сообщения, отправленного в карантин
+	HeaderValidationErrors = "x-dlq-validation-errors"
+)
+
+// Attempt читает счётчик повторов. Сообщение без заголовка, но с WasInDLQ,
+// отправлено старой версией процессора и уже повторялось хотя бы раз
+func Attempt(msg kafka.Message, n Notification) int {
+	if value, ok := header(msg, HeaderAttempt); ok {
+		if attempt, err := strconv.Atoi(value); err == nil && attempt >= 0 {
+			return attempt
+		}
+	}
+	if n.WasInDLQ {
+		return 1
+	}
+	return 0
+}
+
+// FirstFailed — время первого попадания в DLQ, для нового сообщения — now
+func FirstFailed(msg kafka.Message, now time.Time) time.Time {
+	if value, ok := header(msg, HeaderFirstFailed); ok {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t
+		}
+	}
+	return now
+}
+
+// NotBefore — время, до которого отложенное сообщение ждёт в топике задержки
+func NotBefore(msg kafka.Message) (time.Time, bool) {
+	value, ok := header(msg, HeaderNotBefore)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	return t, err == nil
+}
+
+func header(msg kafka.Message, key string) (string, bool) {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value), true
+		}
+	}
+	return "", false
+}
+
+// withHeader возвращает копию заголовков с установленным значением key
+func withHeader(headers []kafka.Header, key, value string) []kafka.Header {
+	result := make([]kafka.Header, 0, len(headers)+1)
+	for _, h := range headers {
+		if h.Key != key {
+			result = append(result, h)
+		}
+	}
+	return append(result, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// withoutHeader возвращает копию заголовков без key
+func withoutHeader(headers []kafka.Header, key string) []kafka.Header {
+	result := make([]kafka.Header, 0, len(headers))
+	for _, h := range headers {
+		if h.Key != key {
+			result = append(result, h)
+		}
+	}
+	return result
+}
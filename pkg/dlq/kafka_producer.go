@@ -3,7 +3,9 @@ package dlq
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"sync"
 
 	kafkaService "github.com/ShopOnGO/ShopOnGO/pkg/kafkaService"
 	"github.com/segmentio/kafka-go"
@@ -11,6 +13,8 @@ import (
 
 type KafkaProducer interface {
 	Produce(ctx context.Context, topic, key string, value any) error
+	// ProduceMessage отправляет готовое сообщение с заголовками
+	ProduceMessage(ctx context.Context, msg kafka.Message) error
 }
 
 type SimpleKafkaProducer struct {
@@ -36,3 +40,56 @@ func (p *SimpleKafkaProducer) Produce(ctx context.Context, topic, key string, va
 		// Topic указывать нельзя, он уже зашит в Writer при создании
 	})
 }
+
+func (p *SimpleKafkaProducer) ProduceMessage(ctx context.Context, msg kafka.Message) error {
+	// Topic, Partition и Offset прочитанного сообщения Writer не принимает
+	return p.service.ProduceMessage(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: msg.Headers,
+	})
+}
+
+func (p *SimpleKafkaProducer) Close() error {
+	return p.service.Close()
+}
+
+// TopicProducers создаёт продюсеры по топикам по мере надобности:
+// правила маршрутизации могут направлять повторы в разные топики
+type TopicProducers struct {
+	brokers []string
+
+	mu        sync.Mutex
+	producers map[string]*SimpleKafkaProducer
+}
+
+func NewTopicProducers(brokers []string) *TopicProducers {
+	return &TopicProducers{
+		brokers:   brokers,
+		producers: make(map[string]*SimpleKafkaProducer),
+	}
+}
+
+func (t *TopicProducers) For(topic string) KafkaProducer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	producer, ok := t.producers[topic]
+	if !ok {
+		producer = NewKafkaProducer(t.brokers, topic)
+		t.producers[topic] = producer
+	}
+	return producer
+}
+
+func (t *TopicProducers) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var errs []error
+	for topic, producer := range t.producers {
+		if err := producer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(t.producers, topic)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,90 @@
+package dlq
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// Metrics — счётчики процессора. Публикуются в expvar под именем "dlq",
+// по категориям — в "dlq_categories" с ключами вида "EMAIL.retried"
+type Metrics struct {
+	Retried     atomic.Int64
+	Parked      atomic.Int64
+	Skipped     atomic.Int64
+	Quarantined atomic.Int64
+	RetryErrors atomic.Int64
+
+	categories *expvar.Map
+}
+
+// MetricsSnapshot — значения счётчиков на момент вызова
+type MetricsSnapshot struct {
+	Retried     int64 `json:"retried"`
+	Parked      int64 `json:"parked"`
+	Skipped     int64 `json:"skipped"`
+	Quarantined int64 `json:"quarantined"`
+	RetryErrors int64 `json:"retry_errors"`
+}
+
+var (
+	defaultMetrics     *Metrics
+	defaultMetricsOnce sync.Once
+)
+
+// DefaultMetrics возвращает счётчики процесса. expvar не допускает повторной
+// публикации имени, поэтому экземпляр один
+func DefaultMetrics() *Metrics {
+	defaultMetricsOnce.Do(func() {
+		m := &Metrics{categories: new(expvar.Map).Init()}
+		expvar.Publish("dlq", expvar.Func(func() any { return m.Snapshot() }))
+		expvar.Publish("dlq_categories", m.categories)
+		defaultMetrics = m
+	})
+	return defaultMetrics
+}
+
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	return MetricsSnapshot{
+		Retried:     m.Retried.Load(),
+		Parked:      m.Parked.Load(),
+		Skipped:     m.Skipped.Load(),
+		Quarantined: m.Quarantined.Load(),
+		RetryErrors: m.RetryErrors.Load(),
+	}
+}
+
+func (m *Metrics) retried(category string) {
+	m.Retried.Add(1)
+	m.addCategory(category, "retried")
+}
+
+func (m *Metrics) parked(category string) {
+	m.Parked.Add(1)
+	m.addCategory(category, "parked")
+}
+
+func (m *Metrics) skipped(category string) {
+	m.Skipped.Add(1)
+	m.addCategory(category, "skipped")
+}
+
+func (m *Metrics) quarantined(category string) {
+	m.Quarantined.Add(1)
+	m.addCategory(category, "quarantined")
+}
+
+func (m *Metrics) retryError(category string) {
+	m.RetryErrors.Add(1)
+	m.addCategory(category, "retry_errors")
+}
+
+func (m *Metrics) addCategory(category, counter string) {
+	if m.categories == nil {
+		return
+	}
+	if category == "" {
+		category = "UNKNOWN"
+	}
+	m.categories.Add(category+"."+counter, 1)
+}
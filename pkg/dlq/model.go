@@ -1,10 +1,42 @@
 package dlq
 
+import "fmt"
+
+// Ограничения полей уведомления, совпадают с колонками dead_letter_messages
+const (
+	MaxIDLength       = 255
+	MaxCategoryLength = 50
+	MaxSubtypeLength  = 100
+)
+
 type Notification struct {
 	ID       string                 `json:"id" bson:"_id"`
 	Category string                 `json:"category" bson:"category"`
 	Subtype  string                 `json:"subtype" bson:"subtype"`
 	UserID   uint32                 `json:"userID" bson:"userID"`
 	WasInDLQ bool                   `json:"wasInDlq" bson:"wasInDlq"`
+	Version  int                    `json:"version,omitempty" bson:"version,omitempty"` // версия схемы payload, 0 — первая
 	Payload  map[string]interface{} `json:"payload" bson:"payload"`
 }
+
+// Validate проверяет поля уведомления без учёта схемы payload
+func (n Notification) Validate() []string {
+	var errs []string
+	if n.Category == "" {
+		errs = append(errs, "category: is required")
+	}
+	errs = appendTooLong(errs, "id", n.ID, MaxIDLength)
+	errs = appendTooLong(errs, "category", n.Category, MaxCategoryLength)
+	errs = appendTooLong(errs, "subtype", n.Subtype, MaxSubtypeLength)
+	if n.Version < 0 {
+		errs = append(errs, "version: must not be negative")
+	}
+	return errs
+}
+
+func appendTooLong(errs []string, field, value string, limit int) []string {
+	if len(value) > limit {
+		return append(errs, fmt.Sprintf("%s: must be at most %d bytes", field, limit))
+	}
+	return errs
+}
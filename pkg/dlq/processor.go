@@ -1,44 +1,184 @@
 package dlq
 
 import (
-	"admin/configs"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
 )
 
-func StartDLQProcessor(conf *configs.Config) {
-	retrier := NewKafkaRetrier(
-		NewKafkaProducer([]string{conf.Dlq.Broker}, conf.Dlq.ProducerTopic),
-		conf.Dlq.ProducerTopic,
-	)
-
-	log.Printf("[DLQ] ⚙️ Broker: %s, Topic: %s", conf.Dlq.Broker, conf.Dlq.ConsumerTopic)
-
-	consumer := NewConsumer(
-		[]string{conf.Dlq.Broker},
-		conf.Dlq.ConsumerTopic,
-		"dlq-processor-group",
-		"dlq-processor-client", // clientID
-	)
-
-	handler := func(key, value []byte) {
-		var n Notification
-		if err := json.Unmarshal(value, &n); err != nil {
-			log.Printf("[DLQ] 🚫 Не удалось распарсить сообщение: %v", err)
-			return
+// ParkingTopicSuffix — суффикс топика-стоянки, если он не задан в конфиге
+const ParkingTopicSuffix = ".parking"
+
+// QuarantineTopicSuffix — суффикс топика карантина, если он не задан в конфиге
+const QuarantineTopicSuffix = ".quarantine"
+
+// ProducerSource выдаёт продюсер для топика повтора
+type ProducerSource interface {
+	For(topic string) KafkaProducer
+}
+
+// Processor решает судьбу сообщения из DLQ по правилам Router: повторить с задержкой,
+// преобразовать и повторить, отправить на стоянку или отбросить. Перед этим сообщение
+// проверяется по Schemas: непригодные уходят в карантин, старые версии payload поднимаются
+type Processor struct {
+	Router     *Router
+	Producers  ProducerSource
+	Delayer    *Delayer
+	Parker     *Parker
+	Quarantine *Quarantine
+	Schemas    *SchemaRegistry
+	Metrics    *Metrics
+	Recorder   Recorder // может быть nil
+}
+
+func NewProcessor(router *Router, producers ProducerSource, delayer *Delayer, parker *Parker, quarantine *Quarantine, schemas *SchemaRegistry, metrics *Metrics, recorder Recorder) *Processor {
+	return &Processor{
+		Router:     router,
+		Producers:  producers,
+		Delayer:    delayer,
+		Parker:     parker,
+		Quarantine: quarantine,
+		Schemas:    schemas,
+		Metrics:    metrics,
+		Recorder:   recorder,
+	}
+}
+
+// Handle обрабатывает одно сообщение. Сообщение из DLQ перед повтором откладывается
+// в топик задержки, повтор выполняется, когда оно придёт оттуда: так задержка не держит
+// остальные сообщения партиции. Ограничение частоты выдерживается здесь же,
+// отмена ctx прерывает ожидание без повтора
+func (p *Processor) Handle(ctx context.Context, msg kafka.Message) error {
+	var n Notification
+	if err := json.Unmarshal(msg.Value, &n); err != nil {
+		log.Printf("[DLQ] 🚫 Не удалось распарсить сообщение: %v", err)
+		return p.quarantine(ctx, msg, Notification{}, 0, []string{"unparseable: " + err.Error()})
+	}
+	attempt := Attempt(msg, n)
+	checked, errs := p.Schemas.Check(n)
+	if len(errs) > 0 {
+		log.Printf("[DLQ] 🚫 %s не прошло проверку: %s", n.ID, strings.Join(errs, "; "))
+		return p.quarantine(ctx, msg, n, attempt, errs)
+	}
+	if checked.Version != n.Version {
+		log.Printf("[DLQ] ⬆️ %s: payload %s/%s поднят до версии %d", n.ID, n.Category, n.Subtype, checked.Version)
+	}
+	n = checked
+
+	route := p.Router.Route(n)
+	switch route.Action {
+	case ActionDrop:
+		log.Printf("[DLQ] ❎ Пропускаем: %s (правило %s)", n.ID, route.Rule)
+		// отброшенное сообщение остаётся только в БД: без записи его нельзя коммитить
+		if err := p.record(ctx, Record{Message: msg, Notification: n, Attempt: attempt, Outcome: OutcomeSkipped, Reason: "rule " + route.Rule + ": drop"}); err != nil {
+			return err
 		}
+		p.Metrics.skipped(n.Category)
+		return nil
+	case ActionPark:
+		return p.park(ctx, msg, n, attempt, "rule "+route.Rule+": park")
+	}
+
+	policy := route.Policy
+	if attempt >= policy.MaxAttempts {
+		log.Printf("[DLQ] 🅿️ %s: исчерпано %d попыток", n.ID, attempt)
+		return p.park(ctx, msg, n, attempt, fmt.Sprintf("rule %s: max attempts (%d) exceeded", route.Rule, policy.MaxAttempts))
+	}
 
-		if ShouldRetry(n) {
-			n.WasInDLQ = true
-			log.Printf("[DLQ] 🔁 Повторная отправка: %s", n.ID)
-			if err := retrier.RetryWithKey(context.Background(), string(key), n); err != nil {
-				log.Printf("[DLQ] ❌ Ошибка ретрая: %v", err)
-			}
-		} else {
-			log.Printf("[DLQ] ❎ Пропускаем: %s", n.ID)
+	next := attempt + 1
+	if !p.Delayer.Delays.Contains(msg.Topic) {
+		delay := policy.Delay(next)
+		topic, err := p.Delayer.Delay(ctx, msg, delay)
+		if err != nil {
+			log.Printf("[DLQ] ❌ Не удалось отложить %s: %v", n.ID, err)
+			return err
+		}
+		log.Printf("[DLQ] ⏳ %s: попытка %d/%d через %v, отложено в %s (правило %s)", n.ID, next, policy.MaxAttempts, delay, topic, route.Rule)
+		return nil
+	}
+	if notBefore, ok := NotBefore(msg); ok && time.Now().Before(notBefore) {
+		// задержка длиннее ступени топика: Runner подождал ступень, дальше сообщение ждёт в топике
+		topic, err := p.Delayer.Redelay(ctx, msg, notBefore)
+		if err != nil {
+			log.Printf("[DLQ] ❌ Не удалось повторно отложить %s: %v", n.ID, err)
+			return err
 		}
+		log.Printf("[DLQ] ⏳ %s: до попытки %d ещё %v, отложено в %s", n.ID, next, time.Until(notBefore).Round(time.Second), topic)
+		return nil
+	}
+	if err := route.Wait(ctx); err != nil {
+		return err
+	}
+
+	retrier := NewKafkaRetrier(p.Producers.For(route.Topic), route.Topic)
+	if err := retrier.Retry(ctx, msg, route.Notification(n), next, FirstFailed(msg, time.Now())); err != nil {
+		log.Printf("[DLQ] ❌ Ошибка ретрая %s: %v", n.ID, err)
+		p.Metrics.retryError(n.Category)
+		// сообщение уже прочитано из DLQ, поэтому сохраняем его на стоянке, а не теряем
+		return p.park(ctx, msg, n, next, "retry failed: "+err.Error())
+	}
+	log.Printf("[DLQ] 🔁 Повторная отправка: %s", n.ID)
+	p.Metrics.retried(n.Category)
+	p.record(ctx, Record{Message: msg, Notification: n, Attempt: next, Outcome: OutcomeRetried,
+		Reason: fmt.Sprintf("rule %s: retry %d/%d to %s after %s", route.Rule, next, policy.MaxAttempts, route.Topic, msg.Topic)})
+	return nil
+}
+
+func (p *Processor) park(ctx context.Context, msg kafka.Message, n Notification, attempt int, reason string) error {
+	// запись делается до отправки: если стоянка недоступна, сообщение останется в БД
+	p.record(ctx, Record{Message: msg, Notification: n, Attempt: attempt, Outcome: OutcomeParked, Reason: reason})
+	if err := p.Parker.Park(ctx, msg, reason); err != nil {
+		log.Printf("[DLQ] ❌ Не удалось отправить на стоянку: %v", err)
+		return err
+	}
+	p.Metrics.parked(n.Category)
+	return nil
+}
+
+func (p *Processor) quarantine(ctx context.Context, msg kafka.Message, n Notification, attempt int, errs []string) error {
+	if len(n.Validate()) > 0 {
+		// поля с нарушенной моделью могут не поместиться в колонки: сообщение сохраняется по смещению
+		n = Notification{}
+	}
+	// как и на стоянке, запись делается до отправки; без неё сообщение в карантине
+	// нельзя найти и переотправить из БД, поэтому её ошибка прерывает обработку
+	if err := p.record(ctx, Record{Message: msg, Notification: n, Attempt: attempt, Outcome: OutcomeQuarantined,
+		Reason: "validation failed: " + strings.Join(errs, "; "), Errors: errs}); err != nil {
+		return err
+	}
+	if err := p.Quarantine.Put(ctx, msg, errs); err != nil {
+		log.Printf("[DLQ] ❌ Не удалось отправить в карантин: %v", err)
+		return err
 	}
+	p.Metrics.quarantined(n.Category)
+	return nil
+}
 
-	RunWithGracefulShutdown(consumer, handler)
+// record сохраняет решение в БД. Ошибку возвращают пути отбрасывания и карантина;
+// при повторе и стоянке сообщение остаётся в Kafka
+func (p *Processor) record(ctx context.Context, rec Record) error {
+	if p.Recorder == nil {
+		return nil
+	}
+	if err := p.Recorder.Record(ctx, rec); err != nil {
+		log.Printf("[DLQ] ❌ Не удалось сохранить сообщение %s: %v", rec.Notification.ID, err)
+		return err
+	}
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
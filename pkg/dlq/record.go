@@ -0,0 +1,32 @@
+package dlq
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Исходы обработки сообщения процессором
+const (
+	OutcomeRetried = "retried"
+	OutcomeParked  = "parked"
+	OutcomeSkipped = "skipped"
+	// сообщение не прошло проверку модели или схемы payload
+	OutcomeQuarantined = "quarantined"
+)
+
+// Record — сообщение DLQ и решение процессора по нему
+type Record struct {
+	Message      kafka.Message
+	Notification Notification // пустое, если сообщение не распарсилось
+	Attempt      int          // номер попытки, которой соответствует решение
+	Outcome      string
+	Reason       string
+	Errors       []string // ошибки проверки для OutcomeQuarantined
+}
+
+// Recorder сохраняет сообщения DLQ, чтобы их можно было найти и переотправить вручную.
+// Ошибка записи не останавливает обработку сообщения
+type Recorder interface {
+	Record(ctx context.Context, rec Record) error
+}
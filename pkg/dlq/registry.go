@@ -0,0 +1,211 @@
+package dlq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidSchema = errors.New("invalid DLQ schema")
+
+// SchemaFile — файл реестра: схема payload одной версии для категории и подтипа.
+// Пустой Subtype — схема для всех подтипов категории без своей схемы.
+// Upgrade переводит payload предыдущей версии (Version-1) в эту
+//
+//	{
+//	  "category": "EMAIL",
+//	  "subtype": "ORDER_CONFIRMATION",
+//	  "version": 2,
+//	  "upgrade": {"rename": [{"from": "mail", "to": "email"}], "set": {"locale": "ru"}},
+//	  "schema": {"type": "object", "required": ["email"], "properties": {"email": {"type": "string", "format": "email"}}}
+//	}
+type SchemaFile struct {
+	Category string   `json:"category"`
+	Subtype  string   `json:"subtype"`
+	Version  int      `json:"version"`
+	Upgrade  *Upgrade `json:"upgrade"`
+	Schema   *Schema  `json:"schema"`
+
+	path string
+}
+
+// Upgrade — шаг миграции payload на следующую версию: переименование,
+// установка и удаление полей, пути через точку. Шаги выполняются в этом порядке,
+// переименования — в порядке списка, поэтому цепочки вида a→b, b→c предсказуемы
+type Upgrade struct {
+	Rename []Rename       `json:"rename"`
+	Set    map[string]any `json:"set"` // путь -> значение, если поле отсутствует
+	Remove []string       `json:"remove"`
+}
+
+// Rename — переименование поля: старый путь -> новый
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (u *Upgrade) apply(payload map[string]interface{}) {
+	for _, rename := range u.Rename {
+		if value, ok := lookup(payload, rename.From); ok {
+			remove(payload, rename.From)
+			set(payload, rename.To, value)
+		}
+	}
+	for path, value := range u.Set {
+		if _, ok := lookup(payload, path); !ok {
+			set(payload, path, value)
+		}
+	}
+	for _, path := range u.Remove {
+		remove(payload, path)
+	}
+}
+
+// SchemaRegistry — схемы payload по категории и подтипу, загруженные из локального
+// каталога. Версии одного ключа идут подряд; сообщение старой версии поднимается
+// до последней и проверяется её схемой. Для уведомлений без схемы проверяется только модель
+type SchemaRegistry struct {
+	schemas map[string][]*SchemaFile // ключ — schemaKey, версии по возрастанию
+}
+
+// NewSchemaRegistry возвращает пустой реестр: проверяется только модель уведомления
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{schemas: make(map[string][]*SchemaFile)}
+}
+
+// LoadSchemas читает все *.json из dir и подкаталогов. Пустой dir — пустой реестр
+func LoadSchemas(dir string) (*SchemaRegistry, error) {
+	registry := NewSchemaRegistry()
+	if dir == "" {
+		return registry, nil
+	}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".json") {
+			return nil
+		}
+		file, err := loadSchemaFile(path)
+		if err != nil {
+			return err
+		}
+		return registry.add(file)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for key, files := range registry.schemas {
+		sort.Slice(files, func(i, j int) bool { return files[i].Version < files[j].Version })
+		for i := 1; i < len(files); i++ {
+			if files[i].Version != files[i-1].Version+1 {
+				return nil, fmt.Errorf("%w: %s: versions %d and %d are not consecutive (%s, %s)",
+					ErrInvalidSchema, key, files[i-1].Version, files[i].Version, files[i-1].path, files[i].path)
+			}
+		}
+	}
+	return registry, nil
+}
+
+func loadSchemaFile(path string) (*SchemaFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file SchemaFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSchema, path, err)
+	}
+	file.path = path
+	if file.Category == "" {
+		return nil, fmt.Errorf("%w: %s: category is required", ErrInvalidSchema, path)
+	}
+	if file.Version <= 0 {
+		return nil, fmt.Errorf("%w: %s: version must be positive", ErrInvalidSchema, path)
+	}
+	if file.Schema == nil {
+		return nil, fmt.Errorf("%w: %s: schema is required", ErrInvalidSchema, path)
+	}
+	if err := file.Schema.compile("schema"); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSchema, path, err)
+	}
+	if file.Upgrade != nil {
+		for i, rename := range file.Upgrade.Rename {
+			if rename.From == "" || rename.To == "" {
+				return nil, fmt.Errorf("%w: %s: upgrade.rename[%d]: from and to are required", ErrInvalidSchema, path, i)
+			}
+		}
+	}
+	return &file, nil
+}
+
+func (r *SchemaRegistry) add(file *SchemaFile) error {
+	key := schemaKey(file.Category, file.Subtype)
+	for _, existing := range r.schemas[key] {
+		if existing.Version == file.Version {
+			return fmt.Errorf("%w: %s version %d is defined in %s and %s",
+				ErrInvalidSchema, key, file.Version, existing.path, file.path)
+		}
+	}
+	r.schemas[key] = append(r.schemas[key], file)
+	return nil
+}
+
+// Len — сколько схем загружено
+func (r *SchemaRegistry) Len() int {
+	total := 0
+	for _, files := range r.schemas {
+		total += len(files)
+	}
+	return total
+}
+
+// Check проверяет модель уведомления и payload по схеме. Payload старой версии
+// поднимается до последней; возвращается обновлённая копия, исходное уведомление
+// не меняется. Непустой список ошибок — сообщение нужно отправить в карантин
+func (r *SchemaRegistry) Check(n Notification) (Notification, []string) {
+	if errs := n.Validate(); len(errs) > 0 {
+		return n, errs
+	}
+	files := r.lookup(n.Category, n.Subtype)
+	if len(files) == 0 {
+		return n, nil
+	}
+
+	version := max(n.Version, 1) // уведомления без версии — первая версия
+	first, latest := files[0], files[len(files)-1]
+	if version > latest.Version {
+		return n, []string{fmt.Sprintf("version: %d is newer than the latest known %d", version, latest.Version)}
+	}
+	if version < first.Version {
+		return n, []string{fmt.Sprintf("version: %d is no longer supported, oldest is %d", version, first.Version)}
+	}
+
+	n.Payload = cloneMap(n.Payload)
+	for _, file := range files[version-first.Version+1:] {
+		if file.Upgrade != nil {
+			file.Upgrade.apply(n.Payload)
+		}
+	}
+	n.Version = latest.Version
+	return n, latest.Schema.Validate(n.Payload, "payload")
+}
+
+func (r *SchemaRegistry) lookup(category, subtype string) []*SchemaFile {
+	if files, ok := r.schemas[schemaKey(category, subtype)]; ok {
+		return files
+	}
+	return r.schemas[schemaKey(category, "")]
+}
+
+func schemaKey(category, subtype string) string {
+	if subtype == "" {
+		return category
+	}
+	return category + "/" + subtype
+}
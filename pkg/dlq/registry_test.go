@@ -0,0 +1,208 @@
+package dlq
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeSchemas(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestUpgradeApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		upgrade Upgrade
+		payload map[string]interface{}
+		want    map[string]interface{}
+	}{
+		{
+			name:    "rename chain follows list order",
+			upgrade: Upgrade{Rename: []Rename{{From: "a", To: "b"}, {From: "b", To: "c"}}},
+			payload: map[string]interface{}{"a": 1},
+			want:    map[string]interface{}{"c": 1},
+		},
+		{
+			name:    "reversed chain renames only the first step",
+			upgrade: Upgrade{Rename: []Rename{{From: "b", To: "c"}, {From: "a", To: "b"}}},
+			payload: map[string]interface{}{"a": 1},
+			want:    map[string]interface{}{"b": 1},
+		},
+		{
+			name:    "rename of a missing field is skipped",
+			upgrade: Upgrade{Rename: []Rename{{From: "mail", To: "email"}}},
+			payload: map[string]interface{}{"name": "x"},
+			want:    map[string]interface{}{"name": "x"},
+		},
+		{
+			name:    "nested rename creates the target object",
+			upgrade: Upgrade{Rename: []Rename{{From: "user.mail", To: "contact.email"}}},
+			payload: map[string]interface{}{"user": map[string]interface{}{"mail": "a@b.c"}},
+			want: map[string]interface{}{
+				"user":    map[string]interface{}{},
+				"contact": map[string]interface{}{"email": "a@b.c"},
+			},
+		},
+		{
+			name:    "set does not overwrite an existing field",
+			upgrade: Upgrade{Set: map[string]any{"locale": "ru", "currency": "KZT"}},
+			payload: map[string]interface{}{"locale": "en"},
+			want:    map[string]interface{}{"locale": "en", "currency": "KZT"},
+		},
+		{
+			name:    "remove runs after rename and set",
+			upgrade: Upgrade{Rename: []Rename{{From: "a", To: "b"}}, Set: map[string]any{"c": 1}, Remove: []string{"b", "c"}},
+			payload: map[string]interface{}{"a": 1},
+			want:    map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.upgrade.apply(tt.payload)
+			if !reflect.DeepEqual(tt.payload, tt.want) {
+				t.Errorf("payload = %v, want %v", tt.payload, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaRegistryCheck(t *testing.T) {
+	dir := writeSchemas(t, map[string]string{
+		"v2.json": `{"category": "EMAIL", "version": 2, "schema": {"type": "object", "required": ["mail"]}}`,
+		"v3.json": `{"category": "EMAIL", "version": 3, "upgrade": {"rename": [{"from": "mail", "to": "email"}]},
+			"schema": {"type": "object", "required": ["email"], "properties": {"email": {"type": "string"}}}}`,
+		"sms.json": `{"category": "SMS", "subtype": "OTP", "version": 1, "schema": {"type": "object", "required": ["code"]}}`,
+	})
+	registry, err := LoadSchemas(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		n           Notification
+		wantVersion int
+		wantErr     string // подстрока первой ошибки, пусто — без ошибок
+		wantPayload map[string]interface{}
+	}{
+		{
+			name:        "oldest version is upgraded to latest",
+			n:           Notification{Category: "EMAIL", Version: 2, Payload: map[string]interface{}{"mail": "a@b.c"}},
+			wantVersion: 3,
+			wantPayload: map[string]interface{}{"email": "a@b.c"},
+		},
+		{
+			name:        "latest version is checked as is",
+			n:           Notification{Category: "EMAIL", Version: 3, Payload: map[string]interface{}{"email": "a@b.c"}},
+			wantVersion: 3,
+			wantPayload: map[string]interface{}{"email": "a@b.c"},
+		},
+		{
+			name:    "version newer than latest",
+			n:       Notification{Category: "EMAIL", Version: 4, Payload: map[string]interface{}{"email": "a@b.c"}},
+			wantErr: "newer than the latest known 3",
+		},
+		{
+			name:    "unversioned message is version 1, below the oldest",
+			n:       Notification{Category: "EMAIL", Payload: map[string]interface{}{"email": "a@b.c"}},
+			wantErr: "no longer supported, oldest is 2",
+		},
+		{
+			name:    "upgraded payload is validated by the latest schema",
+			n:       Notification{Category: "EMAIL", Version: 2, Payload: map[string]interface{}{"mail": 42.0}},
+			wantErr: "email",
+		},
+		{
+			name:    "subtype schema",
+			n:       Notification{Category: "SMS", Subtype: "OTP", Version: 1, Payload: map[string]interface{}{}},
+			wantErr: "code",
+		},
+		{
+			name:        "category without schema checks only the model",
+			n:           Notification{Category: "PUSH", Version: 7, Payload: map[string]interface{}{"any": true}},
+			wantVersion: 7,
+			wantPayload: map[string]interface{}{"any": true},
+		},
+		{
+			name:    "model errors come first",
+			n:       Notification{Version: -1},
+			wantErr: "category: is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := cloneMap(tt.n.Payload)
+			checked, errs := registry.Check(tt.n)
+			if tt.wantErr != "" {
+				if len(errs) == 0 || !strings.Contains(errs[0], tt.wantErr) {
+					t.Fatalf("errors = %v, want one containing %q", errs, tt.wantErr)
+				}
+				return
+			}
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if checked.Version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", checked.Version, tt.wantVersion)
+			}
+			if !reflect.DeepEqual(checked.Payload, tt.wantPayload) {
+				t.Errorf("payload = %v, want %v", checked.Payload, tt.wantPayload)
+			}
+			if !reflect.DeepEqual(tt.n.Payload, original) {
+				t.Errorf("original payload changed: %v", tt.n.Payload)
+			}
+		})
+	}
+}
+
+func TestLoadSchemasRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name: "versions are not consecutive",
+			files: map[string]string{
+				"v1.json": `{"category": "EMAIL", "version": 1, "schema": {"type": "object"}}`,
+				"v3.json": `{"category": "EMAIL", "version": 3, "schema": {"type": "object"}}`,
+			},
+		},
+		{
+			name: "duplicate version",
+			files: map[string]string{
+				"a.json": `{"category": "EMAIL", "version": 1, "schema": {"type": "object"}}`,
+				"b.json": `{"category": "EMAIL", "version": 1, "schema": {"type": "object"}}`,
+			},
+		},
+		{
+			name:  "rename without target",
+			files: map[string]string{"a.json": `{"category": "EMAIL", "version": 1, "upgrade": {"rename": [{"from": "a"}]}, "schema": {"type": "object"}}`},
+		},
+		{
+			name:  "missing schema",
+			files: map[string]string{"a.json": `{"category": "EMAIL", "version": 1}`},
+		},
+		{
+			name:  "non-positive version",
+			files: map[string]string{"a.json": `{"category": "EMAIL", "version": 0, "schema": {"type": "object"}}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadSchemas(writeSchemas(t, tt.files)); !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("err = %v, want ErrInvalidSchema", err)
+			}
+		})
+	}
+}
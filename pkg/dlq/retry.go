@@ -2,6 +2,11 @@ package dlq
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
 )
 
 type KafkaRetrier struct {
@@ -19,3 +24,101 @@ func NewKafkaRetrier(producer KafkaProducer, topic string) *KafkaRetrier {
 func (r *KafkaRetrier) RetryWithKey(ctx context.Context, key string, n Notification) error {
 	return r.Producer.Produce(ctx, r.Topic, key, n) // ✅ передаём весь объект
 }
+
+// Retry отправляет уведомление на повторную обработку с номером попытки attempt
+// и сохранёнными заголовками исходного сообщения
+func (r *KafkaRetrier) Retry(ctx context.Context, msg kafka.Message, n Notification, attempt int, firstFailed time.Time) error {
+	n.WasInDLQ = true
+	value, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	// время отложенного повтора к следующему попаданию в DLQ не относится
+	headers := withHeader(withoutHeader(msg.Headers, HeaderNotBefore), HeaderAttempt, strconv.Itoa(attempt))
+	headers = withHeader(headers, HeaderFirstFailed, firstFailed.UTC().Format(time.RFC3339))
+	return r.Producer.ProduceMessage(ctx, kafka.Message{Key: msg.Key, Value: value, Headers: headers})
+}
+
+// Parker отправляет сообщения, которые не будут повторяться, в топик-стоянку.
+// Сообщение сохраняется байт в байт, причина пишется в заголовок
+type Parker struct {
+	Producer KafkaProducer
+	Topic    string
+}
+
+func NewParker(producer KafkaProducer, topic string) *Parker {
+	return &Parker{
+		Producer: producer,
+		Topic:    topic,
+	}
+}
+
+// Quarantine отправляет сообщения, не прошедшие проверку, в топик карантина.
+// Сообщение сохраняется байт в байт, ошибки проверки пишутся в заголовок
+type Quarantine struct {
+	Producer KafkaProducer
+	Topic    string
+}
+
+func NewQuarantine(producer KafkaProducer, topic string) *Quarantine {
+	return &Quarantine{
+		Producer: producer,
+		Topic:    topic,
+	}
+}
+
+func (q *Quarantine) Put(ctx context.Context, msg kafka.Message, errs []string) error {
+	value, err := json.Marshal(errs)
+	if err != nil {
+		return err
+	}
+	return q.Producer.ProduceMessage(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: withHeader(msg.Headers, HeaderValidationErrors, string(value)),
+	})
+}
+
+// Delayer откладывает сообщение DLQ в топик задержки: сообщение сохраняется
+// байт в байт, время повтора пишется в заголовок
+type Delayer struct {
+	Producers ProducerSource
+	Delays    *Delays
+}
+
+func NewDelayer(producers ProducerSource, delays *Delays) *Delayer {
+	return &Delayer{
+		Producers: producers,
+		Delays:    delays,
+	}
+}
+
+// Delay отправляет сообщение в топик ступени delay и возвращает этот топик
+func (d *Delayer) Delay(ctx context.Context, msg kafka.Message, delay time.Duration) (string, error) {
+	topic := d.Delays.Topic(delay)
+	notBefore := time.Now().Add(delay).UTC().Format(time.RFC3339Nano)
+	return topic, d.Producers.For(topic).ProduceMessage(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: withHeader(msg.Headers, HeaderNotBefore, notBefore),
+	})
+}
+
+// Redelay снова откладывает сообщение, которому ждать дольше ступени его топика.
+// Заголовок HeaderNotBefore сохраняется, поэтому общее время ожидания не растёт
+func (d *Delayer) Redelay(ctx context.Context, msg kafka.Message, notBefore time.Time) (string, error) {
+	topic := d.Delays.Topic(time.Until(notBefore))
+	return topic, d.Producers.For(topic).ProduceMessage(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: msg.Headers,
+	})
+}
+
+func (p *Parker) Park(ctx context.Context, msg kafka.Message, reason string) error {
+	return p.Producer.ProduceMessage(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: withHeader(msg.Headers, HeaderParkedReason, reason),
+	})
+}
@@ -0,0 +1,203 @@
+package dlq
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Route — решение маршрутизатора для одного уведомления
+type Route struct {
+	Rule    string // имя правила, "default" — ни одно не подошло
+	Action  string
+	Topic   string
+	Policy  RetryPolicy
+	limiter *limiter
+	apply   *Transform
+}
+
+// Wait выдерживает ограничение частоты правила
+func (r Route) Wait(ctx context.Context) error {
+	if r.limiter == nil {
+		return nil
+	}
+	return r.limiter.wait(ctx)
+}
+
+// Notification применяет преобразование правила, если оно есть
+func (r Route) Notification(n Notification) Notification {
+	if r.apply == nil {
+		return n
+	}
+	return r.apply.Apply(n)
+}
+
+type compiledRules struct {
+	rules    *RuleSet
+	limiters map[string]*limiter
+}
+
+// Router выбирает действие для уведомления по RuleSet. Правила можно заменить
+// на лету: Reload перечитывает файл, Watch делает это при его изменении
+type Router struct {
+	path         string
+	defaultTopic string
+	current      atomic.Pointer[compiledRules]
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// NewRouter загружает правила из path. Пустой path — DefaultRules.
+// Если файл не загрузился, возвращается ошибка и Router с DefaultRules, который
+// по-прежнему следит за path: исправленный файл подхватится Watch без перезапуска
+func NewRouter(path, defaultTopic string) (*Router, error) {
+	r := &Router{path: path, defaultTopic: defaultTopic}
+	r.set(DefaultRules())
+	if path == "" {
+		return r, nil
+	}
+	if err := r.Reload(); err != nil {
+		// тот же неисправный файл не перечитывается на каждом тике Watch
+		if info, statErr := os.Stat(path); statErr == nil {
+			r.modTime = info.ModTime()
+		}
+		return r, err
+	}
+	return r, nil
+}
+
+// Reload перечитывает файл правил. При ошибке остаются прежние правила
+func (r *Router) Reload() error {
+	if r.path == "" {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+	rules, err := LoadRules(r.path)
+	if err != nil {
+		return err
+	}
+	r.modTime = info.ModTime()
+	r.set(rules)
+	log.Printf("[DLQ] 📜 Загружено правил: %d из %s", len(rules.Rules), r.path)
+	return nil
+}
+
+// Watch проверяет время изменения файла раз в interval и перечитывает его
+func (r *Router) Watch(ctx context.Context, interval time.Duration) {
+	if r.path == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(r.path)
+		if err != nil {
+			log.Printf("[DLQ] ❌ Файл правил недоступен: %v", err)
+			continue
+		}
+		r.mu.Lock()
+		changed := !info.ModTime().Equal(r.modTime)
+		r.mu.Unlock()
+		if !changed {
+			continue
+		}
+		if err := r.Reload(); err != nil {
+			log.Printf("[DLQ] ❌ Правила не обновлены, действуют прежние: %v", err)
+		}
+	}
+}
+
+// Route возвращает действие первого подходящего правила
+func (r *Router) Route(n Notification) Route {
+	compiled := r.current.Load()
+	topic := compiled.rules.DefaultTopic
+	if topic == "" {
+		topic = r.defaultTopic
+	}
+	for i := range compiled.rules.Rules {
+		rule := &compiled.rules.Rules[i]
+		if !rule.Match.Matches(n) {
+			continue
+		}
+		route := Route{Rule: rule.Name, Action: rule.Action, Topic: topic, limiter: compiled.limiters[rule.Name], apply: rule.Transform}
+		if rule.Topic != "" {
+			route.Topic = rule.Topic
+		}
+		if rule.Retry != nil {
+			route.Policy = *rule.Retry
+		}
+		return route
+	}
+	return Route{Rule: "default", Action: compiled.rules.DefaultAction, Topic: topic}
+}
+
+// set заменяет правила. Ограничители правил с тем же именем и лимитом переносятся,
+// чтобы перезагрузка не обнуляла накопленные ожидания
+func (r *Router) set(rules *RuleSet) {
+	if rules.DefaultAction == "" {
+		rules.DefaultAction = ActionDrop
+	}
+	previous := r.current.Load()
+	limiters := make(map[string]*limiter)
+	for _, rule := range rules.Rules {
+		if rule.RateLimit == nil {
+			continue
+		}
+		if previous != nil {
+			if old, ok := previous.limiters[rule.Name]; ok && old.limit == *rule.RateLimit {
+				limiters[rule.Name] = old
+				continue
+			}
+		}
+		limiters[rule.Name] = newLimiter(*rule.RateLimit)
+	}
+	r.current.Store(&compiledRules{rules: rules, limiters: limiters})
+}
+
+// limiter — token bucket: PerSecond токенов в секунду, не больше Burst в запасе
+type limiter struct {
+	limit RateLimit
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(limit RateLimit) *limiter {
+	return &limiter{limit: limit, tokens: float64(limit.Burst), last: time.Now()}
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = min(float64(l.limit.Burst), l.tokens+now.Sub(l.last).Seconds()*l.limit.PerSecond)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.limit.PerSecond * float64(time.Second))
+		l.mu.Unlock()
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package dlq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Действия правил маршрутизации
+const (
+	ActionRetry     = "retry"     // повторить в Topic (по умолчанию — исходный топик)
+	ActionPark      = "park"      // сразу отправить на стоянку
+	ActionDrop      = "drop"      // отбросить, сообщение останется только в БД
+	ActionTransform = "transform" // изменить уведомление и повторить как retry
+)
+
+var ErrInvalidRules = errors.New("invalid DLQ rules")
+
+// RuleSet — правила маршрутизации DLQ. Применяется первое подходящее правило,
+// если не подошло ни одно — DefaultAction
+type RuleSet struct {
+	Rules         []Rule `json:"rules" yaml:"rules"`
+	DefaultAction string `json:"default_action" yaml:"default_action"`
+	DefaultTopic  string `json:"default_topic" yaml:"default_topic"` // пусто — топик из конфига
+}
+
+type Rule struct {
+	Name      string       `json:"name" yaml:"name"`
+	Match     Match        `json:"match" yaml:"match"`
+	Action    string       `json:"action" yaml:"action"`
+	Topic     string       `json:"topic" yaml:"topic"`
+	Retry     *RetryPolicy `json:"retry" yaml:"retry"`
+	RateLimit *RateLimit   `json:"rate_limit" yaml:"rate_limit"`
+	Transform *Transform   `json:"transform" yaml:"transform"`
+}
+
+// Match — условия правила, все заданные должны выполняться. Пустой Match подходит всем
+type Match struct {
+	Categories []string `json:"categories" yaml:"categories"`
+	Subtypes   []string `json:"subtypes" yaml:"subtypes"`
+	UserIDs    []uint32 `json:"user_ids" yaml:"user_ids"`
+	// путь к полю payload через точку -> ожидаемое значение в строковом виде
+	Payload map[string]string `json:"payload" yaml:"payload"`
+}
+
+// RateLimit ограничивает частоту повторов по правилу, лишние сообщения ждут
+type RateLimit struct {
+	PerSecond float64 `json:"per_second" yaml:"per_second"`
+	Burst     int     `json:"burst" yaml:"burst"`
+}
+
+// Transform меняет уведомление перед повтором: категорию, подтип и поля payload
+type Transform struct {
+	Category string         `json:"category" yaml:"category"`
+	Subtype  string         `json:"subtype" yaml:"subtype"`
+	Set      map[string]any `json:"set" yaml:"set"`       // путь через точку -> новое значение
+	Remove   []string       `json:"remove" yaml:"remove"` // пути удаляемых полей
+}
+
+// Duration читается из строки вида "30s" или "5m"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	return d.parse(node.Value)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) parse(s string) error {
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// LoadRules читает правила из YAML (.yaml, .yml) или JSON-файла и проверяет их
+func LoadRules(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules RuleSet
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &rules)
+	default:
+		err = json.Unmarshal(data, &rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRules, path, err)
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// Validate проверяет правила и подставляет значения по умолчанию
+func (rs *RuleSet) Validate() error {
+	if rs.DefaultAction == "" {
+		rs.DefaultAction = ActionDrop
+	}
+	if rs.DefaultAction != ActionDrop && rs.DefaultAction != ActionPark {
+		return fmt.Errorf("%w: default_action must be %s or %s", ErrInvalidRules, ActionDrop, ActionPark)
+	}
+	names := make(map[string]bool, len(rs.Rules))
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("%w: duplicate rule name %q", ErrInvalidRules, rule.Name)
+		}
+		names[rule.Name] = true
+
+		switch rule.Action {
+		case ActionRetry, ActionPark, ActionDrop:
+		case ActionTransform:
+			if rule.Transform == nil {
+				return fmt.Errorf("%w: rule %q: transform action requires transform", ErrInvalidRules, rule.Name)
+			}
+		default:
+			return fmt.Errorf("%w: rule %q: unknown action %q", ErrInvalidRules, rule.Name, rule.Action)
+		}
+		if rule.retries() {
+			if rule.Retry == nil {
+				policy := DefaultRetryPolicy
+				rule.Retry = &policy
+			}
+			if rule.Retry.MaxAttempts <= 0 || rule.Retry.BaseDelay <= 0 || rule.Retry.MaxDelay < rule.Retry.BaseDelay {
+				return fmt.Errorf("%w: rule %q: retry needs max_attempts > 0 and 0 < base_delay <= max_delay", ErrInvalidRules, rule.Name)
+			}
+			if rule.Retry.Jitter < 0 || rule.Retry.Jitter > 1 {
+				return fmt.Errorf("%w: rule %q: jitter must be within [0, 1]", ErrInvalidRules, rule.Name)
+			}
+		}
+		if rule.RateLimit != nil {
+			if rule.RateLimit.PerSecond <= 0 || rule.RateLimit.Burst < 0 {
+				return fmt.Errorf("%w: rule %q: rate_limit needs per_second > 0", ErrInvalidRules, rule.Name)
+			}
+			if rule.RateLimit.Burst == 0 {
+				rule.RateLimit.Burst = 1
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Rule) retries() bool {
+	return r.Action == ActionRetry || r.Action == ActionTransform
+}
+
+// Matches проверяет уведомление по условиям правила
+func (m *Match) Matches(n Notification) bool {
+	if len(m.Categories) > 0 && !slices.Contains(m.Categories, n.Category) {
+		return false
+	}
+	if len(m.Subtypes) > 0 && !slices.Contains(m.Subtypes, n.Subtype) {
+		return false
+	}
+	if len(m.UserIDs) > 0 && !slices.Contains(m.UserIDs, n.UserID) {
+		return false
+	}
+	for path, expected := range m.Payload {
+		value, ok := lookup(n.Payload, path)
+		if !ok || fmt.Sprint(value) != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply возвращает изменённую копию уведомления, исходный payload не меняется
+func (t *Transform) Apply(n Notification) Notification {
+	n.Payload = cloneMap(n.Payload)
+	if t.Category != "" {
+		n.Category = t.Category
+	}
+	if t.Subtype != "" {
+		n.Subtype = t.Subtype
+	}
+	for path, value := range t.Set {
+		set(n.Payload, path, value)
+	}
+	for _, path := range t.Remove {
+		remove(n.Payload, path)
+	}
+	return n
+}
+
+func lookup(payload map[string]interface{}, path string) (any, bool) {
+	var current any = payload
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func set(payload map[string]interface{}, path string, value any) {
+	parts := strings.Split(path, ".")
+	current := payload
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+func remove(payload map[string]interface{}, path string) {
+	parts := strings.Split(path, ".")
+	current := payload
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		current = next
+	}
+	delete(current, parts[len(parts)-1])
+}
+
+// cloneMap копирует вложенные объекты, чтобы Transform не менял исходное уведомление
+func cloneMap(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = cloneMap(nested)
+		}
+		result[k] = v
+	}
+	return result
+}
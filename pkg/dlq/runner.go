@@ -0,0 +1,396 @@
+package dlq
+
+import (
+	"admin/configs"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// RulesReloadInterval — как часто проверяется изменение файла правил
+	RulesReloadInterval = 10 * time.Second
+	// DefaultWorkers — сколько партиций обрабатывается одновременно
+	DefaultWorkers = 4
+
+	workerQueueSize  = 16
+	connectRetry     = 10 * time.Second
+	handleRetryDelay = time.Second
+	handleRetryMax   = time.Minute
+	commitTimeout    = 10 * time.Second
+	// после стольких подряд неудачных обработок Runner считается нездоровым
+	unhealthyFailures = 5
+)
+
+var (
+	ErrAlreadyStarted = errors.New("dlq runner already started")
+	ErrNotStarted     = errors.New("dlq runner is not started")
+)
+
+// Health — состояние Runner для проверок готовности
+type Health struct {
+	Running       bool            `json:"running"`
+	Connected     bool            `json:"connected"`
+	Healthy       bool            `json:"healthy"`
+	InFlight      int64           `json:"in_flight"`
+	Committed     int64           `json:"committed"`
+	Failures      int64           `json:"consecutive_failures"`
+	Lag           int64           `json:"lag"`
+	LastMessageAt time.Time       `json:"last_message_at"`
+	LastError     string          `json:"last_error"`
+	LastErrorAt   time.Time       `json:"last_error_at"`
+	Metrics       MetricsSnapshot `json:"metrics"`
+}
+
+// Runner — фоновый процессор DLQ. Start не блокирует: подключение к Kafka и чтение
+// идут в горутинах. Сообщения одной партиции обрабатывает один воркер по порядку,
+// смещение коммитится только после успешной обработки — семантика at-least-once.
+// Неудачная обработка повторяется, пока не пройдёт или Runner не остановят
+type Runner struct {
+	conf     configs.DlqConfig
+	recorder Recorder
+	schemas  *SchemaRegistry
+	router   *Router
+	workers  int
+
+	mu        sync.Mutex
+	cancel    context.CancelFunc
+	done      chan struct{}
+	consumers []*Consumer // DLQ и топики задержки
+
+	connected atomic.Bool
+	inFlight  atomic.Int64
+	committed atomic.Int64
+	failures  atomic.Int64
+	lastMsg   atomic.Int64 // unix nano
+	health    sync.Mutex
+	lastErr   string
+	lastErrAt time.Time
+}
+
+// NewRunner создаёт процессор; schemas == nil — проверяется только модель уведомлений
+func NewRunner(conf configs.DlqConfig, recorder Recorder, schemas *SchemaRegistry) *Runner {
+	workers := conf.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if schemas == nil {
+		schemas = NewSchemaRegistry()
+	}
+	router, err := NewRouter(conf.RulesFile, conf.ProducerTopic)
+	if err != nil {
+		// router уже с правилами по умолчанию и перечитает файл, когда его исправят
+		log.Printf("[DLQ] ❌ Не удалось загрузить правила, используются правила по умолчанию: %v", err)
+	}
+	return &Runner{
+		conf:     conf,
+		recorder: recorder,
+		schemas:  schemas,
+		router:   router,
+		workers:  workers,
+	}
+}
+
+// Router — правила маршрутизации процессора, перечитываются при изменении файла,
+// пока Runner работает
+func (r *Runner) Router() *Router {
+	return r.router
+}
+
+// Start запускает обработку; Runner останавливается вызовом Stop или отменой ctx
+func (r *Runner) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		return ErrAlreadyStarted
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	go r.run(ctx)
+	log.Printf("[DLQ] 🚀 Процессор запущен, воркеров: %d", r.workers)
+	return nil
+}
+
+// Stop прекращает чтение, дожидается текущих обработок и закрывает соединения.
+// Незакоммиченные сообщения будут прочитаны заново после перезапуска
+func (r *Runner) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel = nil
+	r.mu.Unlock()
+	if cancel == nil {
+		return ErrNotStarted
+	}
+
+	cancel()
+	select {
+	case <-done:
+		log.Printf("[DLQ] 🛑 Процессор остановлен: %+v", DefaultMetrics().Snapshot())
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Runner) Health() Health {
+	r.health.Lock()
+	lastErr, lastErrAt := r.lastErr, r.lastErrAt
+	r.health.Unlock()
+
+	r.mu.Lock()
+	running, consumers := r.cancel != nil, r.consumers
+	r.mu.Unlock()
+
+	h := Health{
+		Running:     running,
+		Connected:   r.connected.Load(),
+		InFlight:    r.inFlight.Load(),
+		Committed:   r.committed.Load(),
+		Failures:    r.failures.Load(),
+		LastError:   lastErr,
+		LastErrorAt: lastErrAt,
+		Metrics:     DefaultMetrics().Snapshot(),
+	}
+	if nanos := r.lastMsg.Load(); nanos > 0 {
+		h.LastMessageAt = time.Unix(0, nanos)
+	}
+	if h.Connected {
+		for _, consumer := range consumers {
+			h.Lag += consumer.Lag()
+		}
+	}
+	h.Healthy = h.Running && h.Connected && h.Failures < unhealthyFailures
+	return h
+}
+
+func (r *Runner) run(ctx context.Context) {
+	defer close(r.done)
+
+	conn, err := r.connect(ctx)
+	if err != nil {
+		return // ctx отменён до подключения
+	}
+	r.mu.Lock()
+	r.consumers = conn.consumers
+	r.mu.Unlock()
+	r.connected.Store(true)
+	defer func() {
+		r.connected.Store(false)
+		conn.close()
+	}()
+
+	go conn.processor.Router.Watch(ctx, RulesReloadInterval)
+
+	var wg sync.WaitGroup
+	for _, consumer := range conn.consumers {
+		wg.Add(1)
+		go func(consumer *Consumer) {
+			defer wg.Done()
+			r.consume(ctx, consumer, conn.processor)
+		}(consumer)
+	}
+	wg.Wait()
+}
+
+// consume читает один топик и раздаёт сообщения воркерам по партициям
+func (r *Runner) consume(ctx context.Context, consumer *Consumer, processor *Processor) {
+	queues := make([]chan kafka.Message, r.workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, workerQueueSize)
+		wg.Add(1)
+		go func(queue <-chan kafka.Message) {
+			defer wg.Done()
+			for msg := range queue {
+				// после остановки оставшиеся сообщения не обрабатываются и не коммитятся:
+				// коммит позиционный и подтвердил бы незавершённые сообщения партиции
+				if ctx.Err() != nil {
+					r.inFlight.Add(-1)
+					continue
+				}
+				r.handle(ctx, consumer, processor, msg)
+			}
+		}(queues[i])
+	}
+
+	for {
+		msg, err := consumer.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			r.fail(fmt.Errorf("fetch: %w", err))
+			if sleep(ctx, handleRetryDelay) != nil {
+				break
+			}
+			continue
+		}
+		r.inFlight.Add(1)
+		select {
+		case queues[msg.Partition%r.workers] <- msg:
+		case <-ctx.Done():
+			r.inFlight.Add(-1)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+}
+
+// connection — подключения Runner к Kafka на время работы
+type connection struct {
+	consumers  []*Consumer
+	processor  *Processor
+	parking    *SimpleKafkaProducer
+	quarantine *SimpleKafkaProducer
+	producers  *TopicProducers
+}
+
+func (c *connection) close() {
+	for _, consumer := range c.consumers {
+		if err := consumer.Close(); err != nil {
+			log.Printf("[DLQ] ❌ Ошибка закрытия консьюмера: %v", err)
+		}
+	}
+	if err := c.producers.Close(); err != nil {
+		log.Printf("[DLQ] ❌ Ошибка закрытия продюсеров: %v", err)
+	}
+	if err := c.parking.Close(); err != nil {
+		log.Printf("[DLQ] ❌ Ошибка закрытия продюсера стоянки: %v", err)
+	}
+	if err := c.quarantine.Close(); err != nil {
+		log.Printf("[DLQ] ❌ Ошибка закрытия продюсера карантина: %v", err)
+	}
+}
+
+// connect создаёт консьюмер и продюсеры. kafkaService паникует, если Kafka
+// недоступна дольше таймаута, поэтому попытки повторяются, пока Runner не остановят
+func (r *Runner) connect(ctx context.Context) (*connection, error) {
+	brokers := []string{r.conf.Broker}
+	parkingTopic := r.conf.ParkingTopic
+	if parkingTopic == "" {
+		parkingTopic = r.conf.ConsumerTopic + ParkingTopicSuffix
+	}
+	quarantineTopic := r.conf.QuarantineTopic
+	if quarantineTopic == "" {
+		quarantineTopic = r.conf.ConsumerTopic + QuarantineTopicSuffix
+	}
+
+	delays := NewDelays(r.conf.ConsumerTopic)
+
+	for {
+		conn, err := r.dial(brokers, delays, parkingTopic, quarantineTopic)
+		if err == nil {
+			conn.producers = NewTopicProducers(brokers)
+			conn.processor = NewProcessor(r.router, conn.producers, NewDelayer(conn.producers, delays), NewParker(conn.parking, parkingTopic),
+				NewQuarantine(conn.quarantine, quarantineTopic), r.schemas, DefaultMetrics(), r.recorder)
+			log.Printf("[DLQ] ⚙️ Broker: %s, Topic: %s, Delays: %v, Parking: %s, Quarantine: %s, Schemas: %d",
+				r.conf.Broker, r.conf.ConsumerTopic, delays.Topics(), parkingTopic, quarantineTopic, r.schemas.Len())
+			return conn, nil
+		}
+		r.fail(err)
+		if err := sleep(ctx, connectRetry); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (r *Runner) dial(brokers []string, delays *Delays, parkingTopic, quarantineTopic string) (conn *connection, err error) {
+	conn = &connection{}
+	defer func() {
+		if p := recover(); p != nil {
+			// уже открытые консьюмеры закрываются, при следующей попытке они создаются заново
+			for _, consumer := range conn.consumers {
+				consumer.Close()
+			}
+			err = fmt.Errorf("connect: %v", p)
+		}
+	}()
+	conn.consumers = append(conn.consumers, NewConsumer(brokers, r.conf.ConsumerTopic, "dlq-processor-group", "dlq-processor-client"))
+	for _, topic := range delays.Topics() {
+		// у каждого топика задержки своя группа: состав одной не меняется из-за другой
+		conn.consumers = append(conn.consumers, NewConsumer(brokers, topic, "dlq-processor-group-"+topic, "dlq-processor-client"))
+	}
+	conn.parking = NewKafkaProducer(brokers, parkingTopic)
+	conn.quarantine = NewKafkaProducer(brokers, quarantineTopic)
+	return conn, nil
+}
+
+// handle обрабатывает сообщение до успеха и коммитит его смещение.
+// Пока сообщение не обработано, следующие сообщения его партиции ждут.
+// Сообщение из топика задержки сначала дожидается своего времени, но не дольше ступени
+// топика: следующие сообщения партиции ждут не дольше неё. Если ждать ещё осталось,
+// Processor откладывает сообщение снова
+func (r *Runner) handle(ctx context.Context, consumer *Consumer, processor *Processor, msg kafka.Message) {
+	defer r.inFlight.Add(-1)
+	if notBefore, ok := NotBefore(msg); ok {
+		if tier, ok := processor.Delayer.Delays.Tier(msg.Topic); ok {
+			if sleep(ctx, min(time.Until(notBefore), tier)) != nil {
+				return
+			}
+		}
+	}
+	r.lastMsg.Store(time.Now().UnixNano())
+
+	delay := handleRetryDelay
+	for {
+		err := r.safeHandle(ctx, processor, msg)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		r.fail(fmt.Errorf("partition %d offset %d: %w", msg.Partition, msg.Offset, err))
+		if sleep(ctx, delay) != nil {
+			return
+		}
+		delay = min(delay*2, handleRetryMax)
+	}
+
+	// обработка, завершившаяся вместе с остановкой, могла не дойти до конца:
+	// сообщение не коммитится и будет прочитано заново после перезапуска
+	if ctx.Err() != nil {
+		return
+	}
+	// сам коммит не прерывается остановкой, начатый коммит доводится до конца
+	commitCtx, cancel := context.WithTimeout(context.Background(), commitTimeout)
+	defer cancel()
+	if err := consumer.Commit(commitCtx, msg); err != nil {
+		r.fail(fmt.Errorf("commit partition %d offset %d: %w", msg.Partition, msg.Offset, err))
+		return
+	}
+	r.committed.Add(1)
+	r.failures.Store(0)
+}
+
+// safeHandle превращает панику продюсера (Kafka недоступна) в ошибку обработки
+func (r *Runner) safeHandle(ctx context.Context, processor *Processor, msg kafka.Message) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	log.Printf("[DLQ] 📩 Сообщение: key=%s, partition=%d, offset=%d", string(msg.Key), msg.Partition, msg.Offset)
+	return processor.Handle(ctx, msg)
+}
+
+func (r *Runner) fail(err error) {
+	log.Printf("[DLQ] ❌ %v", err)
+	r.failures.Add(1)
+	r.health.Lock()
+	r.lastErr, r.lastErrAt = err.Error(), time.Now()
+	r.health.Unlock()
+}
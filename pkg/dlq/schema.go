@@ -0,0 +1,292 @@
+package dlq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema — подмножество JSON Schema, которого хватает для payload уведомлений:
+// type, properties, required, additionalProperties, items, enum, minimum/maximum,
+// minLength/maxLength, minItems/maxItems, pattern и format (date-time, email, uuid).
+// Остальные ключевые слова, как и в JSON Schema, игнорируются
+type Schema struct {
+	Type                 Types              `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *Additional        `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+	Pattern              string             `json:"pattern"`
+	Format               string             `json:"format"`
+
+	pattern *regexp.Regexp
+}
+
+// Types — значение type: одна строка или список
+type Types []string
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = list
+	return nil
+}
+
+// Additional — значение additionalProperties: true/false или схема лишних полей
+type Additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+func (a *Additional) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		a.Allowed = allowed
+		return nil
+	}
+	a.Allowed = true
+	return json.Unmarshal(data, &a.Schema)
+}
+
+var (
+	schemaTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// compile проверяет схему и компилирует регулярные выражения; path — место в файле для ошибок
+func (s *Schema) compile(path string) error {
+	for _, t := range s.Type {
+		if !slices.Contains(schemaTypes, t) {
+			return fmt.Errorf("%s: unknown type %q", path, t)
+		}
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: pattern: %v", path, err)
+		}
+		s.pattern = pattern
+	}
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%s.%s: empty schema", path, name)
+		}
+		if err := property.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(path + "[]"); err != nil {
+			return err
+		}
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		if err := s.AdditionalProperties.Schema.compile(path + ".*"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate проверяет значение, декодированное из JSON, и возвращает все нарушения
+// в виде "путь: описание". Пустой результат — значение подходит
+func (s *Schema) Validate(value any, path string) []string {
+	var errs []string
+	s.validate(value, path, &errs)
+	return errs
+}
+
+func (s *Schema) validate(value any, path string, errs *[]string) {
+	addf := func(format string, args ...any) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(value, t) }) {
+		addf("expected %s, got %s", strings.Join(s.Type, " or "), typeOf(value))
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return jsonEqual(e, value) }) {
+		addf("must be one of %s", enumString(s.Enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, path+"."+name+": is required")
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := s.Properties[name]; ok {
+				property.validate(v[name], path+"."+name, errs)
+				continue
+			}
+			if s.AdditionalProperties == nil {
+				continue
+			}
+			if !s.AdditionalProperties.Allowed {
+				*errs = append(*errs, path+"."+name+": unexpected field")
+			} else if s.AdditionalProperties.Schema != nil {
+				s.AdditionalProperties.Schema.validate(v[name], path+"."+name, errs)
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			addf("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			addf("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			addf("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			addf("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			addf("must match %s", s.Pattern)
+		}
+		if !validFormat(s.Format, v) {
+			addf("must be a valid %s", s.Format)
+		}
+	default:
+		if number, ok := toFloat(value); ok {
+			if s.Minimum != nil && number < *s.Minimum {
+				addf("must be >= %v", *s.Minimum)
+			}
+			if s.Maximum != nil && number > *s.Maximum {
+				addf("must be <= %v", *s.Maximum)
+			}
+		}
+	}
+}
+
+func hasType(value any, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		number, ok := toFloat(value)
+		return ok && number == math.Trunc(number)
+	}
+	return false
+}
+
+func typeOf(value any) string {
+	for _, t := range []string{"null", "object", "array", "string", "boolean", "integer", "number"} {
+		if hasType(value, t) {
+			return t
+		}
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// toFloat приводит число к float64: из JSON приходит float64, уведомления,
+// собранные в коде, могут содержать целые типы
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	}
+	return 0, false
+}
+
+func validFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	}
+	return true
+}
+
+// jsonEqual сравнивает значения так, как они выглядят в JSON: 1 и 1.0 равны
+func jsonEqual(a, b any) bool {
+	left, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(left, right)
+}
+
+func enumString(values []any) string {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Sprint(values)
+	}
+	return string(data)
+}
@@ -0,0 +1,80 @@
+package event
+
+import "github.com/shopspring/decimal"
+
+// Доменные события каталога и остатков. Пишутся в outbox в транзакции изменения
+// и публикуются в Kafka с ключом "<агрегат>:<id>", поэтому события одного агрегата
+// приходят подписчикам по порядку. Доставка at-least-once: дубликаты отсеиваются по ID события
+const (
+	AggregateProduct  = "product"
+	AggregateVariant  = "variant"
+	AggregateCategory = "category"
+	AggregateOrder    = "order"
+
+	ProductCreated      = "product.created"
+	ProductUpdated      = "product.updated"
+	ProductDeleted      = "product.deleted"
+	VariantPriceChanged = "variant.price_changed"
+	VariantStockChanged = "variant.stock_changed"
+	CategoryMoved       = "category.moved"
+
+	// ответы на события заказа
+	StockReserved          = "stock.reserved"
+	StockReservationFailed = "stock.reservation_failed"
+	StockCommitted         = "stock.committed"
+	StockReleased          = "stock.released"
+	StockRestocked         = "stock.restocked"
+	StockOperationFailed   = "stock.operation_failed"
+)
+
+// ProductData — данные product.created и product.updated
+type ProductData struct {
+	ProductID  uint   `json:"product_id"`
+	Name       string `json:"name"`
+	CategoryID uint   `json:"category_id"`
+	BrandID    uint   `json:"brand_id"`
+	IsActive   bool   `json:"is_active"`
+}
+
+type ProductDeletedData struct {
+	ProductID uint `json:"product_id"`
+	Hard      bool `json:"hard"` // удалён без возможности восстановления
+}
+
+type PriceChangedData struct {
+	VariantID   uint            `json:"variant_id"`
+	ProductID   uint            `json:"product_id"`
+	OldPrice    decimal.Decimal `json:"old_price"`
+	OldDiscount decimal.Decimal `json:"old_discount"`
+	Price       decimal.Decimal `json:"price"`
+	Discount    decimal.Decimal `json:"discount"`
+	Source      string          `json:"source"`
+}
+
+type StockChangedData struct {
+	VariantID     uint   `json:"variant_id"`
+	ProductID     uint   `json:"product_id"`
+	Stock         uint32 `json:"stock"`
+	ReservedStock uint32 `json:"reserved_stock"`
+}
+
+// CategoryMovedData — смена родителя категории; nil — корень
+type CategoryMovedData struct {
+	CategoryID  uint  `json:"category_id"`
+	OldParentID *uint `json:"old_parent_id"`
+	NewParentID *uint `json:"new_parent_id"`
+}
+
+// StockLine — позиция заказа
+type StockLine struct {
+	VariantID uint   `json:"variant_id"`
+	Quantity  uint32 `json:"quantity"`
+}
+
+// StockResultData — результат обработки события заказа; Reason заполнен у отказов
+type StockResultData struct {
+	OrderID     uint        `json:"order_id"`
+	SourceEvent string      `json:"source_event_id"`
+	Lines       []StockLine `json:"lines,omitempty"`
+	Reason      string      `json:"reason,omitempty"`
+}
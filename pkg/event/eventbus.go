@@ -1,28 +1,301 @@
 package event
 
-const ( // описание всех событий:
-	LInkVisitedEvent = "link.visited"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"admin/pkg/logger"
+)
+
+// DefaultBuffer — размер очереди асинхронного подписчика по умолчанию
+const DefaultBuffer = 64
+
+var (
+	ErrBusClosed  = errors.New("event bus is closed")
+	ErrBufferFull = errors.New("subscriber buffer is full")
+)
+
+// Topic — типизированная тема: публиковать и подписываться можно только значениями T
+type Topic[T any] struct {
+	name string
+}
+
+var (
+	topicsMu sync.Mutex
+	topics   = map[string]reflect.Type{}
 )
 
-type Event struct {
-	Type string
-	Data any
+// NewTopic регистрирует тему. Повторная регистрация имени с другим типом — ошибка программиста, паника
+func NewTopic[T any](name string) Topic[T] {
+	typ := reflect.TypeFor[T]()
+	topicsMu.Lock()
+	defer topicsMu.Unlock()
+	if registered, ok := topics[name]; ok && registered != typ {
+		panic(fmt.Sprintf("event: topic %q already registered with type %v", name, registered))
+	}
+	topics[name] = typ
+	return Topic[T]{name: name}
+}
+
+func (t Topic[T]) Name() string {
+	return t.name
+}
+
+// Handler обрабатывает событие темы
+type Handler[T any] func(ctx context.Context, payload T) error
+
+// Options настраивают асинхронного подписчика
+type Options struct {
+	// Buffer — размер очереди; 0 — DefaultBuffer
+	Buffer int
+	// Block — ждать места в очереди (до отмены ctx публикующего) вместо отбрасывания события
+	Block bool
+}
+
+// Bus — шина событий внутри процесса. Каждое событие получают все подписчики темы.
+// Синхронные обработчики (Hook) выполняются в горутине публикующего: их ошибка
+// возвращается из Publish, а в транзакции, переданной через контекст, они участвуют
+// наравне с вызывающим кодом. Асинхронные (Subscribe) получают событие через свою
+// ограниченную очередь в отдельной горутине. Паника обработчика не выходит за его пределы
+type Bus struct {
+	mu      sync.RWMutex
+	hooks   map[string][]*Subscription
+	subs    map[string][]*Subscription
+	closed  bool
+	closing chan struct{} // закрывается в Close и прерывает ожидающие места отправки
+	senders sync.WaitGroup
+	wg      sync.WaitGroup
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		hooks:   make(map[string][]*Subscription),
+		subs:    make(map[string][]*Subscription),
+		closing: make(chan struct{}),
+	}
+}
+
+// Subscription — подписка на тему; Stats показывает её счётчики
+type Subscription struct {
+	Name  string
+	Topic string
+
+	handle func(ctx context.Context, payload any) error
+	queue  chan delivery
+	block  bool
+
+	delivered atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+}
+
+type delivery struct {
+	ctx     context.Context
+	payload any
+}
+
+// SubscriptionStats — счётчики подписки
+type SubscriptionStats struct {
+	Name      string `json:"name"`
+	Topic     string `json:"topic"`
+	Async     bool   `json:"async"`
+	Queued    int    `json:"queued"`
+	Delivered int64  `json:"delivered"`
+	Failed    int64  `json:"failed"`
+	Dropped   int64  `json:"dropped"`
 }
 
-type EventBus struct {
-	bus chan Event
+// Hook добавляет синхронный обработчик. Hook-и темы вызываются по порядку добавления,
+// первая ошибка прерывает публикацию
+func Hook[T any](b *Bus, topic Topic[T], name string, handler Handler[T]) *Subscription {
+	sub := newSubscription(topic, name, handler)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.hooks[topic.name] = append(b.hooks[topic.name], sub)
+	return sub
 }
 
-func NewEventBus() *EventBus {
-	return &EventBus{
-		bus: make(chan Event),
+// Subscribe добавляет асинхронного подписчика со своей очередью и горутиной.
+// Ошибки обработчика только логируются: публикующий о них не узнаёт
+func Subscribe[T any](b *Bus, topic Topic[T], name string, handler Handler[T], opts Options) *Subscription {
+	sub := newSubscription(topic, name, handler)
+	buffer := opts.Buffer
+	if buffer <= 0 {
+		buffer = DefaultBuffer
 	}
+	sub.queue = make(chan delivery, buffer)
+	sub.block = opts.Block
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.queue)
+		return sub
+	}
+	b.subs[topic.name] = append(b.subs[topic.name], sub)
+	b.wg.Add(1)
+	go b.run(sub)
+	return sub
+}
+
+func newSubscription[T any](topic Topic[T], name string, handler Handler[T]) *Subscription {
+	return &Subscription{
+		Name:  name,
+		Topic: topic.name,
+		handle: func(ctx context.Context, payload any) error {
+			return handler(ctx, payload.(T))
+		},
+	}
+}
+
+// Publish вызывает синхронные обработчики, затем раздаёт событие асинхронным подписчикам
+func Publish[T any](ctx context.Context, b *Bus, topic Topic[T], payload T) error {
+	if err := Dispatch(ctx, b, topic, payload); err != nil {
+		return err
+	}
+	return Notify(ctx, b, topic, payload)
 }
 
-func (e *EventBus) Publish(event Event) { // метод публикации(отправитель)
-	e.bus <- event
+// Dispatch вызывает только синхронные обработчики. Внутри транзакции используется Dispatch,
+// а Notify — после фиксации, чтобы асинхронные подписчики не увидели откаченное изменение
+func Dispatch[T any](ctx context.Context, b *Bus, topic Topic[T], payload T) error {
+	b.mu.RLock()
+	hooks := b.hooks[topic.name]
+	b.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook.call(ctx, payload); err != nil {
+			return fmt.Errorf("event %s: hook %s: %w", topic.name, hook.Name, err)
+		}
+	}
+	return nil
 }
 
-func (e *EventBus) Subscribe() <-chan Event { // получение СООБЩЕНИЯ!! канала  <-chan!!
-	return e.bus
+// Notify раздаёт событие асинхронным подписчикам. Если очередь подписчика заполнена,
+// событие для него отбрасывается (или ожидает места, если подписка с Block).
+// Контекст доставки наследует значения ctx, но не его отмену.
+// Блокировка шины на время ожидания не держится: Close и подписка не ждут отправку
+func Notify[T any](ctx context.Context, b *Bus, topic Topic[T], payload T) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrBusClosed
+	}
+	// очереди закрываются только после того, как все начатые отправки завершатся
+	b.senders.Add(1)
+	defer b.senders.Done()
+	subs := b.subs[topic.name]
+	b.mu.RUnlock()
+
+	d := delivery{ctx: context.WithoutCancel(ctx), payload: payload}
+	var errs []error
+	for _, sub := range subs {
+		if sub.block {
+			select {
+			case sub.queue <- d:
+			case <-ctx.Done():
+				sub.dropped.Add(1)
+				errs = append(errs, fmt.Errorf("event %s: subscriber %s: %w", topic.name, sub.Name, ctx.Err()))
+			case <-b.closing:
+				sub.dropped.Add(1)
+				errs = append(errs, fmt.Errorf("event %s: subscriber %s: %w", topic.name, sub.Name, ErrBusClosed))
+			}
+			continue
+		}
+		select {
+		case sub.queue <- d:
+		default:
+			sub.dropped.Add(1)
+			logger.Errorf("event %s: subscriber %s: %v, event dropped", topic.name, sub.Name, ErrBufferFull)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *Bus) run(sub *Subscription) {
+	defer b.wg.Done()
+	for d := range sub.queue {
+		if err := sub.call(d.ctx, d.payload); err != nil {
+			logger.Errorf("event %s: subscriber %s: %v", sub.Topic, sub.Name, err)
+		}
+	}
+}
+
+// call вызывает обработчик, превращая панику в ошибку
+func (sub *Subscription) call(ctx context.Context, payload any) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+		if err != nil {
+			sub.failed.Add(1)
+		} else {
+			sub.delivered.Add(1)
+		}
+	}()
+	return sub.handle(ctx, payload)
+}
+
+// Close перестаёт принимать события и ждёт, пока асинхронные подписчики
+// обработают свои очереди, но не дольше ctx. Отправки, ждущие места в очереди,
+// прерываются с ErrBusClosed
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	first := !b.closed
+	if first {
+		b.closed = true
+		close(b.closing)
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		if first {
+			b.senders.Wait()
+			b.mu.RLock()
+			for _, subs := range b.subs {
+				for _, sub := range subs {
+					close(sub.queue)
+				}
+			}
+			b.mu.RUnlock()
+		}
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bus) Stats() []SubscriptionStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var stats []SubscriptionStats
+	collect := func(subs map[string][]*Subscription) {
+		for _, list := range subs {
+			for _, sub := range list {
+				stats = append(stats, SubscriptionStats{
+					Name:      sub.Name,
+					Topic:     sub.Topic,
+					Async:     sub.queue != nil,
+					Queued:    len(sub.queue),
+					Delivered: sub.delivered.Load(),
+					Failed:    sub.failed.Load(),
+					Dropped:   sub.dropped.Load(),
+				})
+			}
+		}
+	}
+	collect(b.hooks)
+	collect(b.subs)
+	return stats
 }
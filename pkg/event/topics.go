@@ -0,0 +1,13 @@
+package event
+
+import "time"
+
+// Темы шины внутри процесса
+
+// LinkVisitedData — переход по короткой ссылке
+type LinkVisitedData struct {
+	LinkID    uint
+	VisitedAt time.Time
+}
+
+var LinkVisited = NewTopic[LinkVisitedData]("link.visited")
@@ -0,0 +1,75 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels ограничивает размер декодируемого изображения, чтобы маленький
+// файл с огромными заявленными размерами не занял всю память
+const MaxPixels = 100_000_000
+
+const DefaultJPEGQuality = 85
+
+var ErrTooLarge = errors.New("image dimensions are too large")
+
+// Renditions — ссылки на производные изображения по их именам, например "card" и "card_webp"
+type Renditions map[string]string
+
+// Decode читает изображение любого зарегистрированного формата (jpeg, png, gif, webp),
+// предварительно проверяя размеры по заголовку
+func Decode(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Fit уменьшает изображение, вписывая его в maxWidth x maxHeight с сохранением пропорций.
+// Изображения меньше рамки не увеличиваются
+func Fit(src image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxWidth && height <= maxHeight {
+		return src
+	}
+
+	scale := min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height))
+	dstWidth := max(1, int(float64(width)*scale+0.5))
+	dstHeight := max(1, int(float64(height)*scale+0.5))
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, xdraw.Over, nil)
+	return dst
+}
+
+// EncodeJPEG кодирует изображение в JPEG. Прозрачные области заливаются белым,
+// так как JPEG не поддерживает альфа-канал
+func EncodeJPEG(w io.Writer, img image.Image, quality int) error {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+	return jpeg.Encode(w, flat, &jpeg.Options{Quality: quality})
+}
+
+// EncodeWebP кодирует изображение в WebP без потерь
+func EncodeWebP(w io.Writer, img image.Image) error {
+	return nativewebp.Encode(w, img, nil)
+}
@@ -0,0 +1,128 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ShopOnGO/ShopOnGO/pkg/logger"
+)
+
+// DefaultShutdownTimeout — сколько ждать остановки всех компонентов, если не задано в конфиге
+const DefaultShutdownTimeout = 30 * time.Second
+
+// StartFunc запускает компонент и не должен блокироваться: долгую работу
+// компонент ведёт в своих горутинах. ctx — корневой контекст приложения,
+// он отменяется только после остановки всех компонентов
+type StartFunc func(ctx context.Context) error
+
+// StopFunc останавливает компонент. ctx ограничен общим дедлайном остановки
+type StopFunc func(ctx context.Context) error
+
+type component struct {
+	name  string
+	start StartFunc
+	stop  StopFunc
+}
+
+// Manager запускает компоненты в порядке добавления и останавливает в обратном:
+// по сигналу SIGINT/SIGTERM, отмене родительского контекста или вызову Fail
+type Manager struct {
+	ShutdownTimeout time.Duration
+
+	components []component
+	failed     chan error
+	failOnce   sync.Once
+}
+
+func NewManager(shutdownTimeout time.Duration) *Manager {
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
+	return &Manager{
+		ShutdownTimeout: shutdownTimeout,
+		failed:          make(chan error, 1),
+	}
+}
+
+// Add регистрирует компонент; start и stop могут быть nil
+func (m *Manager) Add(name string, start StartFunc, stop StopFunc) {
+	m.components = append(m.components, component{name: name, start: start, stop: stop})
+}
+
+// Fail сообщает о фатальной ошибке компонента после запуска (например, Serve
+// вернул ошибку) и запускает остановку приложения. Учитывается первая ошибка
+func (m *Manager) Fail(err error) {
+	m.failOnce.Do(func() {
+		m.failed <- err
+	})
+}
+
+// Run запускает компоненты и блокируется до сигнала остановки, затем
+// останавливает запущенные компоненты в пределах ShutdownTimeout
+func (m *Manager) Run(parent context.Context) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	started := 0
+	var runErr error
+	for _, c := range m.components {
+		if c.start != nil {
+			logger.Infof("Starting %s", c.name)
+			if err := c.start(ctx); err != nil {
+				runErr = fmt.Errorf("start %s: %w", c.name, err)
+				break
+			}
+		}
+		started++
+	}
+
+	if runErr == nil {
+		logger.Info("Application started")
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		select {
+		case sig := <-signals:
+			logger.Infof("Received %s, shutting down", sig)
+		case <-parent.Done():
+			logger.Info("Context cancelled, shutting down")
+		case err := <-m.failed:
+			runErr = err
+			logger.Errorf("Component failed, shutting down: %v", err)
+		}
+	} else {
+		logger.Errorf("%v", runErr)
+	}
+
+	return errors.Join(runErr, m.shutdown(m.components[:started]))
+}
+
+func (m *Manager) shutdown(components []component) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		if c.stop == nil {
+			continue
+		}
+		logger.Infof("Stopping %s", c.name)
+		if err := c.stop(ctx); err != nil {
+			logger.Errorf("Error due stopping %s: %v", c.name, err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.name, err))
+		}
+	}
+	if ctx.Err() != nil {
+		errs = append(errs, fmt.Errorf("shutdown deadline %v exceeded", m.ShutdownTimeout))
+	}
+	logger.Info("Application stopped")
+	return errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+)
+
+// Workers — фоновые циклы вида Run(ctx), которые завершаются отменой контекста.
+// Start и Stop подходят для Manager.Add
+type Workers struct {
+	funcs  []func(ctx context.Context)
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Go добавляет цикл; запускается он в Start
+func (w *Workers) Go(fn func(ctx context.Context)) {
+	w.funcs = append(w.funcs, fn)
+}
+
+func (w *Workers) Start(ctx context.Context) error {
+	ctx, w.cancel = context.WithCancel(ctx)
+	for _, fn := range w.funcs {
+		w.wg.Add(1)
+		go func(fn func(ctx context.Context)) {
+			defer w.wg.Done()
+			fn(ctx)
+		}(fn)
+	}
+	return nil
+}
+
+// Stop отменяет контекст циклов и ждёт их завершения, но не дольше ctx
+func (w *Workers) Stop(ctx context.Context) error {
+	if w.cancel == nil {
+		return nil
+	}
+	w.cancel()
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,44 @@
+package money
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Currency описывает валюту ISO 4217 и правило округления розничных цен
+type Currency struct {
+	Code     string
+	Exponent int32 // число знаков после запятой по ISO 4217
+	// Step — шаг округления цены. Нулевой шаг означает минимальную единицу валюты.
+	// Например, тиыны не в обращении, поэтому цены в тенге округляются до целого
+	Step decimal.Decimal
+}
+
+var currencies = map[string]Currency{
+	"RUB": {Code: "RUB", Exponent: 2},
+	"BYN": {Code: "BYN", Exponent: 2},
+	"KZT": {Code: "KZT", Exponent: 2, Step: decimal.NewFromInt(1)},
+	"USD": {Code: "USD", Exponent: 2},
+	"EUR": {Code: "EUR", Exponent: 2},
+}
+
+// LookupCurrency возвращает валюту по коду ISO 4217 без учёта регистра
+func LookupCurrency(code string) (Currency, error) {
+	c, ok := currencies[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Currency{}, ErrUnknownCurrency
+	}
+	return c, nil
+}
+
+// Round округляет сумму по правилам валюты (половина — вверх)
+func (c Currency) Round(amount decimal.Decimal) decimal.Decimal {
+	if c.Step.IsPositive() {
+		return amount.Div(c.Step).Round(0).Mul(c.Step)
+	}
+	return amount.Round(c.Exponent)
+}
@@ -0,0 +1,56 @@
+package money
+
+import (
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money — сумма в конкретной валюте. Сумма всегда округлена по правилам валюты
+type Money struct {
+	Amount   decimal.Decimal
+	Currency Currency
+}
+
+// New создаёт сумму в валюте code, округляя её по правилам валюты
+func New(amount decimal.Decimal, code string) (Money, error) {
+	c, err := LookupCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: c.Round(amount), Currency: c}, nil
+}
+
+// FromMinor создаёт сумму из минимальных единиц валюты (копеек, тиынов)
+func FromMinor(minor int64, code string) (Money, error) {
+	c, err := LookupCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: c.Round(decimal.New(minor, -c.Exponent)), Currency: c}, nil
+}
+
+// Minor возвращает сумму в минимальных единицах валюты
+func (m Money) Minor() int64 {
+	return m.Amount.Shift(m.Currency.Exponent).IntPart()
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency.Code != other.Currency.Code {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount.Add(other.Amount), Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency.Code != other.Currency.Code {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount.Sub(other.Amount), Currency: m.Currency}, nil
+}
+
+func (m Money) String() string {
+	return m.Amount.StringFixed(m.Currency.Exponent) + " " + m.Currency.Code
+}
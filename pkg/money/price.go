@@ -0,0 +1,53 @@
+package money
+
+import (
+	"errors"
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+// Скидка у товаров и вариантов — всегда абсолютная сумма в валюте цены, не процент.
+// Итоговая цена = Price - Discount
+
+var (
+	ErrInvalidPrice    = errors.New("price must be positive")
+	ErrInvalidDiscount = errors.New("discount must be non-negative and less than price")
+	ErrPriceTooLarge   = errors.New("price exceeds maximum supported value")
+)
+
+// MaxUint32Cents — предел цены для полей uint32 в admin-proto (42 949 672.95)
+var MaxUint32Cents = CentsToDecimal(math.MaxUint32)
+
+// FinalPrice возвращает цену с учётом скидки, не меньше нуля
+func FinalPrice(price, discount decimal.Decimal) decimal.Decimal {
+	final := price.Sub(discount)
+	if final.IsNegative() {
+		return decimal.Zero
+	}
+	return final
+}
+
+// ValidatePrice проверяет, что цена положительна, а скидка меньше цены
+func ValidatePrice(price, discount decimal.Decimal) error {
+	if !price.IsPositive() {
+		return ErrInvalidPrice
+	}
+	if discount.IsNegative() || !discount.LessThan(price) {
+		return ErrInvalidDiscount
+	}
+	return nil
+}
+
+// DecimalToUint32Cents переводит сумму в копейки для полей uint32.
+// При переполнении возвращает math.MaxUint32 и ok == false
+func DecimalToUint32Cents(d decimal.Decimal) (cents uint32, ok bool) {
+	c := DecimalToCents(d)
+	switch {
+	case c < 0:
+		return 0, false
+	case c > math.MaxUint32:
+		return math.MaxUint32, false
+	}
+	return uint32(c), true
+}
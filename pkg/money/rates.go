@@ -0,0 +1,56 @@
+package money
+
+import (
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrNoRate = errors.New("exchange rate not found")
+
+// RateTable — курсы валют относительно базовой: 1 Base = Rates[code] code
+type RateTable struct {
+	Base  string
+	Rates map[string]decimal.Decimal
+}
+
+func NewRateTable(base string) *RateTable {
+	return &RateTable{
+		Base:  base,
+		Rates: map[string]decimal.Decimal{base: decimal.NewFromInt(1)},
+	}
+}
+
+// Set задаёт курс валюты code к базовой
+func (t *RateTable) Set(code string, rate decimal.Decimal) {
+	t.Rates[code] = rate
+}
+
+// Rate возвращает, сколько to стоит одна единица from. Кросс-курс считается через базовую валюту
+func (t *RateTable) Rate(from, to string) (decimal.Decimal, error) {
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+	fromRate, ok := t.Rates[from]
+	if !ok || !fromRate.IsPositive() {
+		return decimal.Zero, ErrNoRate
+	}
+	toRate, ok := t.Rates[to]
+	if !ok || !toRate.IsPositive() {
+		return decimal.Zero, ErrNoRate
+	}
+	return toRate.Div(fromRate), nil
+}
+
+// Convert переводит сумму в валюту to и округляет по её правилам
+func (t *RateTable) Convert(m Money, to string) (Money, error) {
+	target, err := LookupCurrency(to)
+	if err != nil {
+		return Money{}, err
+	}
+	rate, err := t.Rate(m.Currency.Code, target.Code)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: target.Round(m.Amount.Mul(rate)), Currency: target}, nil
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	DefaultLocalRoot = "./media"
+	// ссылки на изображения проверяются валидаторами товаров как абсолютные http(s)
+	DefaultLocalBaseURL = "http://localhost/media"
+)
+
+// LocalStorage хранит файлы на локальном диске в Root, раздаются они по BaseURL
+// внешним веб-сервером
+type LocalStorage struct {
+	Root    string
+	BaseURL string
+}
+
+func NewLocalStorage(root, baseURL string) *LocalStorage {
+	if root == "" {
+		root = DefaultLocalRoot
+	}
+	if baseURL == "" {
+		baseURL = DefaultLocalBaseURL
+	}
+	return &LocalStorage{
+		Root:    root,
+		BaseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Put пишет файл во временный и переименовывает, чтобы не отдавать недописанный файл
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(target)
+}
+
+// Delete не считает ошибкой отсутствие файла: очистка может повторяться
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.BaseURL + "/" + key
+}
+
+func (s *LocalStorage) KeyOf(url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, s.BaseURL+"/")
+	if !ok {
+		return "", false
+	}
+	if _, err := s.path(key); err != nil {
+		return "", false
+	}
+	return key, true
+}
+
+// path не даёт ключу выйти за пределы Root
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || clean[1:] != key {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(key)), nil
+}
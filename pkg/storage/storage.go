@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage — хранилище файлов медиа. Ключ — относительный путь вида "product/12/abc.jpg",
+// публичная ссылка строится из ключа. Реализации: LocalStorage, S3-совместимая — позже
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+	// KeyOf возвращает ключ файла по его публичной ссылке, если файл лежит в этом хранилище
+	KeyOf(url string) (string, bool)
+}
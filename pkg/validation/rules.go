@@ -0,0 +1,137 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+func fail(field, description string) []FieldError {
+	return []FieldError{{Field: field, Description: description}}
+}
+
+// Check — правило из произвольного условия: ok == false даёт ошибку description
+func Check[T any](field, description string, ok func(T) bool) Rule[T] {
+	return func(entity T) []FieldError {
+		if ok(entity) {
+			return nil
+		}
+		return fail(field, description)
+	}
+}
+
+func Required[T any](field string, get func(T) string) Rule[T] {
+	return func(entity T) []FieldError {
+		if strings.TrimSpace(get(entity)) == "" {
+			return fail(field, "is required")
+		}
+		return nil
+	}
+}
+
+func MaxLength[T any](field string, max int, get func(T) string) Rule[T] {
+	return func(entity T) []FieldError {
+		if len([]rune(get(entity))) > max {
+			return fail(field, fmt.Sprintf("must be at most %d characters", max))
+		}
+		return nil
+	}
+}
+
+// Pattern проверяет непустое значение регулярным выражением
+func Pattern[T any](field string, re *regexp.Regexp, description string, get func(T) string) Rule[T] {
+	return func(entity T) []FieldError {
+		if value := get(entity); value != "" && !re.MatchString(value) {
+			return fail(field, description)
+		}
+		return nil
+	}
+}
+
+// Barcode проверяет контрольную цифру штрих-кода товара (EAN-8, UPC-A, EAN-13). Пустое значение допустимо.
+// GTIN-14 обозначает транспортную упаковку и печатается как ITF-14, которого нет в pkg/barcode, поэтому не принимается
+func Barcode[T any](field string, get func(T) string) Rule[T] {
+	return func(entity T) []FieldError {
+		if value := get(entity); value != "" && (len(value) == 14 || !ValidGTIN(value)) {
+			return fail(field, "must be a valid EAN-8, UPC-A or EAN-13 with correct check digit")
+		}
+		return nil
+	}
+}
+
+// URL проверяет, что непустое значение — абсолютный http(s) адрес
+func URL[T any](field string, get func(T) string) Rule[T] {
+	return func(entity T) []FieldError {
+		if value := get(entity); value != "" && !validHTTPURL(value) {
+			return fail(field, "must be an absolute http or https URL")
+		}
+		return nil
+	}
+}
+
+// ImageURLs проверяет каждый адрес изображения, ошибки указывают индекс: images[2]
+func ImageURLs[T any](field string, get func(T) []string) Rule[T] {
+	return func(entity T) []FieldError {
+		var errs []FieldError
+		for i, value := range get(entity) {
+			if !ValidImageURL(value) {
+				errs = append(errs, FieldError{
+					Field:       fmt.Sprintf("%s[%d]", field, i),
+					Description: "must be an http(s) URL of a jpg, jpeg, png, webp or gif image",
+				})
+			}
+		}
+		return errs
+	}
+}
+
+var imageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".webp": true, ".gif": true,
+}
+
+// ValidImageURL — абсолютный http(s) адрес с расширением изображения
+func ValidImageURL(value string) bool {
+	if !validHTTPURL(value) {
+		return false
+	}
+	u, _ := url.Parse(value)
+	return imageExtensions[strings.ToLower(path.Ext(u.Path))]
+}
+
+func validHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// ValidGTIN проверяет длину и контрольную цифру GTIN-8/12/13/14
+func ValidGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return GTINCheckDigit(code[:len(code)-1]) == code[len(code)-1]
+}
+
+// GTINCheckDigit считает контрольную цифру GTIN по алгоритму GS1 (mod 10, веса 3 и 1 справа налево)
+func GTINCheckDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
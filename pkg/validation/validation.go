@@ -0,0 +1,79 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FieldError — ошибка конкретного поля сущности
+type FieldError struct {
+	Field       string
+	Description string
+}
+
+// Errors — все нарушения, найденные при проверке сущности
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, fe := range e {
+		parts = append(parts, fe.Field+": "+fe.Description)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Rule проверяет одно условие. Правило может вернуть несколько ошибок, например по элементам списка
+type Rule[T any] func(entity T) []FieldError
+
+// Validator — набор правил для сущности. Правила выполняются все, ошибки накапливаются
+type Validator[T any] struct {
+	rules []Rule[T]
+}
+
+func New[T any](rules ...Rule[T]) *Validator[T] {
+	return &Validator[T]{rules: rules}
+}
+
+// Add добавляет правила, например специфичные для категории или канала продаж
+func (v *Validator[T]) Add(rules ...Rule[T]) *Validator[T] {
+	v.rules = append(v.rules, rules...)
+	return v
+}
+
+// Validate возвращает Errors со всеми нарушениями или nil
+func (v *Validator[T]) Validate(entity T) error {
+	var errs Errors
+	for _, rule := range v.rules {
+		errs = append(errs, rule(entity)...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ToStatus переводит ошибку валидации в codes.InvalidArgument.
+// Для Errors в статус добавляется errdetails.BadRequest с нарушениями по полям
+func ToStatus(err error) error {
+	var errs Errors
+	if !errors.As(err, &errs) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, fe := range errs {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fe.Field,
+			Description: fe.Description,
+		})
+	}
+	st, detailsErr := status.New(codes.InvalidArgument, errs.Error()).WithDetails(badRequest)
+	if detailsErr != nil {
+		return status.Error(codes.InvalidArgument, errs.Error())
+	}
+	return st.Err()
+}